@@ -88,7 +88,7 @@ func Test_ValidateTest(t *testing.T) {
 			out, err = cmd.RunAndReturnTrimmedCombinedOutput()
 		}, runtimeLimit)
 		require.NoError(t, err)
-		expected := "Config is valid: \x1b[32;1mtrue\x1b[0m\nWarning(s):\n- invalid pipeline ID (invalid:id): doesn't conform to: [A-Za-z0-9-_.]"
+		expected := fmt.Sprintf("Config is valid: \x1b[32;1mtrue\x1b[0m\nWarning(s):\n- %s:5:3: invalid pipeline ID (invalid:id): doesn't conform to: [A-Za-z0-9-_.]", configPth)
 		require.Equal(t, expected, out)
 		require.Equal(t, true, elapsed < runtimeLimit, runningTimeMsg, elapsed, elapsed-runtimeLimit)
 	}
@@ -105,7 +105,7 @@ func Test_ValidateTest(t *testing.T) {
 			out, err = cmd.RunAndReturnTrimmedCombinedOutput()
 		}, runtimeLimit)
 		require.NoError(t, err)
-		expected := "Config is valid: \x1b[32;1mtrue\x1b[0m\nWarning(s):\n- invalid workflow ID (invalid:id): doesn't conform to: [A-Za-z0-9-_.]"
+		expected := fmt.Sprintf("Config is valid: \x1b[32;1mtrue\x1b[0m\nWarning(s):\n- %s:5:3: invalid workflow ID (invalid:id): doesn't conform to: [A-Za-z0-9-_.]", configPth)
 		require.Equal(t, expected, out)
 		require.Equal(t, true, elapsed < runtimeLimit, runningTimeMsg, elapsed, elapsed-runtimeLimit)
 	}
@@ -173,7 +173,7 @@ func Test_ValidateTestJSON(t *testing.T) {
 			out, err = cmd.RunAndReturnTrimmedCombinedOutput()
 		}, runtimeLimit)
 		require.NoError(t, err)
-		expected := "{\"data\":{\"config\":{\"is_valid\":true,\"warnings\":[\"invalid pipeline ID (invalid:id): doesn't conform to: [A-Za-z0-9-_.]\"]}}}"
+		expected := fmt.Sprintf("{\"data\":{\"config\":{\"is_valid\":true,\"warnings\":[\"invalid pipeline ID (invalid:id): doesn't conform to: [A-Za-z0-9-_.]\"],\"issues\":[{\"level\":\"warning\",\"message\":\"invalid pipeline ID (invalid:id): doesn't conform to: [A-Za-z0-9-_.]\",\"path\":\"pipelines.invalid:id\",\"file\":\"%s\",\"line\":5,\"column\":3}]}}}", configPth)
 		require.Equal(t, expected, out)
 		require.Equal(t, true, elapsed < runtimeLimit, runningTimeMsg, elapsed, elapsed-runtimeLimit)
 	}
@@ -190,7 +190,7 @@ func Test_ValidateTestJSON(t *testing.T) {
 			out, err = cmd.RunAndReturnTrimmedCombinedOutput()
 		}, runtimeLimit)
 		require.NoError(t, err)
-		expected := "{\"data\":{\"config\":{\"is_valid\":true,\"warnings\":[\"invalid workflow ID (invalid:id): doesn't conform to: [A-Za-z0-9-_.]\"]}}}"
+		expected := fmt.Sprintf("{\"data\":{\"config\":{\"is_valid\":true,\"warnings\":[\"invalid workflow ID (invalid:id): doesn't conform to: [A-Za-z0-9-_.]\"],\"issues\":[{\"level\":\"warning\",\"message\":\"invalid workflow ID (invalid:id): doesn't conform to: [A-Za-z0-9-_.]\",\"path\":\"workflows.invalid:id\",\"file\":\"%s\",\"line\":5,\"column\":3}]}}}", configPth)
 		require.Equal(t, expected, out)
 		require.Equal(t, true, elapsed < runtimeLimit, runningTimeMsg, elapsed, elapsed-runtimeLimit)
 	}
@@ -224,7 +224,7 @@ func Test_ValidateTestJSON(t *testing.T) {
 			out, err = cmd.RunAndReturnTrimmedCombinedOutput()
 		}, runtimeLimit)
 		require.Error(t, err, out)
-		expected := fmt.Sprintf("{\"data\":{\"config\":{\"is_valid\":false,\"error\":\"Config (path:%s) is not valid: missing format_version\"}}}", configPth)
+		expected := fmt.Sprintf("{\"data\":{\"config\":{\"is_valid\":false,\"error\":\"Config (path:%s) is not valid: missing format_version\",\"issues\":[{\"level\":\"error\",\"message\":\"missing format_version\"}]}}}", configPth)
 		require.Equal(t, expected, out)
 		require.Equal(t, true, elapsed < runtimeLimit, runningTimeMsg, elapsed, elapsed-runtimeLimit)
 	}
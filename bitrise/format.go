@@ -0,0 +1,296 @@
+package bitrise
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+
+	envmanModels "github.com/bitrise-io/envman/models"
+	"github.com/tothszabi/bitrise-test/models"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	formatIndent = 2
+	// blankLineMarker is a temporary head comment, which marks the blank lines of the source config in the formatted config.
+	blankLineMarker = "#bitrise-fmt-blank-line"
+)
+
+var (
+	environmentItemType        = reflect.TypeOf(envmanModels.EnvironmentItemModel{})
+	environmentItemOptionsType = reflect.TypeOf(envmanModels.EnvironmentItemOptionsModel{})
+)
+
+// FormatConfig formats the given bitrise config (YAML) on the YAML node tree:
+// the indentation is canonicalized to 2 spaces and the keys of the known sections are ordered
+// by the config model (models.BitriseDataModel), while comments, anchors, multiline strings,
+// the blank lines separating the keys and items, and the compact (not indented) block sequence style are preserved.
+// The order of the user defined keys (like workflow IDs) is kept.
+func FormatConfig(content []byte) ([]byte, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(content, &root); err != nil {
+		return nil, err
+	}
+	if root.Kind != yaml.DocumentNode || len(root.Content) == 0 {
+		return nil, errors.New("empty config")
+	}
+
+	markBlankLines(root.Content[0], strings.Split(string(content), "\n"), map[int]bool{})
+	compactSequences := hasCompactSequences(root.Content[0])
+
+	formatNode(root.Content[0], reflect.TypeOf(models.BitriseDataModel{}))
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(formatIndent)
+	if err := encoder.Encode(&root); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+
+	lines := strings.Split(buf.String(), "\n")
+	if compactSequences {
+		lines = compactSequenceLines(lines)
+	}
+	for idx, line := range lines {
+		if strings.TrimSpace(line) == blankLineMarker {
+			lines[idx] = ""
+		}
+	}
+	return []byte(strings.Join(lines, "\n")), nil
+}
+
+// markBlankLines adds a blank line marker head comment to the mapping keys and sequence items,
+// which are preceded by a blank line (above their head comment) in the source config,
+// so the blank lines move together with the reordered keys.
+func markBlankLines(node *yaml.Node, lines []string, markedLines map[int]bool) {
+	mark := func(n *yaml.Node) {
+		if markedLines[n.Line] || !isPrecededByBlankLine(lines, n.Line) {
+			return
+		}
+		markedLines[n.Line] = true
+		if n.HeadComment == "" {
+			n.HeadComment = blankLineMarker
+		} else {
+			n.HeadComment = blankLineMarker + "\n" + n.HeadComment
+		}
+	}
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			mark(node.Content[i])
+			markBlankLines(node.Content[i+1], lines, markedLines)
+		}
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			mark(item)
+			markBlankLines(item, lines, markedLines)
+		}
+	}
+}
+
+// isPrecededByBlankLine returns true if the given (1-based) line, or the comment lines right above it, follow a blank line.
+func isPrecededByBlankLine(lines []string, line int) bool {
+	for idx := line - 2; idx > 0; idx-- {
+		trimmed := strings.TrimSpace(lines[idx])
+		if trimmed == "" {
+			return true
+		}
+		if !strings.HasPrefix(trimmed, "#") {
+			return false
+		}
+	}
+	return false
+}
+
+// hasCompactSequences returns true if the source config's first block sequence, which is a mapping value,
+// is not indented relative to its key (like the steps of the generated configs).
+// Configs without such a sequence are formatted with compact sequences too.
+func hasCompactSequences(node *yaml.Node) bool {
+	compact, found := findSequenceStyle(node)
+	return compact || !found
+}
+
+func findSequenceStyle(node *yaml.Node) (bool, bool) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if value.Kind == yaml.SequenceNode && value.Style&yaml.FlowStyle == 0 && len(value.Content) > 0 && value.Line > key.Line {
+				return value.Column == key.Column, true
+			}
+			if compact, found := findSequenceStyle(value); found {
+				return compact, true
+			}
+		}
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			if compact, found := findSequenceStyle(item); found {
+				return compact, true
+			}
+		}
+	}
+	return false, false
+}
+
+// compactSequenceLines outdents the block sequences of the encoded lines to the level of their keys,
+// the encoder indents them relative to their keys.
+func compactSequenceLines(lines []string) []string {
+	// the key columns of the open block sequences
+	var keyColumns []int
+	// the indentation of the open block scalar's key, -1 if not in a block scalar
+	blockScalarIndent := -1
+
+	result := make([]string, 0, len(lines))
+	for idx, line := range lines {
+		trimmed := strings.TrimLeft(line, " ")
+		if trimmed == "" {
+			result = append(result, line)
+			continue
+		}
+		indent := len(line) - len(trimmed)
+
+		if blockScalarIndent >= 0 {
+			if indent > blockScalarIndent {
+				result = append(result, outdent(line, 2*len(keyColumns)))
+				continue
+			}
+			blockScalarIndent = -1
+		}
+
+		for len(keyColumns) > 0 && indent <= keyColumns[len(keyColumns)-1] {
+			keyColumns = keyColumns[:len(keyColumns)-1]
+		}
+		result = append(result, outdent(line, 2*len(keyColumns)))
+
+		if strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		keyColumn := indent
+		for strings.HasPrefix(line[keyColumn:], "- ") {
+			keyColumn += 2
+		}
+		if isBlockScalarHeader(line) {
+			blockScalarIndent = keyColumn
+			continue
+		}
+		if next := nextContentLine(lines, idx+1); next != "" && strings.HasPrefix(next, strings.Repeat(" ", keyColumn+formatIndent)+"-") {
+			if nextTrimmed := strings.TrimLeft(next, " "); len(next)-len(nextTrimmed) == keyColumn+formatIndent {
+				keyColumns = append(keyColumns, keyColumn)
+			}
+		}
+	}
+	return result
+}
+
+// nextContentLine returns the first line from the given index, which is neither blank nor a comment.
+func nextContentLine(lines []string, from int) string {
+	for _, line := range lines[from:] {
+		trimmed := strings.TrimSpace(line)
+		if trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+			return line
+		}
+	}
+	return ""
+}
+
+// isBlockScalarHeader returns true if the line ends with a literal or folded block scalar indicator (like `key: |-`).
+func isBlockScalarHeader(line string) bool {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return false
+	}
+	last := strings.TrimRight(fields[len(fields)-1], "0123456789+-")
+	return (last == "|" || last == ">") && len(fields) > 1
+}
+
+func outdent(line string, spaces int) string {
+	for i := 0; i < spaces && strings.HasPrefix(line, " "); i++ {
+		line = line[1:]
+	}
+	return line
+}
+
+func formatNode(node *yaml.Node, t reflect.Type) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		switch {
+		case t == environmentItemType:
+			// KEY: value first, then the opts
+			sortMappingKeys(node, []string{}, []string{envmanModels.OptionsKey})
+			if optionsNode := mappingValue(node, envmanModels.OptionsKey); optionsNode != nil {
+				formatNode(optionsNode, environmentItemOptionsType)
+			}
+		case t.Kind() == reflect.Struct:
+			fieldTypes := map[string]reflect.Type{}
+			var keyOrder []string
+			for i := 0; i < t.NumField(); i++ {
+				field := t.Field(i)
+				name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+				if field.PkgPath != "" || name == "" || name == "-" {
+					continue
+				}
+				fieldTypes[name] = field.Type
+				keyOrder = append(keyOrder, name)
+			}
+
+			sortMappingKeys(node, keyOrder, nil)
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				if fieldType, found := fieldTypes[node.Content[i].Value]; found {
+					formatNode(node.Content[i+1], fieldType)
+				}
+			}
+		case t.Kind() == reflect.Map:
+			// user defined keys (IDs): the order is kept
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				formatNode(node.Content[i+1], t.Elem())
+			}
+		}
+	case yaml.SequenceNode:
+		if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+			for _, item := range node.Content {
+				formatNode(item, t.Elem())
+			}
+		}
+	}
+	// scalars and aliases are kept as they are
+}
+
+// sortMappingKeys orders the key-value pairs of the mapping: first the keys of the leading order,
+// then the unknown keys in their original order, then the keys of the trailing order.
+func sortMappingKeys(node *yaml.Node, leadingOrder, trailingOrder []string) {
+	rank := map[string]int{}
+	for idx, key := range leadingOrder {
+		rank[key] = idx - len(leadingOrder)
+	}
+	for idx, key := range trailingOrder {
+		rank[key] = idx + 1
+	}
+
+	type pair struct {
+		key, value *yaml.Node
+	}
+	var pairs []pair
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		pairs = append(pairs, pair{key: node.Content[i], value: node.Content[i+1]})
+	}
+
+	sort.SliceStable(pairs, func(i, j int) bool {
+		return rank[pairs[i].key.Value] < rank[pairs[j].key.Value]
+	})
+
+	node.Content = node.Content[:0]
+	for _, p := range pairs {
+		node.Content = append(node.Content, p.key, p.value)
+	}
+}
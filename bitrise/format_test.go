@@ -0,0 +1,177 @@
+package bitrise
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatConfig(t *testing.T) {
+	t.Log("orders the known keys, preserves comments, anchors and multiline strings")
+	{
+		config := `workflows:
+    # the main workflow
+    primary:
+        steps:
+        - script@1:
+            inputs:
+            - opts:
+                is_expand: false
+              content: |-
+                  echo "hello"
+                  echo "world"
+            title: Hello
+        envs:
+        - &shared
+          FOO: bar # shared env
+        title: Primary
+    secondary:
+        envs:
+        - *shared
+format_version: "12"
+trigger_map:
+- workflow: primary
+  push_branch: master
+`
+		expected := `format_version: "12"
+trigger_map:
+- push_branch: master
+  workflow: primary
+workflows:
+  # the main workflow
+  primary:
+    title: Primary
+    envs:
+    - &shared
+      FOO: bar # shared env
+    steps:
+    - script@1:
+        title: Hello
+        inputs:
+        - content: |-
+            echo "hello"
+            echo "world"
+          opts:
+            is_expand: false
+  secondary:
+    envs:
+    - *shared
+`
+		formatted, err := FormatConfig([]byte(config))
+		require.NoError(t, err)
+		require.Equal(t, expected, string(formatted))
+
+		formattedAgain, err := FormatConfig(formatted)
+		require.NoError(t, err)
+		require.Equal(t, expected, string(formattedAgain))
+	}
+
+	t.Log("preserves the blank lines and the compact sequences")
+	{
+		config := `format_version: "12"
+
+workflows:
+  # the main workflow
+  primary:
+    steps:
+    - script@1:
+        inputs:
+        - content: |-
+            cat <<EOF
+            steps:
+              - not a sequence
+
+            EOF
+
+    - deploy@2: {}
+    envs:
+    - FOO: bar
+
+  secondary:
+    steps:
+    - script@1: {}
+
+trigger_map:
+- push_branch: master
+  workflow: primary
+`
+		expected := `format_version: "12"
+
+trigger_map:
+- push_branch: master
+  workflow: primary
+
+workflows:
+  # the main workflow
+  primary:
+    envs:
+    - FOO: bar
+    steps:
+    - script@1:
+        inputs:
+        - content: |-
+            cat <<EOF
+            steps:
+              - not a sequence
+
+            EOF
+
+    - deploy@2: {}
+
+  secondary:
+    steps:
+    - script@1: {}
+`
+		formatted, err := FormatConfig([]byte(config))
+		require.NoError(t, err)
+		require.Equal(t, expected, string(formatted))
+
+		formattedAgain, err := FormatConfig(formatted)
+		require.NoError(t, err)
+		require.Equal(t, expected, string(formattedAgain))
+	}
+
+	t.Log("keeps the indented sequences")
+	{
+		config := `format_version: "12"
+workflows:
+  primary:
+    steps:
+      - script@1:
+          inputs:
+            - content: echo "hello"
+`
+		formatted, err := FormatConfig([]byte(config))
+		require.NoError(t, err)
+		require.Equal(t, config, string(formatted))
+	}
+
+	t.Log("keeps the order of the IDs and the unknown keys")
+	{
+		config := `format_version: "12"
+workflows:
+  zeta:
+    custom: value
+    title: Zeta
+  alpha:
+    title: Alpha
+`
+		expected := `format_version: "12"
+workflows:
+  zeta:
+    title: Zeta
+    custom: value
+  alpha:
+    title: Alpha
+`
+		formatted, err := FormatConfig([]byte(config))
+		require.NoError(t, err)
+		require.Equal(t, expected, string(formatted))
+	}
+
+	t.Log("empty config")
+	{
+		_, err := FormatConfig([]byte(""))
+		require.EqualError(t, err, "empty config")
+	}
+}
@@ -0,0 +1,84 @@
+package bitrise
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/tothszabi/bitrise-test/configs"
+	"github.com/tothszabi/bitrise-test/log"
+)
+
+// GitStepCloneURL returns the URL the git step should be cloned from:
+// the step repository's local mirror, if it is available in the git mirrors directory, otherwise the repository URL itself.
+// In offline mode the mirror is required.
+func GitStepCloneURL(repositoryURL string) (string, error) {
+	mirrorsDir := os.Getenv(configs.GitMirrorsDirEnvKey)
+	if mirrorsDir != "" {
+		if mirrorPth, found := gitMirrorPath(mirrorsDir, repositoryURL); found {
+			log.Debugf("Using git mirror (%s) for repository: %s", mirrorPth, repositoryURL)
+			return "file://" + mirrorPth, nil
+		}
+	}
+
+	if err := configs.CheckNetworkAccess(fmt.Sprintf("cloning %s", repositoryURL)); err != nil {
+		if mirrorsDir == "" {
+			return "", fmt.Errorf("%s, set %s to use local git mirrors", err, configs.GitMirrorsDirEnvKey)
+		}
+		return "", fmt.Errorf("%s, no mirror found in %s", err, mirrorsDir)
+	}
+	return repositoryURL, nil
+}
+
+// gitMirrorPath returns the repository's mirror in the mirrors directory,
+// mirrors are looked up by host and repository path, with or without the .git suffix
+// (for example https://github.com/bitrise-steplib/steps-script.git -> <dir>/github.com/bitrise-steplib/steps-script.git).
+func gitMirrorPath(mirrorsDir, repositoryURL string) (string, bool) {
+	host, repositoryPth, ok := parseGitRepositoryURL(repositoryURL)
+	if !ok {
+		return "", false
+	}
+
+	repositoryPth = strings.TrimSuffix(repositoryPth, ".git")
+	for _, candidate := range []string{repositoryPth + ".git", repositoryPth} {
+		mirrorPth := filepath.Join(mirrorsDir, host, filepath.FromSlash(candidate))
+		if exist, err := pathutil.IsDirExists(mirrorPth); err != nil {
+			log.Warnf("Failed to check git mirror (%s): %s", mirrorPth, err)
+		} else if exist {
+			return mirrorPth, true
+		}
+	}
+	return "", false
+}
+
+// parseGitRepositoryURL returns the host and the path of a git repository URL,
+// both the URL (https://host/path, ssh://git@host/path) and the scp-like (git@host:path) forms are supported.
+func parseGitRepositoryURL(repositoryURL string) (string, string, bool) {
+	var host, repositoryPth string
+	if strings.Contains(repositoryURL, "://") {
+		u, err := url.Parse(repositoryURL)
+		if err != nil {
+			return "", "", false
+		}
+		host, repositoryPth = u.Hostname(), u.Path
+	} else {
+		split := strings.SplitN(repositoryURL, ":", 2)
+		if len(split) != 2 {
+			return "", "", false
+		}
+		host, repositoryPth = split[0], split[1]
+		if idx := strings.LastIndex(host, "@"); idx != -1 {
+			host = host[idx+1:]
+		}
+	}
+
+	repositoryPth = strings.Trim(path.Clean("/"+repositoryPth), "/")
+	if host == "" || strings.ContainsAny(host, `/\`) || host == "." || host == ".." || repositoryPth == "" {
+		return "", "", false
+	}
+	return host, repositoryPth, true
+}
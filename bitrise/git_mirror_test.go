@@ -0,0 +1,66 @@
+package bitrise
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tothszabi/bitrise-test/configs"
+)
+
+func TestParseGitRepositoryURL(t *testing.T) {
+	for repositoryURL, expected := range map[string][]string{
+		"https://github.com/bitrise-steplib/steps-script.git":   {"github.com", "bitrise-steplib/steps-script.git"},
+		"ssh://git@gitlab.example.com:22/group/sub/step":        {"gitlab.example.com", "group/sub/step"},
+		"git@github.com:bitrise-steplib/steps-script.git":       {"github.com", "bitrise-steplib/steps-script.git"},
+		"https://github.com/../../bitrise-steplib/steps-script": {"github.com", "bitrise-steplib/steps-script"},
+	} {
+		host, repositoryPth, ok := parseGitRepositoryURL(repositoryURL)
+		require.True(t, ok, repositoryURL)
+		require.Equal(t, expected, []string{host, repositoryPth}, repositoryURL)
+	}
+
+	for _, repositoryURL := range []string{"steps-script", "https://github.com", "..:repo"} {
+		_, _, ok := parseGitRepositoryURL(repositoryURL)
+		require.False(t, ok, repositoryURL)
+	}
+}
+
+func TestGitStepCloneURL(t *testing.T) {
+	mirrorsDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(mirrorsDir, "github.com", "bitrise-steplib", "steps-script.git"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(mirrorsDir, "github.com", "bitrise-steplib", "steps-deploy"), 0755))
+	t.Setenv(configs.GitMirrorsDirEnvKey, mirrorsDir)
+
+	defer func() { configs.IsOfflineMode = false }()
+	configs.IsOfflineMode = true
+
+	t.Log("mirror with .git suffix")
+	{
+		cloneURL, err := GitStepCloneURL("git@github.com:bitrise-steplib/steps-script.git")
+		require.NoError(t, err)
+		require.Equal(t, "file://"+filepath.Join(mirrorsDir, "github.com", "bitrise-steplib", "steps-script.git"), cloneURL)
+	}
+
+	t.Log("mirror without .git suffix")
+	{
+		cloneURL, err := GitStepCloneURL("https://github.com/bitrise-steplib/steps-deploy.git")
+		require.NoError(t, err)
+		require.Equal(t, "file://"+filepath.Join(mirrorsDir, "github.com", "bitrise-steplib", "steps-deploy"), cloneURL)
+	}
+
+	t.Log("no mirror in offline mode")
+	{
+		_, err := GitStepCloneURL("https://github.com/bitrise-steplib/steps-git-clone.git")
+		require.EqualError(t, err, "offline mode: cloning https://github.com/bitrise-steplib/steps-git-clone.git requires network access, no mirror found in "+mirrorsDir)
+	}
+
+	t.Log("no mirror in online mode")
+	{
+		configs.IsOfflineMode = false
+		cloneURL, err := GitStepCloneURL("https://github.com/bitrise-steplib/steps-git-clone.git")
+		require.NoError(t, err)
+		require.Equal(t, "https://github.com/bitrise-steplib/steps-git-clone.git", cloneURL)
+	}
+}
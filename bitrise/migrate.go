@@ -0,0 +1,292 @@
+package bitrise
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/versions"
+	"github.com/tothszabi/bitrise-test/models"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	deprecatedPatternKey              = "pattern"
+	deprecatedIsPullRequestAllowedKey = "is_pull_request_allowed"
+	pushBranchKey                     = "push_branch"
+	pullRequestSourceBranchKey        = "pull_request_source_branch"
+)
+
+// MigrationChange is a change made by MigrateConfig,
+// Line is the line of the changed element in the original config.
+type MigrationChange struct {
+	Line    int    `json:"line" yaml:"line"`
+	Message string `json:"message" yaml:"message"`
+}
+
+// String ...
+func (change MigrationChange) String() string {
+	return fmt.Sprintf("line %d: %s", change.Line, change.Message)
+}
+
+// lineEdits collects line based modifications of a document:
+// the untouched lines (including comments and formatting) are kept as they are.
+type lineEdits struct {
+	lines []string
+	// replacements by line index, a replacement can consist of multiple lines or no lines at all (removal)
+	replacements map[int][]string
+}
+
+func newLineEdits(content []byte) *lineEdits {
+	return &lineEdits{
+		lines:        strings.Split(string(content), "\n"),
+		replacements: map[int][]string{},
+	}
+}
+
+// line returns the given (1-based) line.
+func (edits *lineEdits) line(line int) string {
+	return edits.lines[line-1]
+}
+
+// replace replaces the given (1-based) line with the given lines.
+func (edits *lineEdits) replace(line int, newLines ...string) {
+	edits.replacements[line-1] = newLines
+}
+
+func (edits *lineEdits) isChanged() bool {
+	return len(edits.replacements) > 0
+}
+
+func (edits *lineEdits) bytes() []byte {
+	var lines []string
+	for idx, line := range edits.lines {
+		if replacement, found := edits.replacements[idx]; found {
+			lines = append(lines, replacement...)
+		} else {
+			lines = append(lines, line)
+		}
+	}
+	return []byte(strings.Join(lines, "\n"))
+}
+
+// MigrateConfig rewrites the given bitrise config (YAML) to the current format version (models.FormatVersion),
+// the deprecated trigger map items (pattern and is_pull_request_allowed) are migrated
+// to push_branch and pull_request_source_branch trigger items.
+// The config is modified line by line, so comments and key order are preserved.
+func MigrateConfig(content []byte) ([]byte, []MigrationChange, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(content, &root); err != nil {
+		return nil, nil, err
+	}
+	if root.Kind != yaml.DocumentNode || len(root.Content) == 0 || root.Content[0].Kind != yaml.MappingNode {
+		return nil, nil, errors.New("invalid config: the root should be a mapping")
+	}
+	config := root.Content[0]
+
+	edits := newLineEdits(content)
+	var changes []MigrationChange
+
+	triggerMapNode := mappingValue(config, "trigger_map")
+	if triggerMapNode != nil {
+		triggerChanges, err := migrateDeprecatedTriggerItems(edits, triggerMapNode)
+		if err != nil {
+			return nil, nil, err
+		}
+		changes = append(changes, triggerChanges...)
+	}
+
+	formatVersionNode := mappingValue(config, "format_version")
+	if formatVersionNode == nil {
+		return nil, nil, errors.New("missing format_version")
+	}
+	formatVersionChange, err := migrateFormatVersion(edits, formatVersionNode)
+	if err != nil {
+		return nil, nil, err
+	}
+	if formatVersionChange != nil {
+		changes = append(changes, *formatVersionChange)
+	}
+
+	if !edits.isChanged() {
+		return content, nil, nil
+	}
+
+	migrated := edits.bytes()
+	if _, _, err := ConfigModelFromYAMLBytes(migrated); err != nil {
+		return nil, nil, fmt.Errorf("migrated config is not valid: %s", err)
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].Line < changes[j].Line
+	})
+
+	return migrated, changes, nil
+}
+
+func mappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func migrateFormatVersion(edits *lineEdits, node *yaml.Node) (*MigrationChange, error) {
+	if node.Value == models.FormatVersion {
+		return nil, nil
+	}
+
+	isNewer, err := versions.IsVersionGreaterOrEqual(node.Value, models.FormatVersion)
+	if err != nil {
+		return nil, fmt.Errorf("invalid format_version (%s): %s", node.Value, err)
+	}
+	if isNewer {
+		return nil, fmt.Errorf("the format_version (%s) is higher than the bitrise CLI supported format version (%s)", node.Value, models.FormatVersion)
+	}
+	if node.Kind != yaml.ScalarNode || node.Style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0 {
+		return nil, fmt.Errorf("unsupported format_version formatting at line %d", node.Line)
+	}
+
+	line := edits.line(node.Line)
+	start := node.Column - 1
+	end := start + len(node.Value)
+	if node.Style&(yaml.DoubleQuotedStyle|yaml.SingleQuotedStyle) != 0 {
+		// keep the quotes
+		start++
+		end++
+	}
+	if end > len(line) || line[start:end] != node.Value {
+		return nil, fmt.Errorf("unsupported format_version formatting at line %d", node.Line)
+	}
+	edits.replace(node.Line, line[:start]+models.FormatVersion+line[end:])
+
+	return &MigrationChange{
+		Line:    node.Line,
+		Message: fmt.Sprintf("format_version: %s -> %s", node.Value, models.FormatVersion),
+	}, nil
+}
+
+func migrateDeprecatedTriggerItems(edits *lineEdits, triggerMapNode *yaml.Node) ([]MigrationChange, error) {
+	if triggerMapNode.Kind != yaml.SequenceNode {
+		return nil, nil
+	}
+
+	var changes []MigrationChange
+	for idx, itemNode := range triggerMapNode.Content {
+		if itemNode.Kind != yaml.MappingNode || mappingValue(itemNode, deprecatedPatternKey) == nil {
+			continue
+		}
+
+		itemChanges, err := migrateDeprecatedTriggerItem(edits, idx, itemNode)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, itemChanges...)
+	}
+
+	return changes, nil
+}
+
+// migrateDeprecatedTriggerItem replaces the lines of a deprecated trigger item,
+// with the lines of the push_branch item and the pull_request_source_branch item (if pull requests were allowed).
+// The same migration is done in memory by models.migrateDeprecatedTriggerItem.
+func migrateDeprecatedTriggerItem(edits *lineEdits, idx int, itemNode *yaml.Node) ([]MigrationChange, error) {
+	unsupportedErr := fmt.Errorf("unsupported formatting of the deprecated trigger item at line %d, migrate it manually", itemNode.Line)
+
+	if itemNode.Style&yaml.FlowStyle != 0 {
+		return nil, unsupportedErr
+	}
+
+	// the sequence item's dash is expected to be in the line of the item's first key: `- pattern: master`
+	dashPrefix := edits.line(itemNode.Line)[:itemNode.Column-1]
+	if !strings.Contains(dashPrefix, "-") {
+		return nil, unsupportedErr
+	}
+	keyPrefix := strings.Repeat(" ", itemNode.Column-1)
+
+	// the rest of the line from the key, for every key of the item
+	keyLines := map[int]string{}
+	keyByLine := map[int]string{}
+	lastLine := itemNode.Line
+	isPullRequestAllowed := false
+	for i := 0; i+1 < len(itemNode.Content); i += 2 {
+		keyNode, valueNode := itemNode.Content[i], itemNode.Content[i+1]
+		if keyNode.Line != valueNode.Line || valueNode.Kind != yaml.ScalarNode || valueNode.Style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0 {
+			return nil, unsupportedErr
+		}
+		if _, found := keyByLine[keyNode.Line]; found {
+			return nil, unsupportedErr
+		}
+
+		keyByLine[keyNode.Line] = keyNode.Value
+		keyLines[keyNode.Line] = edits.line(keyNode.Line)[keyNode.Column-1:]
+		if valueNode.Line > lastLine {
+			lastLine = valueNode.Line
+		}
+
+		if keyNode.Value == deprecatedIsPullRequestAllowedKey {
+			if err := valueNode.Decode(&isPullRequestAllowed); err != nil {
+				return nil, fmt.Errorf("invalid %s value at line %d: %s", deprecatedIsPullRequestAllowedKey, valueNode.Line, err)
+			}
+		}
+	}
+
+	pattern := mappingValue(itemNode, deprecatedPatternKey)
+	changes := []MigrationChange{
+		{
+			Line:    pattern.Line,
+			Message: fmt.Sprintf("trigger_map[%d]: deprecated %s (%s) migrated to %s", idx, deprecatedPatternKey, pattern.Value, pushBranchKey),
+		},
+	}
+
+	var pushItemLines, pullRequestItemLines []string
+	addLine := func(itemLines []string, rest string) []string {
+		if len(itemLines) == 0 {
+			return append(itemLines, dashPrefix+rest)
+		}
+		return append(itemLines, keyPrefix+rest)
+	}
+
+	for line := itemNode.Line; line <= lastLine; line++ {
+		key, isKeyLine := keyByLine[line]
+		if !isKeyLine {
+			// comment or empty line inside the item
+			pushItemLines = append(pushItemLines, edits.line(line))
+			continue
+		}
+
+		rest := keyLines[line]
+		switch key {
+		case deprecatedPatternKey:
+			rest = strings.TrimPrefix(rest, deprecatedPatternKey)
+			pushItemLines = addLine(pushItemLines, pushBranchKey+rest)
+			pullRequestItemLines = addLine(pullRequestItemLines, pullRequestSourceBranchKey+rest)
+		case deprecatedIsPullRequestAllowedKey:
+			changes = append(changes, MigrationChange{
+				Line:    line,
+				Message: fmt.Sprintf("trigger_map[%d]: deprecated %s removed", idx, deprecatedIsPullRequestAllowedKey),
+			})
+		default:
+			pushItemLines = addLine(pushItemLines, rest)
+			pullRequestItemLines = addLine(pullRequestItemLines, rest)
+		}
+	}
+
+	if isPullRequestAllowed {
+		pushItemLines = append(pushItemLines, pullRequestItemLines...)
+		changes = append(changes, MigrationChange{
+			Line:    pattern.Line,
+			Message: fmt.Sprintf("trigger_map[%d]: %s item added, as pull requests were allowed", idx, pullRequestSourceBranchKey),
+		})
+	}
+
+	edits.replace(itemNode.Line, pushItemLines...)
+	for line := itemNode.Line + 1; line <= lastLine; line++ {
+		edits.replace(line)
+	}
+
+	return changes, nil
+}
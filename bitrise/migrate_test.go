@@ -0,0 +1,104 @@
+package bitrise
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrateConfig(t *testing.T) {
+	t.Log("deprecated trigger items and format version")
+	{
+		config := `format_version: "1.3.0" # comment
+default_step_lib_source: https://github.com/bitrise-io/bitrise-steplib.git
+
+trigger_map:
+# release builds
+- pattern: master
+  is_pull_request_allowed: true
+  # the main workflow
+  workflow: primary
+- push_branch: develop
+  workflow: primary
+- pattern: "feature/*"
+  workflow: primary # feature
+
+workflows:
+  primary:
+`
+		expected := `format_version: "12" # comment
+default_step_lib_source: https://github.com/bitrise-io/bitrise-steplib.git
+
+trigger_map:
+# release builds
+- push_branch: master
+  # the main workflow
+  workflow: primary
+- pull_request_source_branch: master
+  workflow: primary
+- push_branch: develop
+  workflow: primary
+- push_branch: "feature/*"
+  workflow: primary # feature
+
+workflows:
+  primary:
+`
+		migrated, changes, err := MigrateConfig([]byte(config))
+		require.NoError(t, err)
+		require.Equal(t, expected, string(migrated))
+		require.Equal(t, []MigrationChange{
+			{Line: 1, Message: "format_version: 1.3.0 -> 12"},
+			{Line: 6, Message: "trigger_map[0]: deprecated pattern (master) migrated to push_branch"},
+			{Line: 6, Message: "trigger_map[0]: pull_request_source_branch item added, as pull requests were allowed"},
+			{Line: 7, Message: "trigger_map[0]: deprecated is_pull_request_allowed removed"},
+			{Line: 12, Message: "trigger_map[2]: deprecated pattern (feature/*) migrated to push_branch"},
+		}, changes)
+
+		migratedAgain, changes, err := MigrateConfig(migrated)
+		require.NoError(t, err)
+		require.Equal(t, 0, len(changes))
+		require.Equal(t, string(migrated), string(migratedAgain))
+	}
+
+	t.Log("unquoted format version")
+	{
+		migrated, changes, err := MigrateConfig([]byte("format_version: 11\n"))
+		require.NoError(t, err)
+		require.Equal(t, "format_version: 12\n", string(migrated))
+		require.Equal(t, []MigrationChange{{Line: 1, Message: "format_version: 11 -> 12"}}, changes)
+	}
+
+	t.Log("format version higher than the supported one")
+	{
+		_, _, err := MigrateConfig([]byte("format_version: 13\n"))
+		require.EqualError(t, err, "the format_version (13) is higher than the bitrise CLI supported format version (12)")
+	}
+
+	t.Log("flow style deprecated trigger item")
+	{
+		config := `format_version: 11
+trigger_map:
+- {pattern: master, workflow: primary}
+workflows:
+  primary:
+`
+		_, _, err := MigrateConfig([]byte(config))
+		require.EqualError(t, err, "unsupported formatting of the deprecated trigger item at line 3, migrate it manually")
+	}
+
+	t.Log("migration resulting in an invalid config")
+	{
+		config := `format_version: 11
+trigger_map:
+- push_branch: master
+  workflow: primary
+- pattern: master
+  workflow: primary
+workflows:
+  primary:
+`
+		_, _, err := MigrateConfig([]byte(config))
+		require.EqualError(t, err, "migrated config is not valid: duplicated trigger item found (push_branch: master)")
+	}
+}
@@ -229,11 +229,11 @@ func generateYAML(v interface{}) ([]byte, error) {
 	return bytes, nil
 }
 
-func normalizeValidateFillMissingDefaults(bitriseData *models.BitriseDataModel) ([]string, error) {
+func normalizeValidateFillMissingDefaults(bitriseData *models.BitriseDataModel, sourceMap models.SourceMap) ([]models.ValidationIssue, error) {
 	if err := bitriseData.Normalize(); err != nil {
-		return []string{}, err
+		return []models.ValidationIssue{}, err
 	}
-	warnings, err := bitriseData.Validate()
+	warnings, err := bitriseData.ValidateWithSourceMap(sourceMap)
 	if err != nil {
 		return warnings, err
 	}
@@ -243,13 +243,31 @@ func normalizeValidateFillMissingDefaults(bitriseData *models.BitriseDataModel)
 	return warnings, nil
 }
 
+// newSourceMap returns an empty source map if the config can't be parsed by the YAML node parser,
+// the positions are only used to annotate the validation issues.
+func newSourceMap(sourceFile string, configBytes []byte) models.SourceMap {
+	sourceMap, err := models.NewSourceMapFromYAML(sourceFile, configBytes)
+	if err != nil {
+		log.Debugf("Failed to collect config source positions: %s", err)
+		return models.SourceMap{}
+	}
+	return sourceMap
+}
+
 // ConfigModelFromYAMLBytes ...
 func ConfigModelFromYAMLBytes(configBytes []byte) (bitriseData models.BitriseDataModel, warnings []string, err error) {
+	bitriseData, issues, err := ConfigModelWithIssuesFromYAMLBytes(configBytes, "")
+	return bitriseData, models.ValidationIssueMessages(issues), err
+}
+
+// ConfigModelWithIssuesFromYAMLBytes parses and validates the config,
+// the returned warnings and the validation error (models.ValidationIssue) carry their position in the source file.
+func ConfigModelWithIssuesFromYAMLBytes(configBytes []byte, sourceFile string) (bitriseData models.BitriseDataModel, warnings []models.ValidationIssue, err error) {
 	if err = yaml.Unmarshal(configBytes, &bitriseData); err != nil {
 		return
 	}
 
-	warnings, err = normalizeValidateFillMissingDefaults(&bitriseData)
+	warnings, err = normalizeValidateFillMissingDefaults(&bitriseData, newSourceMap(sourceFile, configBytes))
 	if err != nil {
 		return
 	}
@@ -259,10 +277,18 @@ func ConfigModelFromYAMLBytes(configBytes []byte) (bitriseData models.BitriseDat
 
 // ConfigModelFromJSONBytes ...
 func ConfigModelFromJSONBytes(configBytes []byte) (bitriseData models.BitriseDataModel, warnings []string, err error) {
+	bitriseData, issues, err := ConfigModelWithIssuesFromJSONBytes(configBytes, "")
+	return bitriseData, models.ValidationIssueMessages(issues), err
+}
+
+// ConfigModelWithIssuesFromJSONBytes ...
+func ConfigModelWithIssuesFromJSONBytes(configBytes []byte, sourceFile string) (bitriseData models.BitriseDataModel, warnings []models.ValidationIssue, err error) {
 	if err = json.Unmarshal(configBytes, &bitriseData); err != nil {
 		return
 	}
-	warnings, err = normalizeValidateFillMissingDefaults(&bitriseData)
+
+	// JSON is a subset of YAML, so the YAML node parser provides the positions of a JSON config too
+	warnings, err = normalizeValidateFillMissingDefaults(&bitriseData, newSourceMap(sourceFile, configBytes))
 	if err != nil {
 		return
 	}
@@ -272,28 +298,34 @@ func ConfigModelFromJSONBytes(configBytes []byte) (bitriseData models.BitriseDat
 
 // ReadBitriseConfig ...
 func ReadBitriseConfig(pth string) (models.BitriseDataModel, []string, error) {
+	bitriseData, issues, err := ReadBitriseConfigWithIssues(pth)
+	return bitriseData, models.ValidationIssueMessages(issues), err
+}
+
+// ReadBitriseConfigWithIssues ...
+func ReadBitriseConfigWithIssues(pth string) (models.BitriseDataModel, []models.ValidationIssue, error) {
 	if isExists, err := pathutil.IsPathExists(pth); err != nil {
-		return models.BitriseDataModel{}, []string{}, err
+		return models.BitriseDataModel{}, []models.ValidationIssue{}, err
 	} else if !isExists {
-		return models.BitriseDataModel{}, []string{}, fmt.Errorf("No file found at path: %s", pth)
+		return models.BitriseDataModel{}, []models.ValidationIssue{}, fmt.Errorf("No file found at path: %s", pth)
 	}
 
 	bytes, err := fileutil.ReadBytesFromFile(pth)
 	if err != nil {
-		return models.BitriseDataModel{}, []string{}, err
+		return models.BitriseDataModel{}, []models.ValidationIssue{}, err
 	}
 
 	if len(bytes) == 0 {
-		return models.BitriseDataModel{}, []string{}, errors.New("empty config")
+		return models.BitriseDataModel{}, []models.ValidationIssue{}, errors.New("empty config")
 	}
 
 	if strings.HasSuffix(pth, ".json") {
 		log.Debug("=> Using JSON parser for: ", pth)
-		return ConfigModelFromJSONBytes(bytes)
+		return ConfigModelWithIssuesFromJSONBytes(bytes, pth)
 	}
 
 	log.Debug("=> Using YAML parser for: ", pth)
-	return ConfigModelFromYAMLBytes(bytes)
+	return ConfigModelWithIssuesFromYAMLBytes(bytes, pth)
 }
 
 // ReadSpecStep ...
@@ -425,6 +457,10 @@ func removeStepDefaultsAndFillStepOutputs(stepListItem *models.StepListItemModel
 			return err
 		}
 	} else if stepIDData.SteplibSource == "git" {
+		cloneURL, err := GitStepCloneURL(stepIDData.IDorURI)
+		if err != nil {
+			return err
+		}
 		repo, err := git.New(tempStepCloneDirPath)
 		if err != nil {
 			return err
@@ -432,9 +468,9 @@ func removeStepDefaultsAndFillStepOutputs(stepListItem *models.StepListItemModel
 
 		var cloneCmd *command.Model
 		if stepIDData.Version == "" {
-			cloneCmd = repo.Clone(stepIDData.IDorURI, "--depth=1")
+			cloneCmd = repo.Clone(cloneURL, "--depth=1")
 		} else {
-			cloneCmd = repo.CloneTagOrBranch(stepIDData.IDorURI, stepIDData.Version, "--depth=1")
+			cloneCmd = repo.CloneTagOrBranch(cloneURL, stepIDData.Version, "--depth=1")
 		}
 		if err := cloneCmd.Run(); err != nil {
 			return err
@@ -9,6 +9,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/tothszabi/bitrise-test/analytics"
 	"github.com/tothszabi/bitrise-test/bitrise"
 	"github.com/tothszabi/bitrise-test/configs"
 	"github.com/tothszabi/bitrise-test/log"
@@ -219,6 +220,19 @@ func before(c *cli.Context) error {
 		configs.IsPullRequestMode = true
 	}
 
+	// Offline Mode check
+	if c.Bool(OfflineKey) {
+		// make sure the plugins and the steps also get it
+		if err := os.Setenv(configs.OfflineModeEnvKey, "true"); err != nil {
+			failf("Failed to set offline mode env, error: %s", err)
+		}
+		// analytics are sent over the network
+		if err := os.Setenv(analytics.DisabledEnvKey, "true"); err != nil {
+			failf("Failed to disable analytics, error: %s", err)
+		}
+		configs.IsOfflineMode = true
+	}
+
 	return nil
 }
 
@@ -44,6 +44,7 @@ var (
 				flInventory,
 				flInventoryBase64,
 				flFormat,
+				flStepInputs,
 			},
 		},
 		updateCommand,
@@ -62,8 +63,19 @@ var (
 				cli.StringFlag{Name: PRSourceBranchKey, Usage: "Git pull request source branch name."},
 				cli.StringFlag{Name: PRTargetBranchKey, Usage: "Git pull request target branch name."},
 				cli.StringFlag{Name: TagKey, Usage: "Git tag name."},
+				flChangedFile,
+				flChangedFilesDiff,
+				flCommitMessage,
+				flPRLabel,
+				flPRDraft,
+				flEventPayload,
+				flProvider,
 
 				cli.StringFlag{Name: OuputFormatKey, Usage: "Output format. Accepted: json, yml."},
+				cli.BoolFlag{Name: explainKey, Usage: "Explain the evaluation of every trigger map item, and warn about the shadowed items."},
+				cli.BoolFlag{Name: testKey, Usage: "Run the trigger tests (trigger_tests) against the trigger map."},
+				cli.StringFlag{Name: testsFileKey, Usage: "Path of the file defining the trigger tests (trigger_tests), instead of the config."},
+				cli.StringFlag{Name: atKey, Usage: "Print the pipelines and workflows scheduled (schedule trigger items) to the given time (RFC3339 format or now)."},
 
 				// cli params used in CI mode
 				cli.StringFlag{Name: JSONParamsKey, Usage: "Specify command flags with json string-string hash."},
@@ -99,6 +111,14 @@ var (
 				flPath,
 				flConfig,
 				flConfigBase64,
+				cli.BoolFlag{
+					Name:  fmtKey,
+					Usage: "Formatter mode: canonicalizes the indentation and the key order, preserves comments, anchors, multiline strings, blank lines and the sequence style. Redundant fields are not removed in this mode.",
+				},
+				cli.BoolFlag{
+					Name:  checkKey,
+					Usage: "Only check whether the config is formatted (formatter mode), exits with 1 if it is not.",
+				},
 			},
 		},
 		{
@@ -124,6 +144,15 @@ var (
 			},
 		},
 		workflowListCommand,
+		pipelineListCommand,
+		graphCommand,
+		diffCommand,
+		schemaCommand,
+		migrateCommand,
+		serveCommand,
+		stepsCommand,
+		lockCommand,
+		stepCommand,
 		{
 			Name:   "share",
 			Usage:  "Publish your step.",
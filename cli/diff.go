@@ -0,0 +1,134 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/command"
+	"github.com/tothszabi/bitrise-test/bitrise"
+	"github.com/tothszabi/bitrise-test/log"
+	"github.com/tothszabi/bitrise-test/models"
+	"github.com/tothszabi/bitrise-test/output"
+	"github.com/urfave/cli"
+)
+
+const gitRefKey = "git-ref"
+
+var diffCommand = cli.Command{
+	Name:      "diff",
+	Usage:     "Compares two configs (bitrise.yml) at the model level: workflows, steps, inputs, envs, trigger map, pipelines and stages.",
+	ArgsUsage: "[OLD_CONFIG NEW_CONFIG]",
+	Action: func(c *cli.Context) error {
+		if err := diff(c); err != nil {
+			log.Errorf("Failed to compare the configs, error: %s", err)
+			os.Exit(1)
+		}
+		return nil
+	},
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  gitRefKey,
+			Usage: "Compare the config at the given git ref (like origin/master) with the current config, instead of two config files.",
+		},
+		cli.StringFlag{
+			Name:  ConfigKey + ", " + configShortKey,
+			Value: DefaultBitriseConfigFileName,
+			Usage: "Path of the config compared with its version at the git ref (--git-ref).",
+		},
+		cli.StringFlag{
+			Name:  OuputFormatKey,
+			Usage: "Output format. Accepted: raw, json.",
+		},
+	},
+}
+
+func readConfigAtGitRef(ref, pth string) (models.BitriseDataModel, error) {
+	// the ./ prefix makes git resolve the path relative to the current directory, instead of the repository root
+	object := fmt.Sprintf("%s:./%s", ref, filepath.ToSlash(filepath.Clean(pth)))
+
+	var stdout, stderr bytes.Buffer
+	cmd := command.New("git", "show", object).SetStdout(&stdout).SetStderr(&stderr)
+	if err := cmd.Run(); err != nil {
+		return models.BitriseDataModel{}, fmt.Errorf("%s failed: %s: %s", cmd.PrintableCommandArgs(), err, strings.TrimSpace(stderr.String()))
+	}
+
+	config, warnings, err := bitrise.ConfigModelFromYAMLBytes(stdout.Bytes())
+	printWarningsToStderr(prefixedWarnings(object, warnings))
+	if err != nil {
+		return models.BitriseDataModel{}, fmt.Errorf("config (%s) is not valid: %s", object, err)
+	}
+	return config, nil
+}
+
+func readDiffConfig(pth string) (models.BitriseDataModel, error) {
+	config, warnings, err := CreateBitriseConfigFromCLIParams("", pth)
+	printWarningsToStderr(prefixedWarnings(pth, warnings))
+	return config, err
+}
+
+func diff(c *cli.Context) error {
+	format := c.String(OuputFormatKey)
+	if format == "" {
+		format = output.FormatRaw
+	}
+	if format != output.FormatRaw && format != output.FormatJSON {
+		showSubcommandHelp(c)
+		return fmt.Errorf("invalid format: %s", format)
+	}
+
+	var oldConfig, newConfig models.BitriseDataModel
+	var err error
+	if ref := c.String(gitRefKey); ref != "" {
+		if len(c.Args()) > 0 {
+			showSubcommandHelp(c)
+			return fmt.Errorf("config paths can not be provided together with --%s", gitRefKey)
+		}
+
+		configPath := c.String(ConfigKey)
+		if oldConfig, err = readConfigAtGitRef(ref, configPath); err != nil {
+			return err
+		}
+		if newConfig, err = readDiffConfig(configPath); err != nil {
+			return err
+		}
+	} else {
+		if len(c.Args()) != 2 {
+			showSubcommandHelp(c)
+			return fmt.Errorf("two config paths or --%s required", gitRefKey)
+		}
+
+		if oldConfig, err = readDiffConfig(c.Args()[0]); err != nil {
+			return err
+		}
+		if newConfig, err = readDiffConfig(c.Args()[1]); err != nil {
+			return err
+		}
+	}
+
+	configDiff := models.DiffConfigs(oldConfig, newConfig)
+
+	switch format {
+	case output.FormatRaw:
+		fmt.Println(configDiff.String())
+	case output.FormatJSON:
+		diffBytes, err := json.MarshalIndent(configDiff, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to serialize diff: %s", err)
+		}
+		fmt.Println(string(diffBytes))
+	}
+
+	return nil
+}
+
+func prefixedWarnings(prefix string, warnings []string) []string {
+	var prefixed []string
+	for _, warning := range warnings {
+		prefixed = append(prefixed, prefix+": "+warning)
+	}
+	return prefixed
+}
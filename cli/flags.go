@@ -14,6 +14,8 @@ const (
 	PRKey = "pr"
 	// DebugModeKey ...
 	DebugModeKey = "debug"
+	// OfflineKey ...
+	OfflineKey = "offline"
 
 	// VersionKey ...
 	VersionKey      = "version"
@@ -61,6 +63,24 @@ const (
 	// StepYMLKey ...
 	StepYMLKey = "step-yml"
 
+	// StepInputsKey ...
+	StepInputsKey = "step-inputs"
+
+	// ChangedFileKey ...
+	ChangedFileKey = "changed-file"
+	// ChangedFilesDiffKey ...
+	ChangedFilesDiffKey = "changed-files-diff"
+	// CommitMessageKey ...
+	CommitMessageKey = "commit-message"
+	// PRLabelKey ...
+	PRLabelKey = "pr-label"
+	// PRDraftKey ...
+	PRDraftKey = "pr-draft"
+	// EventPayloadKey ...
+	EventPayloadKey = "event-payload"
+	// ProviderKey ...
+	ProviderKey = "provider"
+
 	//
 	// Stepman share
 
@@ -73,6 +93,35 @@ const (
 )
 
 var (
+	flChangedFile = cli.StringSliceFlag{
+		Name:  ChangedFileKey,
+		Usage: "Path of a file changed by the git event, evaluated against the changed_files trigger filters. Can be specified multiple times.",
+	}
+	flChangedFilesDiff = cli.StringFlag{
+		Name:  ChangedFilesDiffKey,
+		Usage: "Git revision range (like origin/master...HEAD), the changed files of the git event are read from the diff of the range.",
+	}
+	flCommitMessage = cli.StringFlag{
+		Name:  CommitMessageKey,
+		Usage: "Commit message of the git event, evaluated against the commit_message trigger filters.",
+	}
+	flPRLabel = cli.StringSliceFlag{
+		Name:  PRLabelKey,
+		Usage: "Label of the pull request, evaluated against the pull_request_label trigger conditions. Can be specified multiple times.",
+	}
+	flPRDraft = cli.BoolFlag{
+		Name:  PRDraftKey,
+		Usage: "The pull request is a draft, evaluated against the draft_pull_request_enabled trigger conditions.",
+	}
+	flEventPayload = cli.StringFlag{
+		Name:  EventPayloadKey,
+		Usage: "Path of a webhook payload (JSON) file, the trigger params are read from the payload.",
+	}
+	flProvider = cli.StringFlag{
+		Name:  ProviderKey,
+		Usage: "Provider of the webhook payload. Accepted: github, gitlab, bitbucket.",
+	}
+
 	// App flags
 	flDebugMode = cli.BoolFlag{
 		Name:   DebugModeKey,
@@ -88,10 +137,16 @@ var (
 		Name:  PRKey,
 		Usage: "If true bitrise runs in pull request mode.",
 	}
+	flOffline = cli.BoolFlag{
+		Name:   OfflineKey,
+		Usage:  "If true network access is disabled, steps are activated only from the local StepLib caches and git mirrors.",
+		EnvVar: configs.OfflineModeEnvKey,
+	}
 	flags = []cli.Flag{
 		flDebugMode,
 		flTool,
 		flPRMode,
+		flOffline,
 	}
 	// Command flags
 	flOutputFormat = cli.StringFlag{
@@ -153,6 +208,10 @@ var (
 		Name:  StepYMLKey,
 		Usage: "Path of step.yml",
 	}
+	flStepInputs = cli.BoolFlag{
+		Name:  StepInputsKey,
+		Usage: "Validate the step inputs against the step definitions (step.yml), requires the StepLib of the steps.",
+	}
 
 	// Stepman share
 	flTag = cli.StringFlag{
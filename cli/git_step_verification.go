@@ -0,0 +1,110 @@
+package cli
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/command"
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/tothszabi/bitrise-test/configs"
+	"github.com/tothszabi/bitrise-test/log"
+	"github.com/tothszabi/bitrise-test/models"
+)
+
+// verifyGitStep verifies the cloned git step against the pinned commit, tree and the tag's signature.
+func verifyGitStep(stepDir string, stepIDData models.StepIDData, gitStep models.GitStepModel) error {
+	head, err := gitRevParse(stepDir, "HEAD")
+	if err != nil {
+		return err
+	}
+
+	if gitStep.Commit != "" && !gitHashMatches(head, gitStep.Commit) {
+		return fmt.Errorf("git step (%s) commit (%s) differs from the pinned commit (%s)", stepIDData.IDorURI, head, gitStep.Commit)
+	}
+
+	if gitStep.Tree != "" {
+		tree, err := gitRevParse(stepDir, "HEAD^{tree}")
+		if err != nil {
+			return err
+		}
+		if !gitHashMatches(tree, gitStep.Tree) {
+			return fmt.Errorf("git step (%s) tree (%s) differs from the pinned tree (%s)", stepIDData.IDorURI, tree, gitStep.Tree)
+		}
+	}
+
+	if gitStep.VerifyTagSignature {
+		if err := verifyGitStepTagSignature(stepDir, stepIDData.Version, gitStep.Keyring, head); err != nil {
+			return fmt.Errorf("git step (%s) tag (%s) signature verification failed: %s", stepIDData.IDorURI, stepIDData.Version, err)
+		}
+	}
+
+	log.Debugf("Git step (%s@%s) verified", stepIDData.IDorURI, stepIDData.Version)
+	return nil
+}
+
+// verifyGitStepTagSignature verifies the tag's signature with the keys of the keyring (or of the default keyring),
+// the keys are imported into a temporary GnuPG home, so the user's own keyring is not used nor modified.
+func verifyGitStepTagSignature(stepDir, tag, keyring, head string) error {
+	if keyring == "" {
+		keyring = os.Getenv(configs.GitStepKeyringEnvKey)
+	}
+	if keyring == "" {
+		return fmt.Errorf("no keyring defined, set the git step's keyring or %s", configs.GitStepKeyringEnvKey)
+	}
+	keyringPth, err := pathutil.AbsPath(keyring)
+	if err != nil {
+		return err
+	}
+	if exist, err := pathutil.IsPathExists(keyringPth); err != nil {
+		return err
+	} else if !exist {
+		return fmt.Errorf("keyring (%s) does not exist", keyringPth)
+	}
+
+	gnupgHome, err := ioutil.TempDir("", "bitrise-gnupg")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := os.RemoveAll(gnupgHome); err != nil {
+			log.Warnf("Failed to remove temporary GnuPG home (%s): %s", gnupgHome, err)
+		}
+	}()
+	if err := os.Chmod(gnupgHome, 0700); err != nil {
+		return err
+	}
+
+	importCmd := command.New("gpg", "--batch", "--homedir", gnupgHome, "--import", keyringPth)
+	if out, err := importCmd.RunAndReturnTrimmedCombinedOutput(); err != nil {
+		return fmt.Errorf("failed to import keyring (%s): %s: %s", keyringPth, err, out)
+	}
+
+	verifyCmd := command.New("git", "verify-tag", tag).SetDir(stepDir).AppendEnvs("GNUPGHOME=" + gnupgHome)
+	if out, err := verifyCmd.RunAndReturnTrimmedCombinedOutput(); err != nil {
+		return fmt.Errorf("%s: %s", err, out)
+	}
+
+	tagCommit, err := gitRevParse(stepDir, tag+"^{commit}")
+	if err != nil {
+		return err
+	}
+	if tagCommit != head {
+		return fmt.Errorf("the checked out commit (%s) is not the tagged commit (%s)", head, tagCommit)
+	}
+	return nil
+}
+
+func gitRevParse(dir, rev string) (string, error) {
+	out, err := command.New("git", "rev-parse", "--verify", rev).SetDir(dir).RunAndReturnTrimmedCombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %s: %s", rev, err, out)
+	}
+	return out, nil
+}
+
+// gitHashMatches returns true if the pinned (possibly abbreviated) hash matches the full hash.
+func gitHashMatches(hash, pinned string) bool {
+	return strings.HasPrefix(strings.ToLower(hash), strings.ToLower(pinned))
+}
@@ -0,0 +1,141 @@
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tothszabi/bitrise-test/models"
+)
+
+func runGitStepTestCommand(t *testing.T, dir string, envs []string, name string, args ...string) string {
+	cmd := exec.Command(name, args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), envs...)
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+	return string(out)
+}
+
+func createGitStepTestRepository(t *testing.T, envs []string) string {
+	repoDir, err := ioutil.TempDir("", "git-step")
+	require.NoError(t, err)
+
+	runGitStepTestCommand(t, repoDir, envs, "git", "init", "-q")
+	runGitStepTestCommand(t, repoDir, envs, "git", "config", "user.name", "Test")
+	runGitStepTestCommand(t, repoDir, envs, "git", "config", "user.email", "test@example.com")
+	require.NoError(t, ioutil.WriteFile(filepath.Join(repoDir, "step.yml"), []byte("title: Test\n"), 0644))
+	runGitStepTestCommand(t, repoDir, envs, "git", "add", "-A")
+	runGitStepTestCommand(t, repoDir, envs, "git", "commit", "-q", "-m", "initial")
+	return repoDir
+}
+
+func TestVerifyGitStep(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not available")
+	}
+
+	repoDir := createGitStepTestRepository(t, nil)
+	defer func() {
+		require.NoError(t, os.RemoveAll(repoDir))
+	}()
+
+	commit, err := gitRevParse(repoDir, "HEAD")
+	require.NoError(t, err)
+	tree, err := gitRevParse(repoDir, "HEAD^{tree}")
+	require.NoError(t, err)
+
+	stepIDData := models.StepIDData{SteplibSource: "git", IDorURI: "https://github.com/bitrise-steplib/steps-script.git", Version: "master"}
+
+	t.Log("pinned commit and tree")
+	{
+		require.NoError(t, verifyGitStep(repoDir, stepIDData, models.GitStepModel{Commit: commit, Tree: tree}))
+	}
+
+	t.Log("abbreviated, upper case commit")
+	{
+		require.NoError(t, verifyGitStep(repoDir, stepIDData, models.GitStepModel{Commit: strings.ToUpper(commit[:7])}))
+	}
+
+	t.Log("commit mismatch")
+	{
+		err := verifyGitStep(repoDir, stepIDData, models.GitStepModel{Commit: "0000000000000000000000000000000000000000"})
+		require.EqualError(t, err, "git step (https://github.com/bitrise-steplib/steps-script.git) commit ("+commit+") differs from the pinned commit (0000000000000000000000000000000000000000)")
+	}
+
+	t.Log("tree mismatch")
+	{
+		err := verifyGitStep(repoDir, stepIDData, models.GitStepModel{Tree: "0000000"})
+		require.EqualError(t, err, "git step (https://github.com/bitrise-steplib/steps-script.git) tree ("+tree+") differs from the pinned tree (0000000)")
+	}
+
+	t.Log("tag signature verification without keyring")
+	{
+		require.NoError(t, os.Unsetenv("BITRISE_GIT_STEP_KEYRING"))
+		err := verifyGitStep(repoDir, stepIDData, models.GitStepModel{VerifyTagSignature: true})
+		require.EqualError(t, err, "git step (https://github.com/bitrise-steplib/steps-script.git) tag (master) signature verification failed: no keyring defined, set the git step's keyring or BITRISE_GIT_STEP_KEYRING")
+	}
+}
+
+func TestVerifyGitStepTagSignature(t *testing.T) {
+	if _, err := exec.LookPath("gpg"); err != nil {
+		t.Skip("gpg is not available")
+	}
+
+	gnupgHome, err := ioutil.TempDir("", "gnupg")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(gnupgHome))
+	}()
+	require.NoError(t, os.Chmod(gnupgHome, 0700))
+	envs := []string{"GNUPGHOME=" + gnupgHome}
+
+	runGitStepTestCommand(t, gnupgHome, envs, "gpg", "--batch", "--passphrase", "", "--quick-gen-key", "Test <test@example.com>", "ed25519", "sign", "never")
+	keyringPth := filepath.Join(gnupgHome, "keyring.asc")
+	require.NoError(t, ioutil.WriteFile(keyringPth, []byte(runGitStepTestCommand(t, gnupgHome, envs, "gpg", "--armor", "--export", "test@example.com")), 0644))
+
+	repoDir := createGitStepTestRepository(t, envs)
+	defer func() {
+		require.NoError(t, os.RemoveAll(repoDir))
+	}()
+	runGitStepTestCommand(t, repoDir, envs, "git", "tag", "-a", "unsigned", "-m", "unsigned")
+	runGitStepTestCommand(t, repoDir, envs, "git", "-c", "user.signingkey=test@example.com", "tag", "-s", "1.0.0", "-m", "1.0.0")
+
+	gitStep := models.GitStepModel{VerifyTagSignature: true, Keyring: keyringPth}
+
+	t.Log("signed tag")
+	{
+		stepIDData := models.StepIDData{SteplibSource: "git", IDorURI: "https://github.com/bitrise-steplib/steps-script.git", Version: "1.0.0"}
+		require.NoError(t, verifyGitStep(repoDir, stepIDData, gitStep))
+	}
+
+	t.Log("keyring from the environment")
+	{
+		require.NoError(t, os.Setenv("BITRISE_GIT_STEP_KEYRING", keyringPth))
+		defer func() {
+			require.NoError(t, os.Unsetenv("BITRISE_GIT_STEP_KEYRING"))
+		}()
+
+		stepIDData := models.StepIDData{SteplibSource: "git", IDorURI: "https://github.com/bitrise-steplib/steps-script.git", Version: "1.0.0"}
+		require.NoError(t, verifyGitStep(repoDir, stepIDData, models.GitStepModel{VerifyTagSignature: true}))
+	}
+
+	t.Log("unsigned tag")
+	{
+		stepIDData := models.StepIDData{SteplibSource: "git", IDorURI: "https://github.com/bitrise-steplib/steps-script.git", Version: "unsigned"}
+		err := verifyGitStep(repoDir, stepIDData, gitStep)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "tag (unsigned) signature verification failed")
+	}
+
+	t.Log("missing keyring")
+	{
+		stepIDData := models.StepIDData{SteplibSource: "git", IDorURI: "https://github.com/bitrise-steplib/steps-script.git", Version: "1.0.0"}
+		err := verifyGitStep(repoDir, stepIDData, models.GitStepModel{VerifyTagSignature: true, Keyring: filepath.Join(gnupgHome, "missing.asc")})
+		require.EqualError(t, err, "git step (https://github.com/bitrise-steplib/steps-script.git) tag (1.0.0) signature verification failed: keyring ("+filepath.Join(gnupgHome, "missing.asc")+") does not exist")
+	}
+}
@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/bitrise-io/go-utils/colorstring"
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/tothszabi/bitrise-test/log"
+	"github.com/urfave/cli"
+)
+
+const (
+	graphFormatDOT     = "dot"
+	graphFormatMermaid = "mermaid"
+	graphFormatJSON    = "json"
+
+	graphStepsKey = "steps"
+)
+
+var graphCommand = cli.Command{
+	Name:  "graph",
+	Usage: "Renders the graph of the trigger map items, pipelines, stages and workflows of the config.",
+	Action: func(c *cli.Context) error {
+		if err := graph(c); err != nil {
+			log.Errorf("Failed to render the graph, error: %s", err)
+			os.Exit(1)
+		}
+		return nil
+	},
+	Flags: []cli.Flag{
+		flConfig,
+		flConfigBase64,
+		cli.StringFlag{
+			Name:  OuputFormatKey,
+			Value: graphFormatDOT,
+			Usage: "Output format. Accepted: dot, mermaid, json.",
+		},
+		cli.BoolFlag{
+			Name:  graphStepsKey,
+			Usage: "Include the steps of the workflows.",
+		},
+		cli.StringFlag{
+			Name:  OuputPathKey,
+			Usage: "Output path, where the graph will be saved. If not defined the graph is printed to the standard output.",
+		},
+	},
+}
+
+func graph(c *cli.Context) error {
+	format := c.String(OuputFormatKey)
+	outputPth := c.String(OuputPathKey)
+
+	if format != graphFormatDOT && format != graphFormatMermaid && format != graphFormatJSON {
+		showSubcommandHelp(c)
+		return fmt.Errorf("invalid format: %s", format)
+	}
+
+	bitriseConfig, warnings, err := CreateBitriseConfigFromCLIParams(c.String(ConfigBase64Key), c.String(ConfigKey))
+	printWarningsToStderr(warnings)
+	if err != nil {
+		return fmt.Errorf("failed to create config: %s", err)
+	}
+
+	configGraph := bitriseConfig.Graph(c.Bool(graphStepsKey))
+
+	var content string
+	switch format {
+	case graphFormatDOT:
+		content = configGraph.DOT()
+	case graphFormatMermaid:
+		content = configGraph.Mermaid()
+	case graphFormatJSON:
+		bytes, err := json.MarshalIndent(configGraph, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to serialize graph: %s", err)
+		}
+		content = string(bytes) + "\n"
+	}
+
+	if outputPth == "" {
+		fmt.Print(content)
+		return nil
+	}
+
+	if err := fileutil.WriteStringToFile(outputPth, content); err != nil {
+		return fmt.Errorf("failed to write file (%s): %s", outputPth, err)
+	}
+
+	log.Infof("Done, saved to path: %s", outputPth)
+
+	return nil
+}
+
+// printWarningsToStderr prints the warnings to the standard error, so that the standard output stays machine readable.
+func printWarningsToStderr(warnings []string) {
+	for _, warning := range warnings {
+		fmt.Fprintln(os.Stderr, colorstring.Yellowf("warning: %s", warning))
+	}
+}
@@ -0,0 +1,232 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	stepmanModels "github.com/bitrise-io/stepman/models"
+	"github.com/tothszabi/bitrise-test/log"
+	"github.com/tothszabi/bitrise-test/models"
+	"github.com/tothszabi/bitrise-test/steplock"
+	"github.com/tothszabi/bitrise-test/tools"
+	"github.com/urfave/cli"
+)
+
+const lockUpdateKey = "update"
+
+var lockCommand = cli.Command{
+	Name:  "lock",
+	Usage: "Records the exact versions of the config's StepLib steps into " + steplock.FileName + ".",
+	Action: func(c *cli.Context) error {
+		if err := lock(c); err != nil {
+			log.Errorf("Failed to lock the steps, error: %s", err)
+			os.Exit(1)
+		}
+		return nil
+	},
+	Flags: []cli.Flag{
+		flConfig,
+		cli.BoolFlag{
+			Name:  lockUpdateKey,
+			Usage: "Update the StepLibs and resolve every step again, instead of keeping the already locked versions.",
+		},
+	},
+}
+
+func lock(c *cli.Context) error {
+	configPth, err := GetBitriseConfigFilePath(c.String(ConfigKey))
+	if err != nil {
+		return err
+	}
+
+	bitriseConfig, warnings, err := CreateBitriseConfigFromCLIParams("", configPth)
+	for _, warning := range warnings {
+		log.Warnf("warning: %s", warning)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create config: %s", err)
+	}
+
+	lockPth := steplock.PathForConfig(configPth)
+	previous, found, err := steplock.Read(lockPth)
+	if err != nil {
+		return err
+	}
+	if !found {
+		previous = steplock.New()
+	}
+
+	stepIDDatas, err := configStepLibSteps(bitriseConfig)
+	if err != nil {
+		return err
+	}
+
+	update := c.Bool(lockUpdateKey)
+	updatedStepLibs := map[string]bool{}
+	current, err := lockSteps(stepIDDatas, previous, update, func(stepIDData models.StepIDData) (steplock.Step, error) {
+		if _, err := ensureStepLibRoute(stepIDData.SteplibSource); err != nil {
+			return steplock.Step{}, err
+		}
+		if update && !updatedStepLibs[stepIDData.SteplibSource] {
+			log.Printf("Updating StepLib: %s", stepIDData.SteplibSource)
+			if err := tools.StepmanUpdate(stepIDData.SteplibSource); err != nil {
+				return steplock.Step{}, fmt.Errorf("failed to update StepLib (%s): %s", stepIDData.SteplibSource, err)
+			}
+			updatedStepLibs[stepIDData.SteplibSource] = true
+		}
+
+		info, err := tools.StepmanStepInfo(stepIDData.SteplibSource, stepIDData.IDorURI, stepIDData.Version)
+		if err != nil {
+			return steplock.Step{}, fmt.Errorf("failed to get step (%s@%s) info: %s", stepIDData.IDorURI, stepIDData.Version, err)
+		}
+		return steplock.Step{
+			Source:  stepIDData.SteplibSource,
+			ID:      info.ID,
+			Version: info.Version,
+			Commit:  stepSourceCommit(info.Step),
+		}, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	changes := stepLockChanges(previous, current)
+	for _, change := range changes {
+		log.Printf("%s", change)
+	}
+
+	if err := steplock.Write(lockPth, current); err != nil {
+		return fmt.Errorf("failed to write lock file: %s", err)
+	}
+	log.Donef("%d steps locked in %s (%d changes)", len(current.Steps), lockPth, len(changes))
+	return nil
+}
+
+// lockSteps returns the lock of the given StepLib steps.
+// The already locked steps are kept (unless update is set), the others are resolved with resolve,
+// the locked steps which are no longer referenced are dropped.
+func lockSteps(stepIDDatas []models.StepIDData, previous steplock.Model, update bool, resolve func(models.StepIDData) (steplock.Step, error)) (steplock.Model, error) {
+	current := steplock.New()
+	for _, stepIDData := range stepIDDatas {
+		key := steplock.Key(stepIDData)
+		if lockedStep, found := previous.Steps[key]; found && !update {
+			current.Steps[key] = lockedStep
+			continue
+		}
+
+		step, err := resolve(stepIDData)
+		if err != nil {
+			return steplock.Model{}, err
+		}
+		current.Steps[key] = step
+	}
+	return current, nil
+}
+
+// stepLockChanges returns the printable differences of the two locks.
+func stepLockChanges(previous, current steplock.Model) []string {
+	var changes []string
+	for _, key := range current.Keys() {
+		step := current.Steps[key]
+		previousStep, found := previous.Steps[key]
+		if !found {
+			changes = append(changes, fmt.Sprintf("+ %s: %s", key, step.Version))
+		} else if previousStep != step {
+			changes = append(changes, fmt.Sprintf("~ %s: %s -> %s", key, previousStep.Version, step.Version))
+		}
+	}
+	for _, key := range previous.Keys() {
+		if _, found := current.Steps[key]; !found {
+			changes = append(changes, fmt.Sprintf("- %s", key))
+		}
+	}
+	return changes
+}
+
+// readConfigStepLock reads the lock file next to the config, returns nil if the config has no lock file.
+// A config passed as base64 encoded data has no lock file.
+func readConfigStepLock(bitriseConfigBase64Data, bitriseConfigPath string) (*steplock.Model, error) {
+	if bitriseConfigBase64Data != "" {
+		return nil, nil
+	}
+
+	configPth, err := GetBitriseConfigFilePath(bitriseConfigPath)
+	if err != nil {
+		return nil, err
+	}
+
+	lockPth := steplock.PathForConfig(configPth)
+	stepLock, found, err := steplock.Read(lockPth)
+	if err != nil || !found {
+		return nil, err
+	}
+	log.Debugf("Using step lock file: %s", lockPth)
+	return &stepLock, nil
+}
+
+// lockedStepIDData returns the StepLib step reference with its locked version, if the step is locked.
+func lockedStepIDData(stepLock *steplock.Model, stepIDData models.StepIDData) (models.StepIDData, steplock.Step, bool) {
+	switch stepIDData.SteplibSource {
+	case "path", "git", "_":
+		return stepIDData, steplock.Step{}, false
+	}
+
+	lockedStep, found := stepLock.Lookup(stepIDData)
+	if !found {
+		return stepIDData, steplock.Step{}, false
+	}
+	stepIDData.Version = lockedStep.Version
+	return stepIDData, lockedStep, true
+}
+
+// stepLockDrift returns the StepLib steps of the workflow (and of its before and after run workflows), which are not locked.
+func stepLockDrift(config models.BitriseDataModel, workflowID string, stepLock *steplock.Model) ([]string, error) {
+	var drift []string
+	seen := map[string]bool{}
+	for _, id := range uniqueWorkflowIDs(walkWorkflows(workflowID, config.Workflows, nil)) {
+		for _, stepListItem := range config.Workflows[id].Steps {
+			compositeStepID, _, err := models.GetStepIDStepDataPair(stepListItem)
+			if err != nil {
+				return nil, err
+			}
+			stepIDData, err := models.CreateStepIDDataFromString(compositeStepID, config.DefaultStepLibSource)
+			if err != nil {
+				return nil, err
+			}
+
+			switch stepIDData.SteplibSource {
+			case "path", "git", "_":
+				continue
+			}
+
+			if _, found := stepLock.Lookup(stepIDData); !found && !seen[compositeStepID] {
+				seen[compositeStepID] = true
+				drift = append(drift, fmt.Sprintf("%s: not locked", compositeStepID))
+			}
+		}
+	}
+	return drift, nil
+}
+
+// checkLockedStepCommit returns an error if the activated step's source commit differs from the locked one.
+func checkLockedStepCommit(lockedStep steplock.Step, specStep stepmanModels.StepModel) error {
+	if lockedStep.Commit == "" {
+		return nil
+	}
+	if commit := stepSourceCommit(specStep); commit != lockedStep.Commit {
+		return fmt.Errorf("step (%s@%s) source commit (%s) differs from the locked commit (%s)", lockedStep.ID, lockedStep.Version, commit, lockedStep.Commit)
+	}
+	return nil
+}
+
+func stepSourceCommit(step stepmanModels.StepModel) string {
+	if step.Source == nil {
+		return ""
+	}
+	return step.Source.Commit
+}
+
+func stepLockDriftError(drift []string) error {
+	return fmt.Errorf("steps differ from %s, run 'bitrise lock' to update it:\n- %s", steplock.FileName, strings.Join(drift, "\n- "))
+}
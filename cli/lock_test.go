@@ -0,0 +1,111 @@
+package cli
+
+import (
+	"errors"
+	"testing"
+
+	stepmanModels "github.com/bitrise-io/stepman/models"
+	"github.com/stretchr/testify/require"
+	"github.com/tothszabi/bitrise-test/models"
+	"github.com/tothszabi/bitrise-test/steplock"
+)
+
+const testStepLibSource = "https://github.com/bitrise-io/bitrise-steplib.git"
+
+func TestLockSteps(t *testing.T) {
+	script := models.StepIDData{SteplibSource: testStepLibSource, IDorURI: "script", Version: "1"}
+	gitClone := models.StepIDData{SteplibSource: testStepLibSource, IDorURI: "git-clone", Version: ""}
+
+	previous := steplock.New()
+	previous.Steps[steplock.Key(script)] = steplock.Step{Source: testStepLibSource, ID: "script", Version: "1.1.0"}
+	previous.Steps[testStepLibSource+"::deploy-to-bitrise-io@2"] = steplock.Step{Source: testStepLibSource, ID: "deploy-to-bitrise-io", Version: "2.0.0"}
+
+	var resolved []string
+	resolve := func(stepIDData models.StepIDData) (steplock.Step, error) {
+		resolved = append(resolved, stepIDData.IDorURI)
+		versions := map[string]string{"script": "1.2.0", "git-clone": "6.2.0"}
+		return steplock.Step{Source: stepIDData.SteplibSource, ID: stepIDData.IDorURI, Version: versions[stepIDData.IDorURI]}, nil
+	}
+
+	t.Log("locked steps are kept, new steps are resolved, unreferenced steps are dropped")
+	{
+		resolved = nil
+		current, err := lockSteps([]models.StepIDData{script, gitClone}, previous, false, resolve)
+		require.NoError(t, err)
+		require.Equal(t, []string{"git-clone"}, resolved)
+		require.Equal(t, "1.1.0", current.Steps[steplock.Key(script)].Version)
+		require.Equal(t, "6.2.0", current.Steps[steplock.Key(gitClone)].Version)
+		require.Equal(t, 2, len(current.Steps))
+
+		require.Equal(t, []string{
+			"+ " + testStepLibSource + "::git-clone@: 6.2.0",
+			"- " + testStepLibSource + "::deploy-to-bitrise-io@2",
+		}, stepLockChanges(previous, current))
+	}
+
+	t.Log("update resolves every step")
+	{
+		resolved = nil
+		current, err := lockSteps([]models.StepIDData{script, gitClone}, previous, true, resolve)
+		require.NoError(t, err)
+		require.Equal(t, []string{"script", "git-clone"}, resolved)
+		require.Equal(t, "1.2.0", current.Steps[steplock.Key(script)].Version)
+		require.Contains(t, stepLockChanges(previous, current), "~ "+testStepLibSource+"::script@1: 1.1.0 -> 1.2.0")
+	}
+
+	t.Log("resolve error")
+	{
+		_, err := lockSteps([]models.StepIDData{script}, steplock.New(), false, func(models.StepIDData) (steplock.Step, error) {
+			return steplock.Step{}, errors.New("step not found")
+		})
+		require.EqualError(t, err, "step not found")
+	}
+}
+
+func TestStepLockDrift(t *testing.T) {
+	config := models.BitriseDataModel{
+		DefaultStepLibSource: testStepLibSource,
+		Workflows: map[string]models.WorkflowModel{
+			"_setup": {
+				Steps: []models.StepListItemModel{{"activate-ssh-key@4": {}}},
+			},
+			"primary": {
+				BeforeRun: []string{"_setup"},
+				Steps: []models.StepListItemModel{
+					{"script@1": {}},
+					{"script@1": {}},
+					{"git-clone@6": {}},
+					{"path::./local-step": {}},
+					{"git::https://github.com/bitrise-steplib/steps-deploy.git@main": {}},
+				},
+			},
+		},
+	}
+
+	stepLock := steplock.New()
+	stepLock.Steps[testStepLibSource+"::script@1"] = steplock.Step{Source: testStepLibSource, ID: "script", Version: "1.2.0"}
+
+	drift, err := stepLockDrift(config, "primary", &stepLock)
+	require.NoError(t, err)
+	require.Equal(t, []string{"activate-ssh-key@4: not locked", "git-clone@6: not locked"}, drift)
+
+	stepIDData, lockedStep, isLocked := lockedStepIDData(&stepLock, models.StepIDData{SteplibSource: testStepLibSource, IDorURI: "script", Version: "1"})
+	require.True(t, isLocked)
+	require.Equal(t, "1.2.0", stepIDData.Version)
+	require.Equal(t, "script", lockedStep.ID)
+
+	_, _, isLocked = lockedStepIDData(nil, models.StepIDData{SteplibSource: testStepLibSource, IDorURI: "script", Version: "1"})
+	require.False(t, isLocked)
+}
+
+func TestCheckLockedStepCommit(t *testing.T) {
+	lockedStep := steplock.Step{Source: testStepLibSource, ID: "script", Version: "1.2.0", Commit: "abc"}
+
+	require.NoError(t, checkLockedStepCommit(lockedStep, stepmanModels.StepModel{Source: &stepmanModels.StepSourceModel{Commit: "abc"}}))
+	require.EqualError(t, checkLockedStepCommit(lockedStep, stepmanModels.StepModel{Source: &stepmanModels.StepSourceModel{Commit: "def"}}),
+		"step (script@1.2.0) source commit (def) differs from the locked commit (abc)")
+	require.Error(t, checkLockedStepCommit(lockedStep, stepmanModels.StepModel{}))
+
+	lockedStep.Commit = ""
+	require.NoError(t, checkLockedStepCommit(lockedStep, stepmanModels.StepModel{}))
+}
@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/tothszabi/bitrise-test/bitrise"
+	"github.com/tothszabi/bitrise-test/log"
+	"github.com/tothszabi/bitrise-test/models"
+	"github.com/urfave/cli"
+)
+
+const checkKey = "check"
+
+var migrateCommand = cli.Command{
+	Name:  "migrate",
+	Usage: "Migrates the bitrise config (bitrise.yml) to the current format version, in place.",
+	Action: func(c *cli.Context) error {
+		if err := migrate(c); err != nil {
+			log.Errorf("Failed to migrate config, error: %s", err)
+			os.Exit(1)
+		}
+		return nil
+	},
+	Flags: []cli.Flag{
+		flConfig,
+		cli.BoolFlag{
+			Name:  checkKey,
+			Usage: "Only report the required changes, without modifying the config. Exits with 1 if the config needs to be migrated.",
+		},
+	},
+}
+
+func migrate(c *cli.Context) error {
+	isCheck := c.Bool(checkKey)
+
+	bitriseConfigPath, err := GetBitriseConfigFilePath(c.String(ConfigKey))
+	if err != nil {
+		return fmt.Errorf("failed to get config (bitrise.yml) path: %s", err)
+	}
+	if bitriseConfigPath == "" {
+		return errors.New("failed to get config (bitrise.yml) path: empty bitriseConfigPath")
+	}
+	if strings.HasSuffix(bitriseConfigPath, ".json") {
+		return errors.New("only YAML configs can be migrated")
+	}
+
+	content, err := fileutil.ReadBytesFromFile(bitriseConfigPath)
+	if err != nil {
+		return err
+	}
+
+	migrated, changes, err := bitrise.MigrateConfig(content)
+	if err != nil {
+		return err
+	}
+
+	if len(changes) == 0 {
+		log.Donef("The config (%s) is up to date, format version: %s", bitriseConfigPath, models.FormatVersion)
+		return nil
+	}
+
+	if isCheck {
+		log.Warnf("The config (%s) needs to be migrated to format version %s:", bitriseConfigPath, models.FormatVersion)
+	} else {
+		log.Infof("Migrating the config (%s) to format version %s:", bitriseConfigPath, models.FormatVersion)
+	}
+	for _, change := range changes {
+		log.Printf("- %s", change)
+	}
+
+	if isCheck {
+		os.Exit(1)
+	}
+
+	if err := fileutil.WriteBytesToFile(bitriseConfigPath, migrated); err != nil {
+		return fmt.Errorf("failed to write file (%s): %s", bitriseConfigPath, err)
+	}
+
+	log.Donef("Done, config migrated")
+
+	return nil
+}
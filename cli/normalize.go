@@ -1,11 +1,17 @@
 package cli
 
 import (
+	"bytes"
+	"os"
+
+	"github.com/bitrise-io/go-utils/fileutil"
 	"github.com/tothszabi/bitrise-test/bitrise"
 	"github.com/tothszabi/bitrise-test/log"
 	"github.com/urfave/cli"
 )
 
+const fmtKey = "fmt"
+
 func normalize(c *cli.Context) error {
 	// Expand cli.Context
 	bitriseConfigBase64Data := c.String(ConfigBase64Key)
@@ -27,6 +33,14 @@ func normalize(c *cli.Context) error {
 		failf("No bitrise config path defined!")
 	}
 
+	if c.Bool(fmtKey) || c.Bool(checkKey) {
+		if bitriseConfigBase64Data != "" {
+			failf("The config can not be formatted from --%s, the formatted config is written to its file: use --%s", ConfigBase64Key, ConfigKey)
+		}
+		formatConfig(bitriseConfigPath, c.Bool(checkKey))
+		return nil
+	}
+
 	// Config validation
 	bitriseConfig, warnings, err := CreateBitriseConfigFromCLIParams(bitriseConfigBase64Data, bitriseConfigPath)
 	for _, warning := range warnings {
@@ -48,3 +62,31 @@ func normalize(c *cli.Context) error {
 
 	return nil
 }
+
+func formatConfig(bitriseConfigPath string, isCheck bool) {
+	content, err := fileutil.ReadBytesFromFile(bitriseConfigPath)
+	if err != nil {
+		failf("Failed to read config, error: %s", err)
+	}
+
+	formatted, err := bitrise.FormatConfig(content)
+	if err != nil {
+		failf("Failed to format config, error: %s", err)
+	}
+
+	if bytes.Equal(content, formatted) {
+		log.Donef("The config (%s) is formatted", bitriseConfigPath)
+		return
+	}
+
+	if isCheck {
+		log.Errorf("The config (%s) is not formatted, run: bitrise normalize --fmt", bitriseConfigPath)
+		os.Exit(1)
+	}
+
+	if err := fileutil.WriteBytesToFile(bitriseConfigPath, formatted); err != nil {
+		failf("Failed to save config to file, error: %s", err)
+	}
+
+	log.Info("Config formatted")
+}
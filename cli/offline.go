@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/command"
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/tothszabi/bitrise-test/bitrise"
+	"github.com/tothszabi/bitrise-test/log"
+	"github.com/tothszabi/bitrise-test/models"
+	"github.com/tothszabi/bitrise-test/steplock"
+	"github.com/tothszabi/bitrise-test/tools"
+)
+
+// offlineArtifactChecker checks if the artifacts required by a workflow are available without network access.
+type offlineArtifactChecker struct {
+	stepLibStep    func(source, id, version string) error
+	gitStep        func(repositoryURL string) error
+	containerImage func(engine, image string) error
+}
+
+var defaultOfflineArtifactChecker = offlineArtifactChecker{
+	stepLibStep: func(source, id, version string) error {
+		_, err := tools.OfflineStepCacheDirPath(source, id, version)
+		return err
+	},
+	gitStep: func(repositoryURL string) error {
+		_, err := bitrise.GitStepCloneURL(repositoryURL)
+		return err
+	},
+	containerImage: func(engine, image string) error {
+		if out, err := command.New(engine, "image", "inspect", image).RunAndReturnTrimmedCombinedOutput(); err != nil {
+			log.Debugf("%s image inspect %s: %s", engine, image, out)
+			return fmt.Errorf("image (%s) is not available locally (%s)", image, engine)
+		}
+		return nil
+	},
+}
+
+// checkOfflineArtifacts checks the steps (with their locked versions), container and service images of the workflow
+// and its before and after run workflows, and returns a single error listing every missing artifact, so the build fails before running any step.
+func checkOfflineArtifacts(config models.BitriseDataModel, workflowID string, stepLock *steplock.Model, checker offlineArtifactChecker) error {
+	var missing []string
+	seen := map[string]bool{}
+	addMissing := func(artifact string, err error) {
+		item := fmt.Sprintf("%s: %s", artifact, strings.TrimPrefix(err.Error(), "offline mode: "))
+		if !seen[item] {
+			seen[item] = true
+			missing = append(missing, item)
+		}
+	}
+
+	for _, id := range uniqueWorkflowIDs(walkWorkflows(workflowID, config.Workflows, nil)) {
+		workflow := config.Workflows[id]
+
+		for _, stepListItem := range workflow.Steps {
+			compositeStepID, _, err := models.GetStepIDStepDataPair(stepListItem)
+			if err != nil {
+				return err
+			}
+			stepIDData, err := models.CreateStepIDDataFromString(compositeStepID, config.DefaultStepLibSource)
+			if err != nil {
+				return err
+			}
+
+			switch stepIDData.SteplibSource {
+			case "path":
+				stepAbsLocalPth, err := pathutil.AbsPath(stepIDData.IDorURI)
+				if err != nil {
+					return err
+				}
+				if exist, err := pathutil.IsDirExists(stepAbsLocalPth); err != nil {
+					return err
+				} else if !exist {
+					addMissing(compositeStepID, fmt.Errorf("step directory (%s) does not exist", stepAbsLocalPth))
+				}
+			case "git", "_":
+				if err := checker.gitStep(stepIDData.IDorURI); err != nil {
+					addMissing(compositeStepID, err)
+				}
+			default:
+				stepIDData, _, _ = lockedStepIDData(stepLock, stepIDData)
+				if err := checker.stepLibStep(stepIDData.SteplibSource, stepIDData.IDorURI, stepIDData.Version); err != nil {
+					addMissing(compositeStepID, err)
+				}
+			}
+		}
+
+		if workflow.Container != nil {
+			engine := workflow.Container.Engine
+			if engine == "" {
+				engine = models.ContainerEngineDocker
+			}
+			if err := checker.containerImage(engine, workflow.Container.Image); err != nil {
+				addMissing(fmt.Sprintf("workflows.%s.container", id), err)
+			}
+		}
+
+		var serviceNames []string
+		for name := range workflow.Services {
+			serviceNames = append(serviceNames, name)
+		}
+		sort.Strings(serviceNames)
+		for _, name := range serviceNames {
+			service := workflow.Services[name]
+			if err := checker.containerImage(serviceEngine(service, workflow.Container), service.Image); err != nil {
+				addMissing(fmt.Sprintf("workflows.%s.services.%s", id, name), err)
+			}
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("offline mode: missing artifacts:\n- %s", strings.Join(missing, "\n- "))
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tothszabi/bitrise-test/models"
+)
+
+func TestCheckOfflineArtifacts(t *testing.T) {
+	checker := offlineArtifactChecker{
+		stepLibStep: func(source, id, version string) error {
+			if id == "script" {
+				return nil
+			}
+			return errors.New("offline mode: step is not cached")
+		},
+		gitStep: func(repositoryURL string) error {
+			return errors.New("offline mode: no mirror found")
+		},
+		containerImage: func(engine, image string) error {
+			if image == "golang:1.17" {
+				return nil
+			}
+			return errors.New("image not available")
+		},
+	}
+
+	t.Log("all artifacts available")
+	{
+		config := models.BitriseDataModel{
+			DefaultStepLibSource: "https://github.com/bitrise-io/bitrise-steplib.git",
+			Workflows: map[string]models.WorkflowModel{
+				"primary": {
+					Container: &models.ContainerModel{Image: "golang:1.17"},
+					Steps:     []models.StepListItemModel{{"script@1": {}}},
+				},
+			},
+		}
+		require.NoError(t, checkOfflineArtifacts(config, "primary", nil, checker))
+	}
+
+	t.Log("missing artifacts of the workflow and its before run workflow are listed")
+	{
+		config := models.BitriseDataModel{
+			DefaultStepLibSource: "https://github.com/bitrise-io/bitrise-steplib.git",
+			Workflows: map[string]models.WorkflowModel{
+				"setup": {
+					Steps: []models.StepListItemModel{{"git-clone@6": {}}},
+				},
+				"primary": {
+					BeforeRun: []string{"setup"},
+					Services:  map[string]models.ServiceModel{"db": {Image: "postgres:14"}},
+					Steps: []models.StepListItemModel{
+						{"script@1": {}},
+						{"git-clone@6": {}},
+						{"git::https://github.com/bitrise-steplib/steps-deploy.git@main": {}},
+						{"path::./does-not-exist": {}},
+					},
+				},
+			},
+		}
+		err := checkOfflineArtifacts(config, "primary", nil, checker)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "offline mode: missing artifacts:\n- git-clone@6: step is not cached\n- git::https://github.com/bitrise-steplib/steps-deploy.git@main: no mirror found\n- path::./does-not-exist: step directory")
+		require.Contains(t, err.Error(), "\n- workflows.primary.services.db: image not available")
+	}
+}
@@ -0,0 +1,169 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/colorstring"
+	"github.com/tothszabi/bitrise-test/log"
+	"github.com/tothszabi/bitrise-test/models"
+	"github.com/tothszabi/bitrise-test/output"
+	"github.com/urfave/cli"
+)
+
+var pipelineListCommand = cli.Command{
+	Name:  "pipelines",
+	Usage: "List of available pipelines in config, with their stages and workflows.",
+	Action: func(c *cli.Context) error {
+		if err := pipelineList(c); err != nil {
+			log.Errorf("List of available pipelines in config failed, error: %s", err)
+			os.Exit(1)
+		}
+		return nil
+	},
+	Flags: []cli.Flag{
+		flConfig,
+		flConfigBase64,
+		cli.StringFlag{
+			Name:  "format",
+			Usage: "Output format. Accepted: raw, json.",
+		},
+		cli.BoolFlag{
+			Name:  "id-only",
+			Usage: "Print pipeline ids only.",
+		},
+	},
+}
+
+// PipelineListOutputModel ...
+type PipelineListOutputModel struct {
+	Pipelines map[string]models.PipelineOverview `json:"pipelines,omitempty" yml:"pipelines,omitempty"`
+	Workflows map[string]models.WorkflowOverview `json:"workflows,omitempty" yml:"workflows,omitempty"`
+	IDOnly    bool                               `json:"-" yml:"-"`
+	Warnings  []string                           `json:"warnings,omitempty" yml:"warnings,omitempty"`
+	Error     string                             `json:"error,omitempty" yml:"error,omitempty"`
+}
+
+func (output PipelineListOutputModel) pipelineIDs() []string {
+	pipelineIDs := []string{}
+	for id := range output.Pipelines {
+		pipelineIDs = append(pipelineIDs, id)
+	}
+	sort.Strings(pipelineIDs)
+	return pipelineIDs
+}
+
+// String ...
+func (output PipelineListOutputModel) String() string {
+	message := ""
+	for _, warning := range output.Warnings {
+		message += colorstring.Yellow(warning) + "\n"
+	}
+	if output.Error != "" {
+		message += colorstring.Red(output.Error) + "\n"
+		return message
+	}
+
+	pipelineIDs := output.pipelineIDs()
+	if output.IDOnly {
+		return strings.Join(pipelineIDs, " ")
+	}
+
+	if len(pipelineIDs) == 0 {
+		return message + colorstring.Red("Config doesn't contain any pipeline")
+	}
+
+	message += "Pipelines\n"
+	message += "---------\n"
+	for _, id := range pipelineIDs {
+		pipeline := output.Pipelines[id]
+		message += fmt.Sprintf("⚡️ %s\n", colorstring.Green(id))
+		for _, stage := range pipeline.Stages {
+			message += fmt.Sprintf("  %s: %s\n", colorstring.Yellow("Stage"), stage.ID)
+			for _, workflowID := range stage.Workflows {
+				workflow := output.Workflows[workflowID]
+				message += fmt.Sprintf("    * %s (%d steps)", workflowID, workflow.ChainStepCount)
+				if len(workflow.RunChain) > 1 {
+					message += fmt.Sprintf(": %s", strings.Join(workflow.RunChain, " -> "))
+				}
+				message += "\n"
+			}
+		}
+		for _, trigger := range pipeline.TriggeredBy {
+			message += fmt.Sprintf("  %s: %s\n", colorstring.Yellow("Triggered by"), trigger)
+		}
+		message += "\n"
+	}
+
+	return message
+}
+
+// JSON ...
+func (output PipelineListOutputModel) JSON() string {
+	var toMarshal interface{}
+	if output.IDOnly {
+		toMarshal = output.pipelineIDs()
+	} else {
+		toMarshal = output
+	}
+
+	data, err := json.MarshalIndent(toMarshal, "", "\t")
+	if err != nil {
+		return fmt.Sprintf(`{"error":"%s"}`, err.Error())
+	}
+	return string(data) + "\n"
+}
+
+func pipelineList(c *cli.Context) error {
+	// Expand cli.Context
+	bitriseConfigBase64Data := c.String(ConfigBase64Key)
+	bitriseConfigPath := c.String(ConfigKey)
+
+	format := c.String(OuputFormatKey)
+	idOnly := c.Bool("id-only")
+
+	// Input validation
+	if format == "" {
+		format = output.FormatRaw
+	}
+	if format != output.FormatRaw && format != output.FormatJSON {
+		showSubcommandHelp(c)
+		return fmt.Errorf("invalid format: %s", format)
+	}
+
+	var logger Logger
+	logger = NewDefaultRawLogger()
+	if format == output.FormatJSON {
+		logger = NewDefaultJSONLogger()
+	}
+
+	// Config validation
+	bitriseConfig, warnings, err := CreateBitriseConfigFromCLIParams(bitriseConfigBase64Data, bitriseConfigPath)
+	if err != nil {
+		logger.Print(PipelineListOutputModel{Error: err.Error(), Warnings: warnings})
+		os.Exit(1)
+	}
+
+	overview := bitriseConfig.Overview()
+	pipelineListOutput := PipelineListOutputModel{
+		Pipelines: overview.Pipelines,
+		IDOnly:    idOnly,
+		Warnings:  warnings,
+	}
+	if !idOnly {
+		// only the workflows of the pipelines are listed
+		pipelineListOutput.Workflows = map[string]models.WorkflowOverview{}
+		for pipelineID := range overview.Pipelines {
+			for _, workflowID := range bitriseConfig.PipelineWorkflows(pipelineID) {
+				pipelineListOutput.Workflows[workflowID] = overview.Workflows[workflowID]
+			}
+		}
+	}
+
+	logger.Print(pipelineListOutput)
+
+	return nil
+}
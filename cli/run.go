@@ -12,6 +12,7 @@ import (
 	"github.com/bitrise-io/go-utils/colorstring"
 	"github.com/bitrise-io/go-utils/pointers"
 	coreanalytics "github.com/bitrise-io/go-utils/v2/analytics"
+	stepmanModels "github.com/bitrise-io/stepman/models"
 	"github.com/gofrs/uuid"
 	"github.com/tothszabi/bitrise-test/analytics"
 	"github.com/tothszabi/bitrise-test/bitrise"
@@ -19,6 +20,7 @@ import (
 	"github.com/tothszabi/bitrise-test/log"
 	"github.com/tothszabi/bitrise-test/models"
 	"github.com/tothszabi/bitrise-test/plugins"
+	"github.com/tothszabi/bitrise-test/steplock"
 	"github.com/tothszabi/bitrise-test/toolkits"
 	"github.com/tothszabi/bitrise-test/tools"
 	"github.com/tothszabi/bitrise-test/version"
@@ -45,6 +47,8 @@ type RunConfig struct {
 	Config   models.BitriseDataModel
 	Workflow string
 	Secrets  []envmanModels.EnvironmentItemModel
+	// StepLock is the config's step lock, nil if the config has no lock file.
+	StepLock *steplock.Model
 }
 
 var runCommand = cli.Command{
@@ -126,6 +130,28 @@ func (r WorkflowRunner) RunWorkflowsWithSetupAndCheckForUpdate() (int, error) {
 		return 1, fmt.Errorf("specified Workflow (%s) does not exist", r.config.Workflow)
 	}
 
+	if r.config.StepLock != nil {
+		drift, err := stepLockDrift(r.config.Config, r.config.Workflow, r.config.StepLock)
+		if err != nil {
+			return 1, err
+		}
+		if len(drift) > 0 {
+			if r.config.Modes.CIMode {
+				return 1, stepLockDriftError(drift)
+			}
+			for _, item := range drift {
+				log.Warnf("Step lock drift: %s, run 'bitrise lock' to update %s", item, steplock.FileName)
+			}
+		}
+	}
+
+	// Fail fast, before running any step, if the build can not run without network access
+	if configs.IsOfflineMode {
+		if err := checkOfflineArtifacts(r.config.Config, r.config.Workflow, r.config.StepLock, defaultOfflineArtifactChecker); err != nil {
+			return 1, err
+		}
+	}
+
 	tracker := analytics.NewDefaultTracker()
 	defer func() {
 		tracker.Wait()
@@ -151,53 +177,9 @@ func (r WorkflowRunner) RunWorkflowsWithSetupAndCheckForUpdate() (int, error) {
 func (r WorkflowRunner) runWorkflows(tracker analytics.Tracker) (models.BuildRunResultsModel, error) {
 	startTime := time.Now()
 
-	// Register run modes
-	if err := registerRunModes(r.config.Modes); err != nil {
-		return models.BuildRunResultsModel{}, fmt.Errorf("failed to register workflow run modes: %s", err)
-	}
-
-	targetWorkflow := r.config.Config.Workflows[r.config.Workflow]
-	if targetWorkflow.Title == "" {
-		targetWorkflow.Title = r.config.Workflow
-	}
-
-	// Envman setup
-	if err := os.Setenv(configs.EnvstorePathEnvKey, configs.OutputEnvstorePath); err != nil {
-		return models.BuildRunResultsModel{}, fmt.Errorf("failed to add env, err: %s", err)
-	}
-
-	if err := os.Setenv(configs.FormattedOutputPathEnvKey, configs.FormattedOutputPath); err != nil {
-		return models.BuildRunResultsModel{}, fmt.Errorf("failed to add env, err: %s", err)
-	}
-
-	if err := tools.EnvmanInit(configs.OutputEnvstorePath, false); err != nil {
-		return models.BuildRunResultsModel{}, fmt.Errorf("failed to run envman init: %s", err)
-	}
-
-	// App level environment
-	environments := append(r.config.Secrets, r.config.Config.App.Environments...)
-
-	if err := os.Setenv("BITRISE_TRIGGERED_WORKFLOW_ID", r.config.Workflow); err != nil {
-		return models.BuildRunResultsModel{}, fmt.Errorf("failed to set BITRISE_TRIGGERED_WORKFLOW_ID env: %s", err)
-	}
-	if err := os.Setenv("BITRISE_TRIGGERED_WORKFLOW_TITLE", targetWorkflow.Title); err != nil {
-		return models.BuildRunResultsModel{}, fmt.Errorf("failed to set BITRISE_TRIGGERED_WORKFLOW_TITLE env: %s", err)
-	}
-
-	environments = append(environments, targetWorkflow.Environments...)
-
-	// Bootstrap Toolkits
-	for _, aToolkit := range toolkits.AllSupportedToolkits() {
-		toolkitName := aToolkit.ToolkitName()
-		if !aToolkit.IsToolAvailableInPATH() {
-			// don't bootstrap if any preinstalled version is available,
-			// the toolkit's `PrepareForStepRun` can bootstrap for itself later if required
-			// or if the system installed version is not sufficient
-			if err := aToolkit.Bootstrap(); err != nil {
-				return models.BuildRunResultsModel{}, fmt.Errorf("failed to bootstrap the required toolkit for the step (%s), error: %s",
-					toolkitName, err)
-			}
-		}
+	environments, err := r.setupRun()
+	if err != nil {
+		return models.BuildRunResultsModel{}, err
 	}
 
 	// Trigger WillStartRun
@@ -223,6 +205,22 @@ func (r WorkflowRunner) runWorkflows(tracker analytics.Tracker) (models.BuildRun
 		return models.BuildRunResultsModel{}, fmt.Errorf("execution plan doesn't have any workflow to run")
 	}
 
+	// Validate step inputs before running any step
+	var plannedWorkflowIDs []string
+	for _, workflowRunPlan := range plan.ExecutionPlan {
+		plannedWorkflowIDs = append(plannedWorkflowIDs, workflowRunPlan.WorkflowID)
+	}
+	inputWarnings, err := validateWorkflowsStepInputs(r.config.Config, uniqueWorkflowIDs(plannedWorkflowIDs), func(stepIDData models.StepIDData) (stepmanModels.StepModel, bool, error) {
+		stepIDData, _, _ = lockedStepIDData(r.config.StepLock, stepIDData)
+		return defaultStepSpecProvider(stepIDData)
+	})
+	for _, warning := range inputWarnings {
+		log.Warnf("warning: %s", warning)
+	}
+	if err != nil {
+		return models.BuildRunResultsModel{}, err
+	}
+
 	buildIDProperties := coreanalytics.Properties{analytics.BuildExecutionID: uuid.Must(uuid.NewV4()).String()}
 
 	log.PrintBitriseStartedEvent(plan)
@@ -249,36 +247,65 @@ func (r WorkflowRunner) runWorkflows(tracker analytics.Tracker) (models.BuildRun
 	return buildRunResults, nil
 }
 
-func processArgs(c *cli.Context) (*RunConfig, error) {
-	workflowToRunID := c.String(WorkflowKey)
-	if workflowToRunID == "" && len(c.Args()) > 0 {
-		workflowToRunID = c.Args()[0]
+// setupRun registers the run modes, initializes the envstores and bootstraps the toolkits,
+// returns the environments of the run (the secrets, the app and the target workflow's envs).
+func (r WorkflowRunner) setupRun() ([]envmanModels.EnvironmentItemModel, error) {
+	// Register run modes
+	if err := registerRunModes(r.config.Modes); err != nil {
+		return nil, fmt.Errorf("failed to register workflow run modes: %s", err)
 	}
 
-	var prGlobalFlagPtr *bool
-	if c.GlobalIsSet(PRKey) {
-		prGlobalFlagPtr = pointers.NewBoolPtr(c.GlobalBool(PRKey))
+	targetWorkflow := r.config.Config.Workflows[r.config.Workflow]
+	if targetWorkflow.Title == "" {
+		targetWorkflow.Title = r.config.Workflow
 	}
 
-	var ciGlobalFlagPtr *bool
-	if c.GlobalIsSet(CIKey) {
-		ciGlobalFlagPtr = pointers.NewBoolPtr(c.GlobalBool(CIKey))
+	// Envman setup
+	if err := os.Setenv(configs.EnvstorePathEnvKey, configs.OutputEnvstorePath); err != nil {
+		return nil, fmt.Errorf("failed to add env, err: %s", err)
 	}
 
-	var secretFiltering *bool
-	if c.IsSet(secretFilteringFlag) {
-		secretFiltering = pointers.NewBoolPtr(c.Bool(secretFilteringFlag))
-	} else if os.Getenv(configs.IsSecretFilteringKey) == "true" {
-		secretFiltering = pointers.NewBoolPtr(true)
-	} else if os.Getenv(configs.IsSecretFilteringKey) == "false" {
-		secretFiltering = pointers.NewBoolPtr(false)
+	if err := os.Setenv(configs.FormattedOutputPathEnvKey, configs.FormattedOutputPath); err != nil {
+		return nil, fmt.Errorf("failed to add env, err: %s", err)
 	}
 
-	var secretEnvsFiltering *bool
-	if os.Getenv(configs.IsSecretEnvsFilteringKey) == "true" {
-		secretEnvsFiltering = pointers.NewBoolPtr(true)
-	} else if os.Getenv(configs.IsSecretEnvsFilteringKey) == "false" {
-		secretEnvsFiltering = pointers.NewBoolPtr(false)
+	if err := tools.EnvmanInit(configs.OutputEnvstorePath, false); err != nil {
+		return nil, fmt.Errorf("failed to run envman init: %s", err)
+	}
+
+	// App level environment
+	environments := append(r.config.Secrets, r.config.Config.App.Environments...)
+
+	if err := os.Setenv("BITRISE_TRIGGERED_WORKFLOW_ID", r.config.Workflow); err != nil {
+		return nil, fmt.Errorf("failed to set BITRISE_TRIGGERED_WORKFLOW_ID env: %s", err)
+	}
+	if err := os.Setenv("BITRISE_TRIGGERED_WORKFLOW_TITLE", targetWorkflow.Title); err != nil {
+		return nil, fmt.Errorf("failed to set BITRISE_TRIGGERED_WORKFLOW_TITLE env: %s", err)
+	}
+
+	environments = append(environments, targetWorkflow.Environments...)
+
+	// Bootstrap Toolkits
+	for _, aToolkit := range toolkits.AllSupportedToolkits() {
+		toolkitName := aToolkit.ToolkitName()
+		if !aToolkit.IsToolAvailableInPATH() {
+			// don't bootstrap if any preinstalled version is available,
+			// the toolkit's `PrepareForStepRun` can bootstrap for itself later if required
+			// or if the system installed version is not sufficient
+			if err := aToolkit.Bootstrap(); err != nil {
+				return nil, fmt.Errorf("failed to bootstrap the required toolkit for the step (%s), error: %s",
+					toolkitName, err)
+			}
+		}
+	}
+
+	return environments, nil
+}
+
+func processArgs(c *cli.Context) (*RunConfig, error) {
+	workflowToRunID := c.String(WorkflowKey)
+	if workflowToRunID == "" && len(c.Args()) > 0 {
+		workflowToRunID = c.Args()[0]
 	}
 
 	bitriseConfigBase64Data := c.String(ConfigBase64Key)
@@ -324,40 +351,82 @@ func processArgs(c *cli.Context) (*RunConfig, error) {
 		return nil, fmt.Errorf("failed to create bitrise config: %s", err)
 	}
 
+	stepLock, err := readConfigStepLock(runParams.BitriseConfigBase64Data, runParams.BitriseConfigPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read step lock: %s", err)
+	}
+
+	modes, err := runModes(c, inventoryEnvironments)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RunConfig{
+		Modes:    modes,
+		Config:   bitriseConfig,
+		Workflow: runParams.WorkflowToRunID,
+		Secrets:  inventoryEnvironments,
+		StepLock: stepLock,
+	}, nil
+}
+
+// runModes returns the run modes defined by the global flags, the command's flags, the env vars and the inventory.
+func runModes(c *cli.Context, inventoryEnvironments []envmanModels.EnvironmentItemModel) (models.WorkflowRunModes, error) {
+	var prGlobalFlagPtr *bool
+	if c.GlobalIsSet(PRKey) {
+		prGlobalFlagPtr = pointers.NewBoolPtr(c.GlobalBool(PRKey))
+	}
+
+	var ciGlobalFlagPtr *bool
+	if c.GlobalIsSet(CIKey) {
+		ciGlobalFlagPtr = pointers.NewBoolPtr(c.GlobalBool(CIKey))
+	}
+
+	var secretFiltering *bool
+	if c.IsSet(secretFilteringFlag) {
+		secretFiltering = pointers.NewBoolPtr(c.Bool(secretFilteringFlag))
+	} else if os.Getenv(configs.IsSecretFilteringKey) == "true" {
+		secretFiltering = pointers.NewBoolPtr(true)
+	} else if os.Getenv(configs.IsSecretFilteringKey) == "false" {
+		secretFiltering = pointers.NewBoolPtr(false)
+	}
+
+	var secretEnvsFiltering *bool
+	if os.Getenv(configs.IsSecretEnvsFilteringKey) == "true" {
+		secretEnvsFiltering = pointers.NewBoolPtr(true)
+	} else if os.Getenv(configs.IsSecretEnvsFilteringKey) == "false" {
+		secretEnvsFiltering = pointers.NewBoolPtr(false)
+	}
+
 	isPRMode, err := isPRMode(prGlobalFlagPtr, inventoryEnvironments)
 	if err != nil {
-		return nil, fmt.Errorf("failed to check PR mode: %s", err)
+		return models.WorkflowRunModes{}, fmt.Errorf("failed to check PR mode: %s", err)
 	}
 
 	isCIMode, err := isCIMode(ciGlobalFlagPtr, inventoryEnvironments)
 	if err != nil {
-		return nil, fmt.Errorf("failed to check CI mode: %s", err)
+		return models.WorkflowRunModes{}, fmt.Errorf("failed to check CI mode: %s", err)
 	}
 
 	enabledFiltering, err := isSecretFiltering(secretFiltering, inventoryEnvironments)
 	if err != nil {
-		return nil, fmt.Errorf("failed to check Secret Filtering mode: %s", err)
+		return models.WorkflowRunModes{}, fmt.Errorf("failed to check Secret Filtering mode: %s", err)
 	}
 
 	enabledEnvsFiltering, err := isSecretEnvsFiltering(secretEnvsFiltering, inventoryEnvironments)
 	if err != nil {
-		return nil, fmt.Errorf("failed to check Secret Envs Filtering mode: %s", err)
+		return models.WorkflowRunModes{}, fmt.Errorf("failed to check Secret Envs Filtering mode: %s", err)
 	}
 
 	noOutputTimeout := readNoOutputTimoutConfiguration(inventoryEnvironments)
 
-	return &RunConfig{
-		Modes: models.WorkflowRunModes{
-			CIMode:                  isCIMode,
-			PRMode:                  isPRMode,
-			DebugMode:               configs.IsDebugMode,
-			NoOutputTimeout:         noOutputTimeout,
-			SecretFilteringMode:     enabledFiltering,
-			SecretEnvsFilteringMode: enabledEnvsFiltering,
-		},
-		Config:   bitriseConfig,
-		Workflow: runParams.WorkflowToRunID,
-		Secrets:  inventoryEnvironments,
+	return models.WorkflowRunModes{
+		CIMode:                  isCIMode,
+		PRMode:                  isPRMode,
+		DebugMode:               configs.IsDebugMode,
+		NoOutputTimeout:         noOutputTimeout,
+		SecretFilteringMode:     enabledFiltering,
+		SecretEnvsFilteringMode: enabledEnvsFiltering,
 	}, nil
 }
 
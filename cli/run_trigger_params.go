@@ -22,6 +22,15 @@ type RunAndTriggerParamsModel struct {
 	PRTargetBranch string `json:"pr-target-branch"`
 	Tag            string `json:"tag"`
 
+	// ChangedFiles is nil if the changed files are not specified
+	ChangedFiles     []string `json:"changed-files"`
+	ChangedFilesDiff string   `json:"changed-files-diff"`
+
+	CommitMessage string `json:"commit-message"`
+	// PRLabels is nil if the pull request labels are not specified
+	PRLabels  []string `json:"pr-labels"`
+	IsDraftPR bool     `json:"pr-draft"`
+
 	// Trigger Check Params
 	Format string `json:"format"`
 
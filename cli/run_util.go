@@ -28,6 +28,7 @@ import (
 	"github.com/tothszabi/bitrise-test/configs"
 	"github.com/tothszabi/bitrise-test/log"
 	"github.com/tothszabi/bitrise-test/models"
+	"github.com/tothszabi/bitrise-test/steplock"
 	"github.com/tothszabi/bitrise-test/stepoutput"
 	"github.com/tothszabi/bitrise-test/toolkits"
 	"github.com/tothszabi/bitrise-test/tools"
@@ -161,14 +162,19 @@ func isDirEmpty(path string) (bool, error) {
 
 // GetBitriseConfigFromBase64Data ...
 func GetBitriseConfigFromBase64Data(configBase64Str string) (models.BitriseDataModel, []string, error) {
+	config, warnings, err := getBitriseConfigWithIssuesFromBase64Data(configBase64Str)
+	return config, models.ValidationIssueMessages(warnings), err
+}
+
+func getBitriseConfigWithIssuesFromBase64Data(configBase64Str string) (models.BitriseDataModel, []models.ValidationIssue, error) {
 	configBase64Bytes, err := base64.StdEncoding.DecodeString(configBase64Str)
 	if err != nil {
-		return models.BitriseDataModel{}, []string{}, fmt.Errorf("Failed to decode base 64 string, error: %s", err)
+		return models.BitriseDataModel{}, []models.ValidationIssue{}, fmt.Errorf("Failed to decode base 64 string, error: %s", err)
 	}
 
-	config, warnings, err := bitrise.ConfigModelFromYAMLBytes(configBase64Bytes)
+	config, warnings, err := bitrise.ConfigModelWithIssuesFromYAMLBytes(configBase64Bytes, "")
 	if err != nil {
-		return models.BitriseDataModel{}, warnings, fmt.Errorf("Failed to parse bitrise config, error: %s", err)
+		return models.BitriseDataModel{}, warnings, fmt.Errorf("Failed to parse bitrise config, error: %w", err)
 	}
 
 	return config, warnings, nil
@@ -191,29 +197,36 @@ func GetBitriseConfigFilePath(bitriseConfigPath string) (string, error) {
 
 // CreateBitriseConfigFromCLIParams ...
 func CreateBitriseConfigFromCLIParams(bitriseConfigBase64Data, bitriseConfigPath string) (models.BitriseDataModel, []string, error) {
+	bitriseConfig, warnings, err := createBitriseConfigWithIssuesFromCLIParams(bitriseConfigBase64Data, bitriseConfigPath)
+	return bitriseConfig, models.ValidationIssueMessages(warnings), err
+}
+
+// createBitriseConfigWithIssuesFromCLIParams returns the config validation warnings with their source positions,
+// the config validation error (models.ValidationIssue) is wrapped into the returned error.
+func createBitriseConfigWithIssuesFromCLIParams(bitriseConfigBase64Data, bitriseConfigPath string) (models.BitriseDataModel, []models.ValidationIssue, error) {
 	bitriseConfig := models.BitriseDataModel{}
-	warnings := []string{}
+	warnings := []models.ValidationIssue{}
 
 	if bitriseConfigBase64Data != "" {
-		config, warns, err := GetBitriseConfigFromBase64Data(bitriseConfigBase64Data)
+		config, warns, err := getBitriseConfigWithIssuesFromBase64Data(bitriseConfigBase64Data)
 		warnings = warns
 		if err != nil {
-			return models.BitriseDataModel{}, warnings, fmt.Errorf("Failed to get config (bitrise.yml) from base 64 data, err: %s", err)
+			return models.BitriseDataModel{}, warnings, fmt.Errorf("Failed to get config (bitrise.yml) from base 64 data, err: %w", err)
 		}
 		bitriseConfig = config
 	} else {
 		bitriseConfigPath, err := GetBitriseConfigFilePath(bitriseConfigPath)
 		if err != nil {
-			return models.BitriseDataModel{}, []string{}, fmt.Errorf("Failed to get config (bitrise.yml) path: %s", err)
+			return models.BitriseDataModel{}, []models.ValidationIssue{}, fmt.Errorf("Failed to get config (bitrise.yml) path: %s", err)
 		}
 		if bitriseConfigPath == "" {
-			return models.BitriseDataModel{}, []string{}, errors.New("Failed to get config (bitrise.yml) path: empty bitriseConfigPath")
+			return models.BitriseDataModel{}, []models.ValidationIssue{}, errors.New("Failed to get config (bitrise.yml) path: empty bitriseConfigPath")
 		}
 
-		config, warns, err := bitrise.ReadBitriseConfig(bitriseConfigPath)
+		config, warns, err := bitrise.ReadBitriseConfigWithIssues(bitriseConfigPath)
 		warnings = warns
 		if err != nil {
-			return models.BitriseDataModel{}, warnings, fmt.Errorf("Config (path:%s) is not valid: %s", bitriseConfigPath, err)
+			return models.BitriseDataModel{}, warnings, fmt.Errorf("Config (path:%s) is not valid: %w", bitriseConfigPath, err)
 		}
 		bitriseConfig = config
 	}
@@ -379,8 +392,12 @@ func (r WorkflowRunner) executeStep(
 	stepUUID string,
 	step stepmanModels.StepModel, sIDData models.StepIDData,
 	stepAbsDirPath, bitriseSourceDir string,
+	container *models.ContainerModel,
 	secrets []string) (int, error) {
-	toolkitForStep := toolkits.ToolkitForStep(step)
+	toolkitForStep := toolkits.ToolkitForStep(step, stepAbsDirPath)
+	if container != nil {
+		toolkitForStep = toolkits.NewContainerToolkit(*container, toolkitForStep, bitriseSourceDir)
+	}
 	toolkitName := toolkitForStep.ToolkitName()
 
 	if err := toolkitForStep.PrepareForStepRun(step, sIDData, stepAbsDirPath); err != nil {
@@ -415,7 +432,7 @@ func (r WorkflowRunner) executeStep(
 	opts.DebugLogEnabled = true
 	writer := stepoutput.NewWriter(stepSecrets, opts)
 
-	return tools.EnvmanRun(
+	exitCode, err := tools.EnvmanRun(
 		configs.InputEnvstorePath,
 		bitriseSourceDir,
 		cmd,
@@ -423,11 +440,23 @@ func (r WorkflowRunner) executeStep(
 		noOutputTimeout,
 		nil,
 		writer)
+
+	if collector, ok := toolkitForStep.(toolkits.StepOutputCollector); ok {
+		if collectErr := collector.CollectStepOutputs(configs.OutputEnvstorePath); collectErr != nil {
+			if err != nil {
+				return exitCode, err
+			}
+			return 1, fmt.Errorf("Failed to collect the step outputs from the container, error: %s", collectErr)
+		}
+	}
+
+	return exitCode, err
 }
 
 func (r WorkflowRunner) runStep(
 	stepUUID string,
 	step stepmanModels.StepModel, stepIDData models.StepIDData, stepDir string,
+	container *models.ContainerModel,
 	environments []envmanModels.EnvironmentItemModel, secrets []string) (int, []envmanModels.EnvironmentItemModel, error) {
 	log.Debugf("[BITRISE_CLI] - Try running step: %s (%s)", stepIDData.IDorURI, stepIDData.Version)
 
@@ -465,7 +494,7 @@ func (r WorkflowRunner) runStep(
 		bitriseSourceDir = configs.CurrentDir
 	}
 
-	if exit, err := r.executeStep(stepUUID, step, stepIDData, stepDir, bitriseSourceDir, secrets); err != nil {
+	if exit, err := r.executeStep(stepUUID, step, stepIDData, stepDir, bitriseSourceDir, container, secrets); err != nil {
 		stepOutputs, envErr := bitrise.CollectEnvironmentsFromFile(configs.OutputEnvstorePath)
 		if envErr != nil {
 			return 1, []envmanModels.EnvironmentItemModel{}, envErr
@@ -533,6 +562,10 @@ func activateStepLibStep(stepIDData models.StepIDData, destination, stepYMLCopyP
 	isStepLibUpdateNeeded := (versionConstraint.VersionLockType == stepmanModels.Latest) ||
 		(versionConstraint.VersionLockType == stepmanModels.MinorLocked) ||
 		(versionConstraint.VersionLockType == stepmanModels.MajorLocked)
+	if configs.IsOfflineMode {
+		// the StepLib can not be updated, the step is resolved from the local StepLib
+		isStepLibUpdateNeeded = false
+	}
 	if !isStepLibUpdated && isStepLibUpdateNeeded {
 		log.Print("Step uses latest version, updating StepLib...")
 		if err := tools.StepmanUpdate(stepIDData.SteplibSource); err != nil {
@@ -544,7 +577,7 @@ func activateStepLibStep(stepIDData models.StepIDData, destination, stepYMLCopyP
 
 	info, err := tools.StepmanStepInfo(stepIDData.SteplibSource, stepIDData.IDorURI, stepIDData.Version)
 	if err != nil {
-		if isStepLibUpdated {
+		if isStepLibUpdated || configs.IsOfflineMode {
 			return stepmanModels.StepInfoModel{}, didStepLibUpdate, fmt.Errorf("stepman JSON steplib step info failed: %s", err)
 		}
 
@@ -597,6 +630,19 @@ func (r WorkflowRunner) activateAndRunSteps(
 	var stepStartTime time.Time
 	runResultCollector := newBuildRunResultCollector(tracker)
 
+	// ------------------------------------------
+	// Starting the workflow's services, they are removed after the last step, even if the workflow failed
+	serviceEnvironments, serviceNetwork, stopServices, servicesErr := startWorkflowServices(plan.UUID, workflow, *environments)
+	defer stopServices()
+	if servicesErr != nil {
+		log.Errorf("Failed to start services: %s", servicesErr)
+	}
+	stepContainer := workflow.Container
+	if serviceNetwork != "" {
+		networkedContainer := containerWithNetwork(*workflow.Container, serviceNetwork)
+		stepContainer = &networkedContainer
+	}
+
 	// ------------------------------------------
 	// Main - Preparing & running the steps
 	for idx, stepListItm := range workflow.Steps {
@@ -622,6 +668,12 @@ func (r WorkflowRunner) activateAndRunSteps(
 			continue
 		}
 
+		if servicesErr != nil {
+			runResultCollector.registerStepRunResults(&buildRunResults, stepExecutionID, stepStartTime, stepmanModels.StepModel{}, stepInfoPtr, stepIdxPtr,
+				"", models.StepRunStatusCodePreparationFailed, 1, servicesErr, isLastStep, true, map[string]string{}, stepStartedProperties)
+			continue
+		}
+
 		//
 		// Preparing the step
 		if err := tools.EnvmanInit(configs.InputEnvstorePath, true); err != nil {
@@ -656,6 +708,9 @@ func (r WorkflowRunner) activateAndRunSteps(
 				"", models.StepRunStatusCodePreparationFailed, 1, err, isLastStep, true, map[string]string{}, stepStartedProperties)
 			continue
 		}
+		// StepLib steps are activated with their locked versions
+		stepIDData, lockedStep, isLocked := lockedStepIDData(r.config.StepLock, stepIDData)
+
 		stepInfoPtr.ID = stepIDData.IDorURI
 		if stepInfoPtr.Step.Title == nil || *stepInfoPtr.Step.Title == "" {
 			stepInfoPtr.Step.Title = pointers.NewStringPtr(stepIDData.IDorURI)
@@ -667,7 +722,7 @@ func (r WorkflowRunner) activateAndRunSteps(
 		// Activating the step
 		stepDir := configs.BitriseWorkStepsDirPath
 
-		activator := newStepActivator()
+		activator := newStepActivator(r.config.Config.GitStep)
 		stepYMLPth, origStepYMLPth, err := activator.activateStep(stepIDData, &buildRunResults, stepDir, configs.BitriseWorkDirPath, &workflowStep, &stepInfoPtr)
 		if err != nil {
 			runResultCollector.registerStepRunResults(&buildRunResults, stepExecutionID, stepStartTime, stepmanModels.StepModel{}, stepInfoPtr, stepIdxPtr,
@@ -693,6 +748,30 @@ func (r WorkflowRunner) activateAndRunSteps(
 				continue
 			}
 
+			if isLocked {
+				if err := checkLockedStepCommit(lockedStep, specStep); err != nil {
+					if r.config.Modes.CIMode {
+						runResultCollector.registerStepRunResults(&buildRunResults, stepExecutionID, stepStartTime, stepmanModels.StepModel{}, stepInfoPtr, stepIdxPtr,
+							"", models.StepRunStatusCodePreparationFailed, 1, stepLockDriftError([]string{err.Error()}), isLastStep, true, map[string]string{}, stepStartedProperties)
+						continue
+					}
+					log.Warnf("Step lock drift: %s, run 'bitrise lock' to update %s", err, steplock.FileName)
+				}
+			}
+
+			if stepIDData.SteplibSource == "git" {
+				// direct git steps' definitions are only available after cloning, so these are not validated before the run
+				inputWarnings, err := models.ValidateStepInputs(plan.WorkflowID, idx, compositeStepIDStr, specStep, workflowStep)
+				for _, warning := range inputWarnings {
+					log.Warnf("warning: %s", warning)
+				}
+				if err != nil {
+					runResultCollector.registerStepRunResults(&buildRunResults, stepExecutionID, stepStartTime, stepmanModels.StepModel{}, stepInfoPtr, stepIdxPtr,
+						"", models.StepRunStatusCodePreparationFailed, 1, err, isLastStep, true, map[string]string{}, stepStartedProperties)
+					continue
+				}
+			}
+
 			mergedStep, err = models.MergeStepWith(specStep, workflowStep)
 			if err != nil {
 				runResultCollector.registerStepRunResults(&buildRunResults, stepExecutionID, stepStartTime, stepmanModels.StepModel{}, stepInfoPtr, stepIdxPtr,
@@ -796,6 +875,9 @@ func (r WorkflowRunner) activateAndRunSteps(
 				})
 			}
 
+			// the connection envs of the workflow's services
+			additionalEnvironments = append(additionalEnvironments, serviceEnvironments...)
+
 			environmentItemModels := append(*environments, additionalEnvironments...)
 			envSource := &env.DefaultEnvironmentSource{}
 			stepDeclaredEnvironments, expandedStepEnvironment, redactedInputsWithType, err := prepareStepEnvironment(prepareStepInputParams{
@@ -844,7 +926,7 @@ func (r WorkflowRunner) activateAndRunSteps(
 
 			tracker.SendStepStartedEvent(stepStartedProperties, prepareAnalyticsStepInfo(mergedStep, stepInfoPtr), redactedInputsWithType, redactedOriginalInputs)
 
-			exit, outEnvironments, err := r.runStep(stepExecutionID, mergedStep, stepIDData, stepDir, stepDeclaredEnvironments, stepSecrets)
+			exit, outEnvironments, err := r.runStep(stepExecutionID, mergedStep, stepIDData, stepDir, stepContainer, stepDeclaredEnvironments, stepSecrets)
 
 			if testDirPath != "" {
 				if err := addTestMetadata(testDirPath, models.TestResultStepInfo{Number: idx, Title: *mergedStep.Title, ID: stepIDData.IDorURI, Version: stepIDData.Version}); err != nil {
@@ -888,7 +970,7 @@ func logStepStarted(stepInfo stepmanModels.StepInfoModel, step stepmanModels.Ste
 		Id:          stepInfo.ID,
 		Version:     stepInfo.Version,
 		Collection:  stepInfo.Library,
-		Toolkit:     toolkits.ToolkitForStep(step).ToolkitName(),
+		Toolkit:     toolkits.ToolkitForStep(step, configs.BitriseWorkStepsDirPath).ToolkitName(),
 		StartTime:   stepStartTime.Format(time.RFC3339),
 	}
 	log.PrintStepStartedEvent(params)
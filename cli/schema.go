@@ -0,0 +1,51 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/tothszabi/bitrise-test/log"
+	"github.com/tothszabi/bitrise-test/models"
+	"github.com/urfave/cli"
+)
+
+var schemaCommand = cli.Command{
+	Name:  "schema",
+	Usage: "Generates the JSON Schema of the bitrise config (bitrise.yml), for IDE autocompletion and validation.",
+	Action: func(c *cli.Context) error {
+		if err := schema(c); err != nil {
+			log.Errorf("Failed to generate JSON Schema, error: %s", err)
+			os.Exit(1)
+		}
+		return nil
+	},
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  OuputPathKey,
+			Usage: "Output path, where the JSON Schema will be saved. If not defined the JSON Schema is printed to the standard output.",
+		},
+	},
+}
+
+func schema(c *cli.Context) error {
+	outputPth := c.String(OuputPathKey)
+
+	schemaBytes, err := models.GenerateJSONSchemaBytes()
+	if err != nil {
+		return err
+	}
+
+	if outputPth == "" {
+		fmt.Print(string(schemaBytes))
+		return nil
+	}
+
+	if err := fileutil.WriteBytesToFile(outputPth, schemaBytes); err != nil {
+		return fmt.Errorf("failed to write file (%s): %s", outputPth, err)
+	}
+
+	log.Infof("Done, saved to path: %s", outputPth)
+
+	return nil
+}
@@ -0,0 +1,153 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/tothszabi/bitrise-test/log"
+	"github.com/tothszabi/bitrise-test/server"
+	"github.com/urfave/cli"
+)
+
+const (
+	addrKey     = "addr"
+	workDirKey  = "workdir"
+	secretKey   = "secret"
+	logDirKey   = "log-dir"
+	apiTokenKey = "api-token"
+	forkPRsKey  = "build-fork-prs"
+
+	webhookSecretEnvKey = "BITRISE_WEBHOOK_SECRET"
+	serveAPITokenEnvKey = "BITRISE_SERVE_API_TOKEN"
+)
+
+var serveCommand = cli.Command{
+	Name:  "serve",
+	Usage: "Starts a webhook listener, which runs the workflows triggered by the received git events in a local working copy.",
+	Action: func(c *cli.Context) error {
+		if err := serve(c); err != nil {
+			log.Errorf("Failed to serve, error: %s", err)
+			os.Exit(1)
+		}
+		return nil
+	},
+	Flags: []cli.Flag{
+		cli.StringFlag{Name: addrKey, Value: "127.0.0.1:8080", Usage: "Address to listen on. Listening on a non-loopback address requires a webhook secret."},
+		cli.StringFlag{Name: workDirKey, Value: ".", Usage: "Path of the git working copy, where the builds are run."},
+		cli.StringFlag{Name: ConfigKey + ", " + configShortKey, Value: "bitrise.yml", Usage: "Path of the config (bitrise.yml), relative to the working copy."},
+		cli.StringFlag{Name: secretKey, EnvVar: webhookSecretEnvKey, Usage: "Shared secret of the webhooks, used for verifying the requests' signature."},
+		cli.StringFlag{Name: logDirKey, Usage: "Directory of the build logs. If not defined a temporary directory is used."},
+		cli.StringFlag{Name: apiTokenKey, EnvVar: serveAPITokenEnvKey, Usage: "Bearer token of the builds endpoints. The builds endpoints are disabled if not defined."},
+		cli.BoolFlag{Name: forkPRsKey, Usage: "Build pull requests from forks. The fork's code runs on this machine with its envs and secrets, only use it if every contributor is trusted. Requires a webhook secret."},
+	},
+}
+
+func serve(c *cli.Context) error {
+	workDir, err := filepath.Abs(c.String(workDirKey))
+	if err != nil {
+		return err
+	}
+
+	configPath := c.String(ConfigKey)
+	if !filepath.IsAbs(configPath) {
+		configPath = filepath.Join(workDir, configPath)
+	}
+
+	logDir := c.String(logDirKey)
+	if logDir == "" {
+		logDir, err = ioutil.TempDir("", "bitrise-serve-logs")
+		if err != nil {
+			return err
+		}
+	} else if err := os.MkdirAll(logDir, 0755); err != nil {
+		return err
+	}
+
+	bitriseBinaryPath, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to get the bitrise binary path: %s", err)
+	}
+
+	addr := c.String(addrKey)
+	secret := c.String(secretKey)
+	if secret == "" {
+		isLoopback, err := isLoopbackAddress(addr)
+		if err != nil {
+			return err
+		}
+		if !isLoopback {
+			return fmt.Errorf("listening on a non-loopback address (%s) requires a webhook secret, define --%s or %s", addr, secretKey, webhookSecretEnvKey)
+		}
+		log.Warnf("No webhook secret defined, the requests are not verified and pull requests from forks are not built")
+	}
+
+	buildForkPRs := c.Bool(forkPRsKey)
+	if buildForkPRs {
+		if secret == "" {
+			return fmt.Errorf("--%s requires a webhook secret, define --%s or %s", forkPRsKey, secretKey, webhookSecretEnvKey)
+		}
+		log.Warnf("Pull requests from forks are built, their code runs on this machine with its envs and secrets")
+	}
+
+	apiToken := c.String(apiTokenKey)
+	if apiToken == "" {
+		log.Warnf("No API token defined, the builds endpoints are disabled")
+	}
+
+	srv := server.New(server.Config{
+		Secret:     secret,
+		ConfigPath: configPath,
+		LogDir:     logDir,
+		APIToken:   apiToken,
+		Runner:     server.NewTriggerRunner(bitriseBinaryPath, workDir, configPath, buildForkPRs),
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go srv.Run(ctx)
+
+	httpServer := &http.Server{
+		Addr:    addr,
+		Handler: srv.Handler(),
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		log.Infof("Shutting down...")
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer shutdownCancel()
+		if err := httpServer.Shutdown(shutdownCtx); err != nil {
+			log.Warnf("Failed to shut down the server: %s", err)
+		}
+	}()
+
+	log.Infof("Listening on %s, working copy: %s, build logs: %s", httpServer.Addr, workDir, logDir)
+	if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// isLoopbackAddress returns true if the listen address (host:port) binds only the loopback interface.
+func isLoopbackAddress(addr string) (bool, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false, fmt.Errorf("invalid address (%s): %s", addr, err)
+	}
+	if host == "localhost" {
+		return true, nil
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback(), nil
+}
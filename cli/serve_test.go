@@ -0,0 +1,27 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsLoopbackAddress(t *testing.T) {
+	for addr, expected := range map[string]bool{
+		"127.0.0.1:8080": true,
+		"localhost:8080": true,
+		"[::1]:8080":     true,
+		":8080":          false,
+		"0.0.0.0:8080":   false,
+		"[::]:8080":      false,
+		"10.0.0.1:8080":  false,
+		"example.com:80": false,
+	} {
+		isLoopback, err := isLoopbackAddress(addr)
+		require.NoError(t, err)
+		require.Equal(t, expected, isLoopback, addr)
+	}
+
+	_, err := isLoopbackAddress("8080")
+	require.Error(t, err)
+}
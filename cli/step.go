@@ -0,0 +1,12 @@
+package cli
+
+import "github.com/urfave/cli"
+
+var stepCommand = cli.Command{
+	Name:  "step",
+	Usage: "Develop and run a single step.",
+	Subcommands: []cli.Command{
+		stepDevCommand,
+		stepRunCommand,
+	},
+}
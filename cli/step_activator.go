@@ -12,15 +12,17 @@ import (
 	"github.com/bitrise-io/go-utils/pathutil"
 	"github.com/bitrise-io/go-utils/pointers"
 	stepmanModels "github.com/bitrise-io/stepman/models"
+	"github.com/tothszabi/bitrise-test/bitrise"
 	"github.com/tothszabi/bitrise-test/log"
 	"github.com/tothszabi/bitrise-test/models"
 )
 
 type stepActivator struct {
+	gitSteps func(stepIDData models.StepIDData) (models.GitStepModel, bool)
 }
 
-func newStepActivator() stepActivator {
-	return stepActivator{}
+func newStepActivator(gitSteps func(stepIDData models.StepIDData) (models.GitStepModel, bool)) stepActivator {
+	return stepActivator{gitSteps: gitSteps}
 }
 
 func (a stepActivator) activateStep(
@@ -66,15 +68,19 @@ func (a stepActivator) activateStep(
 		}
 	} else if stepIDData.SteplibSource == "git" {
 		log.Debugf("[BITRISE_CLI] - Remote step, with direct git uri: (uri:%s) (tag-or-branch:%s)", stepIDData.IDorURI, stepIDData.Version)
+		cloneURL, err := bitrise.GitStepCloneURL(stepIDData.IDorURI)
+		if err != nil {
+			return "", "", err
+		}
 		repo, err := git.New(stepDir)
 		if err != nil {
 			return "", "", err
 		}
 		var cloneCmd *command.Model
 		if stepIDData.Version == "" {
-			cloneCmd = repo.Clone(stepIDData.IDorURI, "--depth=1")
+			cloneCmd = repo.Clone(cloneURL, "--depth=1")
 		} else {
-			cloneCmd = repo.CloneTagOrBranch(stepIDData.IDorURI, stepIDData.Version, "--depth=1")
+			cloneCmd = repo.CloneTagOrBranch(cloneURL, stepIDData.Version, "--depth=1")
 		}
 		if out, err := cloneCmd.RunAndReturnTrimmedCombinedOutput(); err != nil {
 			if strings.HasPrefix(stepIDData.IDorURI, "git@") {
@@ -90,6 +96,10 @@ even if the repository is open source!`)
 			return "", "", err
 		}
 
+		if err := a.verifyGitStep(stepDir, stepIDData); err != nil {
+			return "", "", err
+		}
+
 		if err := command.CopyFile(filepath.Join(stepDir, "step.yml"), stepYMLPth); err != nil {
 			return "", "", err
 		}
@@ -102,11 +112,19 @@ even if the repository is open source!`)
 			return "", "", err
 		}
 
+		cloneURL, err := bitrise.GitStepCloneURL(stepIDData.IDorURI)
+		if err != nil {
+			return "", "", err
+		}
 		repo, err := git.New(stepDir)
 		if err != nil {
 			return "", "", err
 		}
-		if err := repo.CloneTagOrBranch(stepIDData.IDorURI, stepIDData.Version).Run(); err != nil {
+		if err := repo.CloneTagOrBranch(cloneURL, stepIDData.Version).Run(); err != nil {
+			return "", "", err
+		}
+
+		if err := a.verifyGitStep(stepDir, stepIDData); err != nil {
 			return "", "", err
 		}
 	} else if stepIDData.SteplibSource != "" {
@@ -134,3 +152,15 @@ even if the repository is open source!`)
 
 	return stepYMLPth, origStepYMLPth, nil
 }
+
+// verifyGitStep verifies the cloned git step, if the config defines its verification.
+func (a stepActivator) verifyGitStep(stepDir string, stepIDData models.StepIDData) error {
+	if a.gitSteps == nil {
+		return nil
+	}
+	gitStep, found := a.gitSteps(stepIDData)
+	if !found {
+		return nil
+	}
+	return verifyGitStep(stepDir, stepIDData, gitStep)
+}
@@ -0,0 +1,471 @@
+package cli
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	envmanModels "github.com/bitrise-io/envman/models"
+	"github.com/bitrise-io/go-utils/command"
+	"github.com/bitrise-io/go-utils/pathutil"
+	coreanalytics "github.com/bitrise-io/go-utils/v2/analytics"
+	"github.com/gofrs/uuid"
+	"github.com/tothszabi/bitrise-test/analytics"
+	"github.com/tothszabi/bitrise-test/bitrise"
+	"github.com/tothszabi/bitrise-test/log"
+	"github.com/tothszabi/bitrise-test/models"
+	"github.com/tothszabi/bitrise-test/toolkits"
+	"github.com/tothszabi/bitrise-test/version"
+	"github.com/urfave/cli"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	stepDevEnvsSnapshotKey = "envs-snapshot"
+
+	stepDevPollInterval = 500 * time.Millisecond
+)
+
+var stepDevCommand = cli.Command{
+	Name:      "dev",
+	Usage:     "Runs a local (path::) step of the workflow, then watches the step's directory and re-runs the step on every change.",
+	ArgsUsage: "STEP_DIR",
+	Action: func(c *cli.Context) error {
+		if err := stepDev(c); err != nil {
+			log.Errorf("Failed to develop the step, error: %s", err)
+			os.Exit(1)
+		}
+		return nil
+	},
+	Flags: []cli.Flag{
+		cli.StringFlag{Name: WorkflowKey, Usage: "Workflow which uses the step."},
+		flConfig,
+		flInventory,
+		cli.StringFlag{
+			Name:  stepDevEnvsSnapshotKey,
+			Usage: "Path of the env snapshot of the steps preceding the step. The snapshot is created on the first run and reused by the next runs, remove it to run the preceding steps again.",
+		},
+	},
+}
+
+// stepDevTarget is the developed step's position in the workflow run plan.
+type stepDevTarget struct {
+	workflowIdx     int
+	workflowID      string
+	stepIdx         int
+	compositeStepID string
+	stepIDData      models.StepIDData
+}
+
+// String identifies the step in the workflow run plan, like primary.steps[2]: path::./my-step.
+func (target stepDevTarget) String() string {
+	return fmt.Sprintf("%s.steps[%d]: %s", target.workflowID, target.stepIdx, target.compositeStepID)
+}
+
+func stepDev(c *cli.Context) error {
+	if len(c.Args()) != 1 {
+		return errors.New("step directory not specified, usage: bitrise step dev STEP_DIR --workflow WORKFLOW")
+	}
+	if c.String(WorkflowKey) == "" {
+		return workflowNotSpecifiedErr
+	}
+
+	stepDir, err := pathutil.AbsPath(c.Args()[0])
+	if err != nil {
+		return err
+	}
+
+	runConfig, err := processArgs(c)
+	if err != nil {
+		return err
+	}
+	if _, exist := runConfig.Config.Workflows[runConfig.Workflow]; !exist {
+		return fmt.Errorf("specified Workflow (%s) does not exist", runConfig.Workflow)
+	}
+
+	target, err := findStepDevTarget(runConfig.Config, runConfig.Workflow, stepDir)
+	if err != nil {
+		return err
+	}
+
+	if err := bitrise.RunSetupIfNeeded(version.VERSION, false); err != nil {
+		return fmt.Errorf("setup failed: %s", err)
+	}
+
+	tracker := analytics.NewDefaultTracker()
+	defer func() {
+		tracker.Wait()
+	}()
+
+	runner := NewWorkflowRunner(*runConfig)
+	environments, err := runner.setupRun()
+	if err != nil {
+		return err
+	}
+
+	environments, err = runner.stepDevEnvironments(target, environments, c.String(stepDevEnvsSnapshotKey), tracker)
+	if err != nil {
+		return err
+	}
+
+	state, err := stepDirState(stepDir)
+	if err != nil {
+		return err
+	}
+	for {
+		if err := checkStepDevBuild(stepDir, target.stepIDData); err != nil {
+			log.Errorf("%s", err)
+		} else {
+			runner.runStepDevTarget(target, environments, tracker)
+		}
+
+		log.Print()
+		log.Infof("Watching %s for changes, press Ctrl+C to stop...", stepDir)
+
+		var changes []string
+		state, changes, err = waitForStepDirChange(stepDir, state, stepDevPollInterval)
+		if err != nil {
+			return err
+		}
+		log.Printf("Changed: %s", strings.Join(changes, ", "))
+	}
+}
+
+// findStepDevTarget returns the first local (path::) step of the workflow run plan, which refers to the step directory.
+func findStepDevTarget(config models.BitriseDataModel, workflowID, stepDir string) (stepDevTarget, error) {
+	for workflowIdx, id := range walkWorkflows(workflowID, config.Workflows, nil) {
+		for stepIdx, stepListItem := range config.Workflows[id].Steps {
+			compositeStepID, _, err := models.GetStepIDStepDataPair(stepListItem)
+			if err != nil {
+				return stepDevTarget{}, err
+			}
+			stepIDData, err := models.CreateStepIDDataFromString(compositeStepID, config.DefaultStepLibSource)
+			if err != nil {
+				return stepDevTarget{}, err
+			}
+			if stepIDData.SteplibSource != "path" {
+				continue
+			}
+
+			stepAbsLocalPth, err := pathutil.AbsPath(stepIDData.IDorURI)
+			if err != nil {
+				return stepDevTarget{}, err
+			}
+			if filepath.Clean(stepAbsLocalPth) == filepath.Clean(stepDir) {
+				return stepDevTarget{
+					workflowIdx:     workflowIdx,
+					workflowID:      id,
+					stepIdx:         stepIdx,
+					compositeStepID: compositeStepID,
+					stepIDData:      stepIDData,
+				}, nil
+			}
+		}
+	}
+	return stepDevTarget{}, fmt.Errorf("workflow (%s) has no local step (path::) referring to %s", workflowID, stepDir)
+}
+
+// stepDevEnvironments returns the environments of the developed step: the env snapshot if it exists,
+// otherwise the steps preceding the developed step are run to collect their outputs.
+// The snapshot does not contain the secrets and the sensitive envs (like the sensitive step outputs),
+// it is only reused if the sensitive envs are defined as secrets and the config's envs did not change.
+func (r WorkflowRunner) stepDevEnvironments(target stepDevTarget, environments []envmanModels.EnvironmentItemModel, snapshotPth string, tracker analytics.Tracker) ([]envmanModels.EnvironmentItemModel, error) {
+	secrets := r.config.Secrets
+
+	configEnvsHash, err := stepDevConfigEnvsHash(r.config.Config, r.config.Workflow, target)
+	if err != nil {
+		return nil, err
+	}
+
+	if snapshotPth != "" {
+		snapshot, found, err := readStepDevEnvsSnapshot(snapshotPth)
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			if reason := snapshot.outdatedReason(r.config.Workflow, target.String(), configEnvsHash, secrets); reason != "" {
+				log.Warnf("The env snapshot (%s) %s, it will be overwritten", snapshotPth, reason)
+			} else {
+				log.Infof("Using the env snapshot: %s", snapshotPth)
+				return append(append([]envmanModels.EnvironmentItemModel{}, secrets...), snapshot.Envs...), nil
+			}
+		}
+	}
+
+	log.Infof("Running the steps preceding %s", target.compositeStepID)
+
+	plan := createWorkflowRunPlan(r.config.Modes, r.config.Workflow, r.config.Config.Workflows, func() string { return uuid.Must(uuid.NewV4()).String() })
+	buildRunResults := models.BuildRunResultsModel{
+		WorkflowID:     r.config.Workflow,
+		StartTime:      time.Now(),
+		StepmanUpdates: map[string]int{},
+		ProjectType:    r.config.Config.ProjectType,
+	}
+	buildIDProperties := coreanalytics.Properties{analytics.BuildExecutionID: uuid.Must(uuid.NewV4()).String()}
+
+	for i := 0; i <= target.workflowIdx; i++ {
+		workflowRunPlan := plan.ExecutionPlan[i]
+		workflow := r.config.Config.Workflows[workflowRunPlan.WorkflowID]
+		if workflow.Title == "" {
+			workflow.Title = workflowRunPlan.WorkflowID
+		}
+
+		if i == target.workflowIdx {
+			workflow.Steps = workflow.Steps[:target.stepIdx]
+			workflowRunPlan.Steps = workflowRunPlan.Steps[:target.stepIdx]
+			if len(workflow.Steps) == 0 {
+				environments = append(environments, workflow.Environments...)
+				break
+			}
+		}
+
+		buildRunResults = r.runWorkflow(workflowRunPlan, workflowRunPlan.WorkflowID, workflow, r.config.Config.DefaultStepLibSource, buildRunResults, &environments, secrets, false, tracker, buildIDProperties)
+	}
+
+	if buildRunResults.IsBuildFailed() {
+		log.Warnf("The steps preceding %s failed, the step runs with the envs of the failed build", target.compositeStepID)
+		return environments, nil
+	}
+
+	if snapshotPth != "" {
+		snapshot := newStepDevEnvsSnapshot(r.config.Workflow, target.String(), configEnvsHash, environments[len(secrets):])
+		if err := writeStepDevEnvsSnapshot(snapshotPth, snapshot); err != nil {
+			return nil, err
+		}
+		log.Infof("Env snapshot saved: %s", snapshotPth)
+	}
+
+	return environments, nil
+}
+
+// stepDevEnvsSnapshot is the content of the env snapshot file, the envs are stored in the envstore format.
+// The sensitive envs are not stored, only their keys.
+type stepDevEnvsSnapshot struct {
+	Workflow       string                              `yaml:"workflow"`
+	Step           string                              `yaml:"step"`
+	ConfigEnvsHash string                              `yaml:"config_envs_hash"`
+	SensitiveEnvs  []string                            `yaml:"sensitive_envs,omitempty"`
+	Envs           []envmanModels.EnvironmentItemModel `yaml:"envs"`
+}
+
+func newStepDevEnvsSnapshot(workflow, step, configEnvsHash string, environments []envmanModels.EnvironmentItemModel) stepDevEnvsSnapshot {
+	snapshot := stepDevEnvsSnapshot{Workflow: workflow, Step: step, ConfigEnvsHash: configEnvsHash}
+	for _, env := range environments {
+		if opts, err := env.GetOptions(); err == nil && opts.IsSensitive != nil && *opts.IsSensitive {
+			if key, _, err := env.GetKeyValuePair(); err == nil {
+				snapshot.SensitiveEnvs = append(snapshot.SensitiveEnvs, key)
+			}
+			continue
+		}
+		snapshot.Envs = append(snapshot.Envs, env)
+	}
+	return snapshot
+}
+
+// outdatedReason returns why the snapshot can not be used for the step, or an empty string if it can be.
+func (s stepDevEnvsSnapshot) outdatedReason(workflow, step, configEnvsHash string, secrets []envmanModels.EnvironmentItemModel) string {
+	if s.Workflow != workflow || s.Step != step {
+		return fmt.Sprintf("was created for another step (%s: %s)", s.Workflow, s.Step)
+	}
+	if s.ConfigEnvsHash != configEnvsHash {
+		return "was created with different config envs"
+	}
+
+	secretKeys := map[string]bool{}
+	for _, secret := range secrets {
+		if key, _, err := secret.GetKeyValuePair(); err == nil {
+			secretKeys[key] = true
+		}
+	}
+	var missing []string
+	for _, key := range s.SensitiveEnvs {
+		if !secretKeys[key] {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Sprintf("does not contain the sensitive envs (%s), define them as secrets to reuse the snapshot", strings.Join(missing, ", "))
+	}
+	return ""
+}
+
+// stepDevConfigEnvsHash returns the SHA-256 hash of the app envs and the envs of the workflows
+// which run before or contain the developed step.
+func stepDevConfigEnvsHash(config models.BitriseDataModel, workflowID string, target stepDevTarget) (string, error) {
+	envs := [][]envmanModels.EnvironmentItemModel{config.App.Environments}
+	for _, id := range walkWorkflows(workflowID, config.Workflows, nil)[:target.workflowIdx+1] {
+		envs = append(envs, config.Workflows[id].Environments)
+	}
+
+	content, err := yaml.Marshal(envs)
+	if err != nil {
+		return "", fmt.Errorf("failed to serialize config envs: %s", err)
+	}
+	hash := sha256.Sum256(content)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+func readStepDevEnvsSnapshot(pth string) (stepDevEnvsSnapshot, bool, error) {
+	content, err := ioutil.ReadFile(pth)
+	if os.IsNotExist(err) {
+		return stepDevEnvsSnapshot{}, false, nil
+	} else if err != nil {
+		return stepDevEnvsSnapshot{}, false, err
+	}
+
+	var snapshot stepDevEnvsSnapshot
+	if err := yaml.Unmarshal(content, &snapshot); err != nil {
+		return stepDevEnvsSnapshot{}, false, fmt.Errorf("failed to parse env snapshot (%s): %s", pth, err)
+	}
+	if snapshot.Envs, err = bitrise.CollectEnvironmentsFromFileContent(content); err != nil {
+		return stepDevEnvsSnapshot{}, false, fmt.Errorf("invalid env snapshot (%s): %s", pth, err)
+	}
+	return snapshot, true, nil
+}
+
+func writeStepDevEnvsSnapshot(pth string, snapshot stepDevEnvsSnapshot) error {
+	content, err := yaml.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("failed to serialize env snapshot: %s", err)
+	}
+	if err := ioutil.WriteFile(pth, content, 0600); err != nil {
+		return fmt.Errorf("failed to write env snapshot (%s): %s", pth, err)
+	}
+	return nil
+}
+
+// runStepDevTarget runs only the developed step, with the environments of the preceding steps.
+func (r WorkflowRunner) runStepDevTarget(target stepDevTarget, environments []envmanModels.EnvironmentItemModel, tracker analytics.Tracker) models.BuildRunResultsModel {
+	workflow := r.config.Config.Workflows[target.workflowID]
+	if workflow.Title == "" {
+		workflow.Title = target.workflowID
+	}
+	workflow.Steps = []models.StepListItemModel{workflow.Steps[target.stepIdx]}
+
+	plan := models.WorkflowExecutionPlan{
+		UUID:       uuid.Must(uuid.NewV4()).String(),
+		WorkflowID: target.workflowID,
+		Steps:      []models.StepExecutionPlan{{UUID: uuid.Must(uuid.NewV4()).String(), StepID: target.compositeStepID}},
+	}
+	buildRunResults := models.BuildRunResultsModel{
+		WorkflowID:     r.config.Workflow,
+		StartTime:      time.Now(),
+		StepmanUpdates: map[string]int{},
+		ProjectType:    r.config.Config.ProjectType,
+	}
+	workflowIDProperties := coreanalytics.Properties{analytics.WorkflowExecutionID: plan.UUID}
+
+	// every run starts from the same environments
+	stepEnvironments := append([]envmanModels.EnvironmentItemModel{}, environments...)
+	buildRunResults = r.activateAndRunSteps(plan, workflow, r.config.Config.DefaultStepLibSource, buildRunResults, &stepEnvironments, r.config.Secrets, true, tracker, workflowIDProperties)
+	bitrise.PrintSummary(buildRunResults)
+	return buildRunResults
+}
+
+// checkStepDevBuild validates the step.yml and compiles the Go steps (in a copy of the step directory),
+// so the step definition and compile errors are shown without running the step.
+func checkStepDevBuild(stepDir string, stepIDData models.StepIDData) error {
+	specStep, err := bitrise.ReadSpecStep(filepath.Join(stepDir, "step.yml"))
+	if err != nil {
+		return fmt.Errorf("invalid step definition: %s", err)
+	}
+	if specStep.Toolkit == nil || specStep.Toolkit.Go == nil {
+		return nil
+	}
+
+	tmpDir, err := ioutil.TempDir("", "bitrise-step-dev")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := os.RemoveAll(tmpDir); err != nil {
+			log.Warnf("Failed to remove temporary directory (%s): %s", tmpDir, err)
+		}
+	}()
+
+	srcDir := filepath.Join(tmpDir, "src")
+	if err := command.CopyDir(stepDir, srcDir, true); err != nil {
+		return err
+	}
+	if err := toolkits.BuildGoStep(specStep, srcDir, filepath.Join(tmpDir, "step")); err != nil {
+		return fmt.Errorf("compile error:\n%s", err)
+	}
+	return nil
+}
+
+// stepDirState returns the size and modification time of every file in the step directory, keyed by the relative file path.
+// The .git directory is skipped.
+func stepDirState(dir string) (map[string]string, error) {
+	state := map[string]string{}
+	err := filepath.Walk(dir, func(pth string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if info.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, pth)
+		if err != nil {
+			return err
+		}
+		state[rel] = fmt.Sprintf("%d:%d", info.Size(), info.ModTime().UnixNano())
+		return nil
+	})
+	return state, err
+}
+
+// stepDirChanges returns the added, removed and modified files in sorted order.
+func stepDirChanges(previous, current map[string]string) []string {
+	var changes []string
+	for pth, fileState := range current {
+		if previousFileState, found := previous[pth]; !found || previousFileState != fileState {
+			changes = append(changes, pth)
+		}
+	}
+	for pth := range previous {
+		if _, found := current[pth]; !found {
+			changes = append(changes, pth)
+		}
+	}
+	sort.Strings(changes)
+	return changes
+}
+
+// waitForStepDirChange polls the step directory until it changes,
+// it returns once the directory did not change for a poll interval, so a change touching multiple files triggers a single run.
+func waitForStepDirChange(dir string, previous map[string]string, interval time.Duration) (map[string]string, []string, error) {
+	for {
+		time.Sleep(interval)
+		current, err := stepDirState(dir)
+		if err != nil {
+			return nil, nil, err
+		}
+		changes := stepDirChanges(previous, current)
+		if len(changes) == 0 {
+			continue
+		}
+
+		for {
+			time.Sleep(interval)
+			settled, err := stepDirState(dir)
+			if err != nil {
+				return nil, nil, err
+			}
+			if len(stepDirChanges(current, settled)) == 0 {
+				return settled, stepDirChanges(previous, settled), nil
+			}
+			current = settled
+		}
+	}
+}
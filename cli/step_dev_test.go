@@ -0,0 +1,234 @@
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	envmanModels "github.com/bitrise-io/envman/models"
+	"github.com/bitrise-io/go-utils/pointers"
+	"github.com/stretchr/testify/require"
+	"github.com/tothszabi/bitrise-test/bitrise"
+	"github.com/tothszabi/bitrise-test/models"
+)
+
+func TestFindStepDevTarget(t *testing.T) {
+	config := parseStepDevTestConfig(t, `format_version: "11"
+default_step_lib_source: https://github.com/bitrise-io/bitrise-steplib.git
+workflows:
+  _setup:
+    steps:
+    - script@1: {}
+    - path::./steps/setup: {}
+  test:
+    before_run:
+    - _setup
+    steps:
+    - git-clone@6: {}
+    - path::./steps/my-step: {}
+    - path::./steps/my-step:
+        title: Again
+`)
+
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+
+	t.Log("step of the workflow")
+	{
+		target, err := findStepDevTarget(config, "test", filepath.Join(cwd, "steps", "my-step"))
+		require.NoError(t, err)
+		require.Equal(t, 1, target.workflowIdx)
+		require.Equal(t, "test", target.workflowID)
+		require.Equal(t, 1, target.stepIdx)
+		require.Equal(t, "path::./steps/my-step", target.compositeStepID)
+		require.Equal(t, "test.steps[1]: path::./steps/my-step", target.String())
+	}
+
+	t.Log("step of a before run workflow")
+	{
+		target, err := findStepDevTarget(config, "test", filepath.Join(cwd, "steps", "setup")+"/")
+		require.NoError(t, err)
+		require.Equal(t, 0, target.workflowIdx)
+		require.Equal(t, "_setup", target.workflowID)
+		require.Equal(t, 1, target.stepIdx)
+	}
+
+	t.Log("step not used by the workflow")
+	{
+		_, err := findStepDevTarget(config, "test", filepath.Join(cwd, "steps", "other"))
+		require.EqualError(t, err, "workflow (test) has no local step (path::) referring to "+filepath.Join(cwd, "steps", "other"))
+	}
+}
+
+func TestStepDevEnvsSnapshot(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "step-dev")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+	pth := filepath.Join(tmpDir, "envs.yml")
+
+	t.Log("missing snapshot")
+	{
+		_, found, err := readStepDevEnvsSnapshot(pth)
+		require.NoError(t, err)
+		require.False(t, found)
+	}
+
+	t.Log("written snapshot")
+	{
+		require.NoError(t, writeStepDevEnvsSnapshot(pth, stepDevEnvsSnapshot{
+			Workflow:       "test",
+			Step:           "test.steps[1]: path::./steps/my-step",
+			ConfigEnvsHash: "hash",
+			SensitiveEnvs:  []string{"PREP_TOKEN"},
+			Envs:           []envmanModels.EnvironmentItemModel{{"PREP_OUT": "from-prep"}},
+		}))
+
+		info, err := os.Stat(pth)
+		require.NoError(t, err)
+		require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+		snapshot, found, err := readStepDevEnvsSnapshot(pth)
+		require.NoError(t, err)
+		require.True(t, found)
+		require.Equal(t, "test", snapshot.Workflow)
+		require.Equal(t, "test.steps[1]: path::./steps/my-step", snapshot.Step)
+		require.Equal(t, "hash", snapshot.ConfigEnvsHash)
+		require.Equal(t, []string{"PREP_TOKEN"}, snapshot.SensitiveEnvs)
+		require.Equal(t, 1, len(snapshot.Envs))
+
+		key, value, err := snapshot.Envs[0].GetKeyValuePair()
+		require.NoError(t, err)
+		require.Equal(t, "PREP_OUT", key)
+		require.Equal(t, "from-prep", value)
+	}
+}
+
+func TestNewStepDevEnvsSnapshot(t *testing.T) {
+	snapshot := newStepDevEnvsSnapshot("test", "test.steps[1]: path::./steps/my-step", "hash", []envmanModels.EnvironmentItemModel{
+		{"PREP_OUT": "from-prep"},
+		{"PREP_TOKEN": "secret", envmanModels.OptionsKey: envmanModels.EnvironmentItemOptionsModel{IsSensitive: pointers.NewBoolPtr(true)}},
+	})
+	require.Equal(t, []envmanModels.EnvironmentItemModel{{"PREP_OUT": "from-prep"}}, snapshot.Envs)
+	require.Equal(t, []string{"PREP_TOKEN"}, snapshot.SensitiveEnvs)
+
+	t.Log("up to date snapshot")
+	{
+		reason := snapshot.outdatedReason("test", "test.steps[1]: path::./steps/my-step", "hash", []envmanModels.EnvironmentItemModel{{"PREP_TOKEN": "secret"}})
+		require.Equal(t, "", reason)
+	}
+
+	t.Log("snapshot of another step")
+	{
+		reason := snapshot.outdatedReason("test", "test.steps[2]: path::./steps/my-step", "hash", []envmanModels.EnvironmentItemModel{{"PREP_TOKEN": "secret"}})
+		require.Equal(t, "was created for another step (test: test.steps[1]: path::./steps/my-step)", reason)
+	}
+
+	t.Log("changed config envs")
+	{
+		reason := snapshot.outdatedReason("test", "test.steps[1]: path::./steps/my-step", "other", []envmanModels.EnvironmentItemModel{{"PREP_TOKEN": "secret"}})
+		require.Equal(t, "was created with different config envs", reason)
+	}
+
+	t.Log("missing sensitive env")
+	{
+		reason := snapshot.outdatedReason("test", "test.steps[1]: path::./steps/my-step", "hash", nil)
+		require.Equal(t, "does not contain the sensitive envs (PREP_TOKEN), define them as secrets to reuse the snapshot", reason)
+	}
+}
+
+func TestStepDevConfigEnvsHash(t *testing.T) {
+	configContent := `format_version: "11"
+default_step_lib_source: https://github.com/bitrise-io/bitrise-steplib.git
+app:
+  envs:
+  - APP_ENV: app
+workflows:
+  _setup:
+    envs:
+    - SETUP_ENV: setup
+    steps:
+    - path::./steps/setup: {}
+  test:
+    before_run:
+    - _setup
+    envs:
+    - TEST_ENV: test
+    steps:
+    - path::./steps/my-step: {}
+`
+	config := parseStepDevTestConfig(t, configContent)
+	target := stepDevTarget{workflowIdx: 1, workflowID: "test"}
+	hash, err := stepDevConfigEnvsHash(config, "test", target)
+	require.NoError(t, err)
+
+	t.Log("unchanged envs")
+	{
+		otherHash, err := stepDevConfigEnvsHash(parseStepDevTestConfig(t, configContent), "test", target)
+		require.NoError(t, err)
+		require.Equal(t, hash, otherHash)
+	}
+
+	t.Log("changed workflow env")
+	{
+		changed := parseStepDevTestConfig(t, strings.Replace(configContent, "TEST_ENV: test", "TEST_ENV: changed", 1))
+		otherHash, err := stepDevConfigEnvsHash(changed, "test", target)
+		require.NoError(t, err)
+		require.NotEqual(t, hash, otherHash)
+	}
+
+	t.Log("changed app env")
+	{
+		changed := parseStepDevTestConfig(t, strings.Replace(configContent, "APP_ENV: app", "APP_ENV: changed", 1))
+		otherHash, err := stepDevConfigEnvsHash(changed, "test", target)
+		require.NoError(t, err)
+		require.NotEqual(t, hash, otherHash)
+	}
+}
+
+func TestStepDirChanges(t *testing.T) {
+	stepDir, err := ioutil.TempDir("", "step-dev")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(stepDir))
+	}()
+
+	require.NoError(t, os.MkdirAll(filepath.Join(stepDir, ".git"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(stepDir, ".git", "HEAD"), []byte("ref: refs/heads/main"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(stepDir, "step.yml"), []byte("title: Test"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(stepDir, "step.sh"), []byte("echo 1"), 0644))
+
+	previous, err := stepDirState(stepDir)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(previous))
+
+	t.Log("no changes")
+	{
+		current, err := stepDirState(stepDir)
+		require.NoError(t, err)
+		require.Equal(t, 0, len(stepDirChanges(previous, current)))
+	}
+
+	t.Log("added, modified and removed files, the .git directory is skipped")
+	{
+		require.NoError(t, os.MkdirAll(filepath.Join(stepDir, "lib"), 0755))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(stepDir, "lib", "util.sh"), []byte("echo util"), 0644))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(stepDir, "step.sh"), []byte("echo 2 changed"), 0644))
+		require.NoError(t, os.Remove(filepath.Join(stepDir, "step.yml")))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(stepDir, ".git", "HEAD"), []byte("ref: refs/heads/other"), 0644))
+
+		current, err := stepDirState(stepDir)
+		require.NoError(t, err)
+		require.Equal(t, []string{filepath.Join("lib", "util.sh"), "step.sh", "step.yml"}, stepDirChanges(previous, current))
+	}
+}
+
+func parseStepDevTestConfig(t *testing.T, content string) models.BitriseDataModel {
+	config, warnings, err := bitrise.ConfigModelFromYAMLBytes([]byte(content))
+	require.NoError(t, err)
+	require.Equal(t, 0, len(warnings))
+	return config
+}
@@ -0,0 +1,140 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/bitrise-io/go-utils/pathutil"
+	stepmanModels "github.com/bitrise-io/stepman/models"
+	"github.com/bitrise-io/stepman/stepman"
+	"github.com/tothszabi/bitrise-test/bitrise"
+	"github.com/tothszabi/bitrise-test/models"
+	"github.com/tothszabi/bitrise-test/tools"
+)
+
+// stepSpecProvider returns the step definition (step.yml) of a step,
+// the second return value is false if the definition is not available without activating the step.
+type stepSpecProvider func(stepIDData models.StepIDData) (stepmanModels.StepModel, bool, error)
+
+func defaultStepSpecProvider(stepIDData models.StepIDData) (stepmanModels.StepModel, bool, error) {
+	switch stepIDData.SteplibSource {
+	case "path":
+		stepAbsLocalPth, err := pathutil.AbsPath(stepIDData.IDorURI)
+		if err != nil {
+			return stepmanModels.StepModel{}, false, err
+		}
+
+		specStep, err := bitrise.ReadSpecStep(filepath.Join(stepAbsLocalPth, "step.yml"))
+		if err != nil {
+			return stepmanModels.StepModel{}, false, err
+		}
+		return specStep, true, nil
+	case "git", "_":
+		// direct git steps are validated after cloning, steplib independent steps are completely defined in the workflow
+		return stepmanModels.StepModel{}, false, nil
+	default:
+		// the StepLib is set up only if it is not set up yet, to avoid network round trips before the run
+		if _, found := stepman.ReadRoute(stepIDData.SteplibSource); !found {
+			if err := tools.StepmanSetup(stepIDData.SteplibSource); err != nil {
+				return stepmanModels.StepModel{}, false, err
+			}
+		}
+
+		info, err := tools.StepmanStepInfo(stepIDData.SteplibSource, stepIDData.IDorURI, stepIDData.Version)
+		if err != nil {
+			return stepmanModels.StepModel{}, false, err
+		}
+		return info.Step, true, nil
+	}
+}
+
+type stepSpec struct {
+	step  stepmanModels.StepModel
+	found bool
+	err   error
+}
+
+// cachedStepSpecProvider returns a stepSpecProvider, which gets the definition of each step version only once.
+func cachedStepSpecProvider(specProvider stepSpecProvider) stepSpecProvider {
+	specs := map[models.StepIDData]stepSpec{}
+	return func(stepIDData models.StepIDData) (stepmanModels.StepModel, bool, error) {
+		spec, cached := specs[stepIDData]
+		if !cached {
+			spec.step, spec.found, spec.err = specProvider(stepIDData)
+			specs[stepIDData] = spec
+		}
+		return spec.step, spec.found, spec.err
+	}
+}
+
+// validateWorkflowsStepInputs checks the step inputs of the given workflows against the step definitions.
+// Steps without an available definition are skipped, these are reported as warnings.
+// The definition of a step version is queried only once, even if it is used by multiple steps.
+func validateWorkflowsStepInputs(config models.BitriseDataModel, workflowIDs []string, specProvider stepSpecProvider) ([]string, error) {
+	var warnings []string
+	var errs models.StepInputErrors
+	specProvider = cachedStepSpecProvider(specProvider)
+
+	for _, workflowID := range workflowIDs {
+		workflow, found := config.Workflows[workflowID]
+		if !found {
+			return warnings, fmt.Errorf("workflow (%s) does not exist", workflowID)
+		}
+
+		for idx, stepListItem := range workflow.Steps {
+			compositeStepIDStr, workflowStep, err := models.GetStepIDStepDataPair(stepListItem)
+			if err != nil {
+				return warnings, err
+			}
+
+			stepIDData, err := models.CreateStepIDDataFromString(compositeStepIDStr, config.DefaultStepLibSource)
+			if err != nil {
+				return warnings, err
+			}
+
+			specStep, found, err := specProvider(stepIDData)
+			if err != nil {
+				warnings = append(warnings, fmt.Sprintf("workflows.%s.steps[%d] (%s): inputs not validated, failed to get the step definition: %s", workflowID, idx, compositeStepIDStr, err))
+				continue
+			}
+			if !found {
+				continue
+			}
+
+			inputWarnings, err := models.ValidateStepInputs(workflowID, idx, compositeStepIDStr, specStep, workflowStep)
+			warnings = append(warnings, inputWarnings...)
+			if inputErrs, ok := err.(models.StepInputErrors); ok {
+				errs = append(errs, inputErrs...)
+			} else if err != nil {
+				return warnings, err
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return warnings, errs
+	}
+	return warnings, nil
+}
+
+func allWorkflowIDs(config models.BitriseDataModel) []string {
+	var workflowIDs []string
+	for workflowID := range config.Workflows {
+		workflowIDs = append(workflowIDs, workflowID)
+	}
+	sort.Strings(workflowIDs)
+	return workflowIDs
+}
+
+func uniqueWorkflowIDs(workflowIDs []string) []string {
+	var unique []string
+	seen := map[string]bool{}
+	for _, workflowID := range workflowIDs {
+		if !seen[workflowID] {
+			seen[workflowID] = true
+			unique = append(unique, workflowID)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,110 @@
+package cli
+
+import (
+	"errors"
+	"testing"
+
+	stepmanModels "github.com/bitrise-io/stepman/models"
+	"github.com/stretchr/testify/require"
+	"github.com/tothszabi/bitrise-test/bitrise"
+	"github.com/tothszabi/bitrise-test/models"
+	"gopkg.in/yaml.v2"
+)
+
+func TestValidateWorkflowsStepInputs(t *testing.T) {
+	configStr := `
+format_version: 1.3.0
+default_step_lib_source: "https://github.com/bitrise-io/bitrise-steplib.git"
+
+workflows:
+  primary:
+    steps:
+    - script:
+        inputs:
+        - retry_count: three
+    - path::./my-step:
+        inputs:
+        - content: echo hello
+  deploy:
+    steps:
+    - script:
+        inputs:
+        - retry_count: 3
+`
+	config, warnings, err := bitrise.ConfigModelFromYAMLBytes([]byte(configStr))
+	require.NoError(t, err)
+	require.Equal(t, 0, len(warnings))
+
+	specStepYML := `
+inputs:
+- retry_count: 1
+  opts:
+    meta:
+      type: int
+`
+	var specStep stepmanModels.StepModel
+	require.NoError(t, yaml.Unmarshal([]byte(specStepYML), &specStep))
+
+	provider := func(stepIDData models.StepIDData) (stepmanModels.StepModel, bool, error) {
+		if stepIDData.SteplibSource == "path" {
+			return stepmanModels.StepModel{}, false, errors.New("step.yml not found")
+		}
+		return specStep, true, nil
+	}
+
+	t.Log("invalid step input")
+	{
+		warnings, err := validateWorkflowsStepInputs(config, []string{"primary"}, provider)
+		require.EqualError(t, err, "invalid step inputs:\n- workflows.primary.steps[0] (script) inputs.retry_count: value (three) is not an int")
+		require.Equal(t, []string{"workflows.primary.steps[1] (path::./my-step): inputs not validated, failed to get the step definition: step.yml not found"}, warnings)
+	}
+
+	t.Log("valid step input")
+	{
+		warnings, err := validateWorkflowsStepInputs(config, []string{"deploy"}, provider)
+		require.NoError(t, err)
+		require.Equal(t, 0, len(warnings))
+	}
+
+	t.Log("non existing workflow")
+	{
+		_, err := validateWorkflowsStepInputs(config, []string{"release"}, provider)
+		require.EqualError(t, err, "workflow (release) does not exist")
+	}
+
+	t.Log("all workflow ids")
+	{
+		require.Equal(t, []string{"deploy", "primary"}, allWorkflowIDs(config))
+		require.Equal(t, []string{"primary", "deploy"}, uniqueWorkflowIDs([]string{"primary", "deploy", "primary"}))
+	}
+}
+
+func TestCachedStepSpecProvider(t *testing.T) {
+	calls := map[models.StepIDData]int{}
+	provider := cachedStepSpecProvider(func(stepIDData models.StepIDData) (stepmanModels.StepModel, bool, error) {
+		calls[stepIDData]++
+		if stepIDData.IDorURI == "missing" {
+			return stepmanModels.StepModel{}, false, errors.New("step not found")
+		}
+		return stepmanModels.StepModel{}, true, nil
+	})
+
+	script := models.StepIDData{SteplibSource: "https://github.com/bitrise-io/bitrise-steplib.git", IDorURI: "script", Version: "1"}
+	scriptLatest := models.StepIDData{SteplibSource: "https://github.com/bitrise-io/bitrise-steplib.git", IDorURI: "script"}
+	missing := models.StepIDData{SteplibSource: "https://github.com/bitrise-io/bitrise-steplib.git", IDorURI: "missing"}
+
+	for i := 0; i < 3; i++ {
+		_, found, err := provider(script)
+		require.NoError(t, err)
+		require.True(t, found)
+
+		_, found, err = provider(scriptLatest)
+		require.NoError(t, err)
+		require.True(t, found)
+
+		_, _, err = provider(missing)
+		require.EqualError(t, err, "step not found")
+	}
+
+	require.Equal(t, map[models.StepIDData]int{script: 1, scriptLatest: 1, missing: 1}, calls)
+}
@@ -0,0 +1,258 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	envmanModels "github.com/bitrise-io/envman/models"
+	coreanalytics "github.com/bitrise-io/go-utils/v2/analytics"
+	stepmanModels "github.com/bitrise-io/stepman/models"
+	"github.com/gofrs/uuid"
+	"github.com/tothszabi/bitrise-test/analytics"
+	"github.com/tothszabi/bitrise-test/bitrise"
+	"github.com/tothszabi/bitrise-test/configs"
+	"github.com/tothszabi/bitrise-test/log"
+	"github.com/tothszabi/bitrise-test/models"
+	"github.com/tothszabi/bitrise-test/version"
+	"github.com/urfave/cli"
+)
+
+const (
+	stepRunInputKey   = "input"
+	stepRunEnvFileKey = "env-file"
+
+	// stepRunWorkflowID is the ID of the workflow, which runs the step.
+	stepRunWorkflowID = "step_run"
+
+	defaultStepRunStepLibSource = "https://github.com/bitrise-io/bitrise-steplib.git"
+)
+
+var stepRunCommand = cli.Command{
+	Name:      "run",
+	Usage:     "Activates a StepLib, git or local step and runs it once with the given inputs.",
+	ArgsUsage: "STEP_ID",
+	Action: func(c *cli.Context) error {
+		exitCode, err := stepRun(c)
+		if err != nil {
+			log.Errorf("Failed to run the step, error: %s", err)
+		}
+		os.Exit(exitCode)
+		return nil
+	},
+	Flags: []cli.Flag{
+		cli.StringSliceFlag{
+			Name:  stepRunInputKey,
+			Usage: "Input of the step in KEY=VALUE format. Can be specified multiple times.",
+		},
+		cli.StringFlag{
+			Name:  stepRunEnvFileKey,
+			Usage: "Path of a file defining the envs of the step run (in the inventory format: envs: [{KEY: value}]).",
+		},
+		cli.StringFlag{
+			Name:   CollectionKey,
+			Usage:  "StepLib of the step, if the step ID does not define it. Defaults to the config's default StepLib or the Bitrise StepLib.",
+			EnvVar: CollectionPathEnvKey,
+		},
+		flConfig,
+		flInventory,
+		cli.BoolFlag{Name: secretFilteringFlag, Usage: "Hide secret values from the log."},
+	},
+}
+
+// stepRun runs the step and returns the exit code of the step run.
+func stepRun(c *cli.Context) (int, error) {
+	if len(c.Args()) != 1 {
+		return 1, errors.New("step not specified, usage: bitrise step run STEP_ID --input KEY=VALUE")
+	}
+	compositeStepID := c.Args()[0]
+
+	inputs, err := parseStepRunInputs(c.StringSlice(stepRunInputKey))
+	if err != nil {
+		return 1, err
+	}
+
+	var environments []envmanModels.EnvironmentItemModel
+	if envFilePth := c.String(stepRunEnvFileKey); envFilePth != "" {
+		environments, err = bitrise.CollectEnvironmentsFromFile(envFilePth)
+		if err != nil {
+			return 1, fmt.Errorf("failed to read env file (%s): %s", envFilePth, err)
+		}
+	}
+
+	inventoryEnvironments, err := CreateInventoryFromCLIParams("", c.String(InventoryKey))
+	if err != nil {
+		return 1, fmt.Errorf("failed to create inventory: %s", err)
+	}
+
+	modes, err := runModes(c, inventoryEnvironments)
+	if err != nil {
+		return 1, err
+	}
+
+	stepLibSource, err := stepRunStepLibSource(c.String(CollectionKey), c.String(ConfigKey))
+	if err != nil {
+		return 1, err
+	}
+
+	config, err := stepRunConfig(compositeStepID, stepLibSource, inputs, environments)
+	if err != nil {
+		return 1, err
+	}
+
+	if configs.IsOfflineMode {
+		if err := checkOfflineArtifacts(config, stepRunWorkflowID, nil, defaultOfflineArtifactChecker); err != nil {
+			return 1, err
+		}
+	}
+
+	if err := bitrise.RunSetupIfNeeded(version.VERSION, false); err != nil {
+		return 1, fmt.Errorf("setup failed: %s", err)
+	}
+
+	tracker := analytics.NewDefaultTracker()
+	defer func() {
+		tracker.Wait()
+	}()
+
+	runner := NewWorkflowRunner(RunConfig{
+		Modes:    modes,
+		Config:   config,
+		Workflow: stepRunWorkflowID,
+		Secrets:  inventoryEnvironments,
+	})
+	buildRunResults, outputs, err := runner.runSingleStep(tracker)
+	if err != nil {
+		return 1, err
+	}
+
+	if len(outputs) > 0 {
+		log.Print()
+		log.Infof("Outputs:")
+		for _, output := range stepRunOutputs(outputs) {
+			log.Printf("%s", output)
+		}
+	}
+
+	return buildRunResults.ExitCode(), nil
+}
+
+// parseStepRunInputs parses the KEY=VALUE inputs, the value may contain further = characters.
+func parseStepRunInputs(items []string) ([]envmanModels.EnvironmentItemModel, error) {
+	var inputs []envmanModels.EnvironmentItemModel
+	for _, item := range items {
+		split := strings.SplitN(item, "=", 2)
+		if len(split) != 2 || split[0] == "" {
+			return nil, fmt.Errorf("invalid input (%s), should be in KEY=VALUE format", item)
+		}
+		inputs = append(inputs, envmanModels.EnvironmentItemModel{split[0]: split[1]})
+	}
+	return inputs, nil
+}
+
+// stepRunStepLibSource returns the StepLib of the steps referenced without a StepLib:
+// the collection if defined, otherwise the default StepLib of the config (if there is any), otherwise the Bitrise StepLib.
+func stepRunStepLibSource(collection, bitriseConfigPath string) (string, error) {
+	if collection != "" {
+		return collection, nil
+	}
+
+	// the config is optional, it is read only if it is specified or found on its default path
+	if bitriseConfigPath == "" {
+		if defaultPth := filepath.Join(configs.CurrentDir, DefaultBitriseConfigFileName); isPathExists(defaultPth) {
+			bitriseConfigPath = defaultPth
+		}
+	}
+	if bitriseConfigPath != "" {
+		config, warnings, err := CreateBitriseConfigFromCLIParams("", bitriseConfigPath)
+		for _, warning := range warnings {
+			log.Warnf("warning: %s", warning)
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to create bitrise config: %s", err)
+		}
+		if config.DefaultStepLibSource != "" {
+			return config.DefaultStepLibSource, nil
+		}
+	}
+
+	return defaultStepRunStepLibSource, nil
+}
+
+// stepRunConfig returns a config with a single workflow, which runs the step with the given inputs.
+func stepRunConfig(compositeStepID, stepLibSource string, inputs, environments []envmanModels.EnvironmentItemModel) (models.BitriseDataModel, error) {
+	if _, err := models.CreateStepIDDataFromString(compositeStepID, stepLibSource); err != nil {
+		return models.BitriseDataModel{}, fmt.Errorf("invalid step (%s): %s", compositeStepID, err)
+	}
+
+	config := models.BitriseDataModel{
+		FormatVersion:        models.FormatVersion,
+		DefaultStepLibSource: stepLibSource,
+		Workflows: map[string]models.WorkflowModel{
+			stepRunWorkflowID: {
+				Environments: environments,
+				Steps: []models.StepListItemModel{
+					{compositeStepID: stepmanModels.StepModel{Inputs: inputs}},
+				},
+			},
+		},
+	}
+	if _, err := config.Validate(); err != nil {
+		return models.BitriseDataModel{}, err
+	}
+	return config, nil
+}
+
+// runSingleStep runs the only step of the runner's workflow, returns the run results and the outputs of the step.
+func (r WorkflowRunner) runSingleStep(tracker analytics.Tracker) (models.BuildRunResultsModel, []envmanModels.EnvironmentItemModel, error) {
+	environments, err := r.setupRun()
+	if err != nil {
+		return models.BuildRunResultsModel{}, nil, err
+	}
+
+	inputWarnings, err := validateWorkflowsStepInputs(r.config.Config, []string{r.config.Workflow}, defaultStepSpecProvider)
+	for _, warning := range inputWarnings {
+		log.Warnf("warning: %s", warning)
+	}
+	if err != nil {
+		return models.BuildRunResultsModel{}, nil, err
+	}
+
+	plan := createWorkflowRunPlan(r.config.Modes, r.config.Workflow, r.config.Config.Workflows, func() string { return uuid.Must(uuid.NewV4()).String() })
+	workflowRunPlan := plan.ExecutionPlan[0]
+	workflow := r.config.Config.Workflows[r.config.Workflow]
+	workflow.Title = workflowRunPlan.Steps[0].StepID
+
+	buildRunResults := models.BuildRunResultsModel{
+		WorkflowID:     r.config.Workflow,
+		StartTime:      time.Now(),
+		StepmanUpdates: map[string]int{},
+	}
+	workflowIDProperties := coreanalytics.Properties{analytics.WorkflowExecutionID: workflowRunPlan.UUID}
+
+	// the step's outputs are appended to the environments
+	outputsIdx := len(environments)
+	buildRunResults = r.activateAndRunSteps(workflowRunPlan, workflow, r.config.Config.DefaultStepLibSource, buildRunResults, &environments, r.config.Secrets, true, tracker, workflowIDProperties)
+	bitrise.PrintSummary(buildRunResults)
+
+	return buildRunResults, environments[outputsIdx:], nil
+}
+
+// stepRunOutputs returns the printable outputs, the values of the sensitive outputs are redacted.
+func stepRunOutputs(outputs []envmanModels.EnvironmentItemModel) []string {
+	var items []string
+	for _, output := range outputs {
+		key, value, err := output.GetKeyValuePair()
+		if err != nil {
+			continue
+		}
+		if opts, err := output.GetOptions(); err == nil && opts.IsSensitive != nil && *opts.IsSensitive {
+			value = "[REDACTED]"
+		}
+		items = append(items, fmt.Sprintf("%s: %s", key, value))
+	}
+	return items
+}
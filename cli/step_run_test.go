@@ -0,0 +1,137 @@
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	envmanModels "github.com/bitrise-io/envman/models"
+	"github.com/bitrise-io/go-utils/pointers"
+	"github.com/stretchr/testify/require"
+	"github.com/tothszabi/bitrise-test/configs"
+)
+
+func TestParseStepRunInputs(t *testing.T) {
+	t.Log("inputs")
+	{
+		inputs, err := parseStepRunInputs([]string{"name=World", "query=a=b", "empty="})
+		require.NoError(t, err)
+		require.Equal(t, []envmanModels.EnvironmentItemModel{
+			{"name": "World"},
+			{"query": "a=b"},
+			{"empty": ""},
+		}, inputs)
+	}
+
+	t.Log("no inputs")
+	{
+		inputs, err := parseStepRunInputs(nil)
+		require.NoError(t, err)
+		require.Equal(t, 0, len(inputs))
+	}
+
+	t.Log("invalid inputs")
+	{
+		_, err := parseStepRunInputs([]string{"name"})
+		require.EqualError(t, err, "invalid input (name), should be in KEY=VALUE format")
+
+		_, err = parseStepRunInputs([]string{"=World"})
+		require.EqualError(t, err, "invalid input (=World), should be in KEY=VALUE format")
+	}
+}
+
+func TestStepRunStepLibSource(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "step-run")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+
+	originalCurrentDir := configs.CurrentDir
+	configs.CurrentDir = tmpDir
+	defer func() {
+		configs.CurrentDir = originalCurrentDir
+	}()
+
+	t.Log("collection")
+	{
+		source, err := stepRunStepLibSource("https://github.com/my/steplib.git", "")
+		require.NoError(t, err)
+		require.Equal(t, "https://github.com/my/steplib.git", source)
+	}
+
+	t.Log("no config")
+	{
+		source, err := stepRunStepLibSource("", "")
+		require.NoError(t, err)
+		require.Equal(t, "https://github.com/bitrise-io/bitrise-steplib.git", source)
+	}
+
+	t.Log("default StepLib of the config")
+	{
+		configPth := filepath.Join(tmpDir, "bitrise.yml")
+		require.NoError(t, ioutil.WriteFile(configPth, []byte(`format_version: "11"
+default_step_lib_source: https://github.com/my/steplib.git
+`), 0644))
+
+		source, err := stepRunStepLibSource("", "")
+		require.NoError(t, err)
+		require.Equal(t, "https://github.com/my/steplib.git", source)
+	}
+
+	t.Log("missing config")
+	{
+		_, err := stepRunStepLibSource("", filepath.Join(tmpDir, "missing.yml"))
+		require.Error(t, err)
+	}
+}
+
+func TestStepRunConfig(t *testing.T) {
+	inputs := []envmanModels.EnvironmentItemModel{{"name": "World"}}
+	envs := []envmanModels.EnvironmentItemModel{{"GREETING": "Hello"}}
+
+	t.Log("StepLib step")
+	{
+		config, err := stepRunConfig("script@1", "https://github.com/bitrise-io/bitrise-steplib.git", inputs, envs)
+		require.NoError(t, err)
+		require.Equal(t, "https://github.com/bitrise-io/bitrise-steplib.git", config.DefaultStepLibSource)
+
+		workflow, found := config.Workflows["step_run"]
+		require.True(t, found)
+		require.Equal(t, envs, workflow.Environments)
+		require.Equal(t, 1, len(workflow.Steps))
+
+		step, found := workflow.Steps[0]["script@1"]
+		require.True(t, found)
+		require.Equal(t, inputs, step.Inputs)
+	}
+
+	t.Log("local step")
+	{
+		config, err := stepRunConfig("path::./steps/my-step", "https://github.com/bitrise-io/bitrise-steplib.git", nil, nil)
+		require.NoError(t, err)
+
+		_, found := config.Workflows["step_run"].Steps[0]["path::./steps/my-step"]
+		require.True(t, found)
+	}
+
+	t.Log("invalid step")
+	{
+		_, err := stepRunConfig("path::", "https://github.com/bitrise-io/bitrise-steplib.git", nil, nil)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid step (path::)")
+	}
+}
+
+func TestStepRunOutputs(t *testing.T) {
+	outputs := []envmanModels.EnvironmentItemModel{
+		{"GREETING_TEXT": "Hello, World!"},
+		{"GREETING_TOKEN": "secret", envmanModels.OptionsKey: envmanModels.EnvironmentItemOptionsModel{IsSensitive: pointers.NewBoolPtr(true)}},
+	}
+
+	require.Equal(t, []string{
+		"GREETING_TEXT: Hello, World!",
+		"GREETING_TOKEN: [REDACTED]",
+	}, stepRunOutputs(outputs))
+}
@@ -0,0 +1,285 @@
+package cli
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/bitrise-io/stepman/stepman"
+	"github.com/tothszabi/bitrise-test/log"
+	"github.com/tothszabi/bitrise-test/models"
+	"github.com/tothszabi/bitrise-test/stepcache"
+	"github.com/tothszabi/bitrise-test/toolkits"
+	"github.com/tothszabi/bitrise-test/tools"
+	"github.com/urfave/cli"
+)
+
+const (
+	defaultStepsCacheArchivePath = "steps-cache.tar.gz"
+
+	stepsCacheForceKey = "force"
+)
+
+var stepsCommand = cli.Command{
+	Name:  "steps",
+	Usage: "Manage the steps of the config.",
+	Subcommands: []cli.Command{
+		{
+			Name:  "cache",
+			Usage: "Export or import the step caches.",
+			Subcommands: []cli.Command{
+				{
+					Name:  "export",
+					Usage: "Packs the cached sources, step.yml files and Go binaries of the StepLib steps referenced by the config into an archive.",
+					Action: func(c *cli.Context) error {
+						if err := stepsCacheExport(c); err != nil {
+							log.Errorf("Failed to export the step cache, error: %s", err)
+							os.Exit(1)
+						}
+						return nil
+					},
+					Flags: []cli.Flag{
+						flConfig,
+						flConfigBase64,
+						cli.StringFlag{
+							Name:  OuputPathKey,
+							Value: defaultStepsCacheArchivePath,
+							Usage: "Path of the archive.",
+						},
+					},
+				},
+				{
+					Name:  "import",
+					Usage: "Extracts an archive created by the export command into the step caches.",
+					Action: func(c *cli.Context) error {
+						if err := stepsCacheImport(c); err != nil {
+							log.Errorf("Failed to import the step cache, error: %s", err)
+							os.Exit(1)
+						}
+						return nil
+					},
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  PathKey,
+							Value: defaultStepsCacheArchivePath,
+							Usage: "Path of the archive.",
+						},
+						cli.BoolFlag{
+							Name:  stepsCacheForceKey,
+							Usage: "Overwrite the already cached steps.",
+						},
+					},
+				},
+			},
+		},
+	},
+}
+
+func stepsCacheExport(c *cli.Context) error {
+	bitriseConfig, warnings, err := CreateBitriseConfigFromCLIParams(c.String(ConfigBase64Key), c.String(ConfigKey))
+	for _, warning := range warnings {
+		log.Warnf("warning: %s", warning)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create config: %s", err)
+	}
+
+	stepIDDatas, err := configStepLibSteps(bitriseConfig)
+	if err != nil {
+		return err
+	}
+
+	activationDir, err := ioutil.TempDir("", "steps-cache-export")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := os.RemoveAll(activationDir); err != nil {
+			log.Warnf("Failed to remove %s: %s", activationDir, err)
+		}
+	}()
+
+	var entries []stepcache.Entry
+	entryIdxs := map[string]int{}
+	for _, stepIDData := range stepIDDatas {
+		route, err := ensureStepLibRoute(stepIDData.SteplibSource)
+		if err != nil {
+			return err
+		}
+
+		info, err := tools.StepmanStepInfo(stepIDData.SteplibSource, stepIDData.IDorURI, stepIDData.Version)
+		if err != nil {
+			return fmt.Errorf("failed to get step (%s@%s) info: %s", stepIDData.IDorURI, stepIDData.Version, err)
+		}
+
+		key := stepIDData.SteplibSource + "::" + info.ID + "@" + info.Version
+		idx, found := entryIdxs[key]
+		if !found {
+			// activating the step downloads it into the StepLib cache, if it is not cached yet
+			if err := tools.StepmanActivate(stepIDData.SteplibSource, info.ID, info.Version, filepath.Join(activationDir, strings.ReplaceAll(key, "/", "_")), ""); err != nil {
+				return fmt.Errorf("failed to activate step (%s@%s): %s", info.ID, info.Version, err)
+			}
+
+			entry := stepcache.Entry{
+				Source:    stepIDData.SteplibSource,
+				ID:        info.ID,
+				Version:   info.Version,
+				SourceDir: stepman.GetStepCacheDirPath(route, info.ID, info.Version),
+			}
+			if stepYMLPath := filepath.Join(stepman.GetStepCollectionDirPath(route, info.ID, info.Version), "step.yml"); isPathExists(stepYMLPath) {
+				entry.StepYMLPath = stepYMLPath
+			}
+
+			idx = len(entries)
+			entryIdxs[key] = idx
+			entries = append(entries, entry)
+		}
+
+		// the Go toolkit caches the binaries by the version referenced in the config
+		if binaryPath := toolkits.GoStepBinaryCachePath(stepIDData); entries[idx].BinaryPath == "" && stepIDData.IsUniqueResourceID() && isPathExists(binaryPath) {
+			entries[idx].BinaryPath = binaryPath
+		}
+	}
+
+	outputPth := c.String(OuputPathKey)
+	manifest, err := stepcache.Export(outputPth, entries)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range manifest.Steps {
+		binary := ""
+		if item.BinaryHash != "" {
+			binary = " (with binary)"
+		}
+		log.Printf("- %s@%s%s", item.ID, item.Version, binary)
+	}
+	log.Donef("%d steps exported to %s", len(manifest.Steps), outputPth)
+	return nil
+}
+
+func stepsCacheImport(c *cli.Context) error {
+	archivePth := c.String(PathKey)
+
+	result, err := stepcache.Import(archivePth, func(item stepcache.ManifestItem) (stepcache.Entry, error) {
+		route, err := ensureStepLibRoute(item.Source)
+		if err != nil {
+			return stepcache.Entry{}, err
+		}
+		return stepsCacheImportEntry(route, item)
+	}, c.Bool(stepsCacheForceKey))
+	if err != nil {
+		return err
+	}
+
+	for _, item := range result.Imported {
+		log.Printf("- %s@%s", item.ID, item.Version)
+	}
+	for _, item := range result.Skipped {
+		log.Printf("- %s@%s (already cached, skipped)", item.ID, item.Version)
+	}
+	log.Donef("%d steps imported from %s", len(result.Imported), archivePth)
+	return nil
+}
+
+// stepsCacheImportEntry returns the locations of the imported step in the StepLib's cache,
+// the step's source and step.yml have to be located inside the StepLib's cache and collection dirs.
+// The binary's location is derived from the step's source, ID and version (like for the compiled binaries),
+// the binary name of the archive is not used.
+func stepsCacheImportEntry(route stepman.SteplibRoute, item stepcache.ManifestItem) (stepcache.Entry, error) {
+	entry := stepcache.Entry{
+		Source:      item.Source,
+		ID:          item.ID,
+		Version:     item.Version,
+		SourceDir:   stepman.GetStepCacheDirPath(route, item.ID, item.Version),
+		StepYMLPath: filepath.Join(stepman.GetStepCollectionDirPath(route, item.ID, item.Version), "step.yml"),
+	}
+	if !isSubPath(stepman.GetCacheBaseDir(route), entry.SourceDir) {
+		return stepcache.Entry{}, fmt.Errorf("invalid step source dir: %s is outside of %s", entry.SourceDir, stepman.GetCacheBaseDir(route))
+	}
+	if !isSubPath(stepman.GetLibraryBaseDirPath(route), entry.StepYMLPath) {
+		return stepcache.Entry{}, fmt.Errorf("invalid step.yml path: %s is outside of %s", entry.StepYMLPath, stepman.GetLibraryBaseDirPath(route))
+	}
+
+	if item.BinaryHash != "" {
+		entry.BinaryPath = toolkits.GoStepBinaryCachePath(models.StepIDData{SteplibSource: item.Source, IDorURI: item.ID, Version: item.Version})
+	}
+	return entry, nil
+}
+
+// isSubPath returns true if the cleaned pth is located below the dir.
+func isSubPath(dir, pth string) bool {
+	rel, err := filepath.Rel(dir, pth)
+	if err != nil {
+		return false
+	}
+	return rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// configStepLibSteps returns the StepLib steps of the config's workflows, the steps with other sources (like path:: or git::) are skipped.
+func configStepLibSteps(config models.BitriseDataModel) ([]models.StepIDData, error) {
+	var workflowIDs []string
+	for workflowID := range config.Workflows {
+		workflowIDs = append(workflowIDs, workflowID)
+	}
+	sort.Strings(workflowIDs)
+
+	var stepIDDatas []models.StepIDData
+	seen := map[string]bool{}
+	for _, workflowID := range workflowIDs {
+		for _, stepListItem := range config.Workflows[workflowID].Steps {
+			compositeStepID, _, err := models.GetStepIDStepDataPair(stepListItem)
+			if err != nil {
+				return nil, err
+			}
+			stepIDData, err := models.CreateStepIDDataFromString(compositeStepID, config.DefaultStepLibSource)
+			if err != nil {
+				return nil, err
+			}
+
+			switch stepIDData.SteplibSource {
+			case "path", "git", "_":
+				log.Debugf("Skipping %s step: %s", stepIDData.SteplibSource, compositeStepID)
+				continue
+			}
+
+			key := stepIDData.SteplibSource + "::" + stepIDData.IDorURI + "@" + stepIDData.Version
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			stepIDDatas = append(stepIDDatas, stepIDData)
+		}
+	}
+	return stepIDDatas, nil
+}
+
+// ensureStepLibRoute returns the StepLib's stepman route, and sets up the StepLib if it is not set up yet.
+func ensureStepLibRoute(source string) (stepman.SteplibRoute, error) {
+	if route, found := stepman.ReadRoute(source); found {
+		return route, nil
+	}
+
+	log.Printf("Setting up StepLib: %s", source)
+	if err := tools.StepmanSetup(source); err != nil {
+		return stepman.SteplibRoute{}, fmt.Errorf("failed to set up StepLib (%s): %s", source, err)
+	}
+
+	route, found := stepman.ReadRoute(source)
+	if !found {
+		return stepman.SteplibRoute{}, fmt.Errorf("no route found for StepLib: %s", source)
+	}
+	return route, nil
+}
+
+func isPathExists(pth string) bool {
+	exists, err := pathutil.IsPathExists(pth)
+	if err != nil {
+		log.Warnf("Failed to check path (%s): %s", pth, err)
+	}
+	return exists
+}
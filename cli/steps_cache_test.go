@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/stepman/stepman"
+	"github.com/stretchr/testify/require"
+	"github.com/tothszabi/bitrise-test/bitrise"
+	"github.com/tothszabi/bitrise-test/models"
+	"github.com/tothszabi/bitrise-test/stepcache"
+	"github.com/tothszabi/bitrise-test/toolkits"
+)
+
+func TestConfigStepLibSteps(t *testing.T) {
+	configStr := `
+format_version: 1.4.0
+default_step_lib_source: https://github.com/bitrise-io/bitrise-steplib.git
+
+workflows:
+  test:
+    steps:
+    - script@1.1.3: {}
+    - path::./my-step: {}
+    - git::https://github.com/bitrise-steplib/steps-hello.git@master: {}
+  build:
+    steps:
+    - https://github.com/my-org/steplib.git::my-step@2.0.0: {}
+    - script@1.1.3: {}
+    - script@1: {}
+`
+	config, warnings, err := bitrise.ConfigModelFromYAMLBytes([]byte(configStr))
+	require.NoError(t, err)
+	require.Equal(t, 0, len(warnings))
+
+	stepIDDatas, err := configStepLibSteps(config)
+	require.NoError(t, err)
+	require.Equal(t, []models.StepIDData{
+		{SteplibSource: "https://github.com/my-org/steplib.git", IDorURI: "my-step", Version: "2.0.0"},
+		{SteplibSource: "https://github.com/bitrise-io/bitrise-steplib.git", IDorURI: "script", Version: "1.1.3"},
+		{SteplibSource: "https://github.com/bitrise-io/bitrise-steplib.git", IDorURI: "script", Version: "1"},
+	}, stepIDDatas)
+}
+
+func TestStepsCacheImportEntry(t *testing.T) {
+	route := stepman.SteplibRoute{SteplibURI: "https://github.com/bitrise-io/bitrise-steplib.git", FolderAlias: "alias"}
+
+	t.Log("step")
+	{
+		entry, err := stepsCacheImportEntry(route, stepcache.ManifestItem{Source: route.SteplibURI, ID: "script", Version: "1.1.3", BinaryHash: "hash", BinaryName: "script-bin"})
+		require.NoError(t, err)
+		require.Equal(t, stepman.GetStepCacheDirPath(route, "script", "1.1.3"), entry.SourceDir)
+		require.Equal(t, filepath.Join(stepman.GetStepCollectionDirPath(route, "script", "1.1.3"), "step.yml"), entry.StepYMLPath)
+		require.Equal(t, toolkits.GoStepBinaryCachePath(models.StepIDData{SteplibSource: route.SteplibURI, IDorURI: "script", Version: "1.1.3"}), entry.BinaryPath)
+	}
+
+	t.Log("step without binary")
+	{
+		entry, err := stepsCacheImportEntry(route, stepcache.ManifestItem{Source: route.SteplibURI, ID: "script", Version: "1.1.3"})
+		require.NoError(t, err)
+		require.Equal(t, "", entry.BinaryPath)
+	}
+
+	t.Log("binary name of another step is ignored")
+	{
+		otherBinaryPath := toolkits.GoStepBinaryCachePath(models.StepIDData{SteplibSource: route.SteplibURI, IDorURI: "other", Version: "1.0.0"})
+		entry, err := stepsCacheImportEntry(route, stepcache.ManifestItem{Source: route.SteplibURI, ID: "script", Version: "1.1.3", BinaryHash: "hash", BinaryName: filepath.Base(otherBinaryPath)})
+		require.NoError(t, err)
+		require.NotEqual(t, otherBinaryPath, entry.BinaryPath)
+	}
+
+	t.Log("path traversal in id or version")
+	{
+		for _, item := range []stepcache.ManifestItem{
+			{ID: "../../../..", Version: "1.0.0"},
+			{ID: "script", Version: "../../.."},
+			{ID: "..", Version: ".."},
+		} {
+			_, err := stepsCacheImportEntry(route, item)
+			require.Error(t, err)
+			require.Contains(t, err.Error(), "is outside of")
+		}
+	}
+}
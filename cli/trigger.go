@@ -29,6 +29,13 @@ var triggerCommand = cli.Command{
 		cli.StringFlag{Name: PRSourceBranchKey, Usage: "Git pull request source branch name."},
 		cli.StringFlag{Name: PRTargetBranchKey, Usage: "Git pull request target branch name."},
 		cli.StringFlag{Name: TagKey, Usage: "Git tag name."},
+		flChangedFile,
+		flChangedFilesDiff,
+		flCommitMessage,
+		flPRLabel,
+		flPRDraft,
+		flEventPayload,
+		flProvider,
 
 		// cli params used in CI mode
 		cli.StringFlag{Name: JSONParamsKey, Usage: "Specify command flags with json string-string hash."},
@@ -54,14 +61,32 @@ func printAvailableTriggerFilters(triggerMap []models.TriggerMapItemModel) {
 		} else {
 			if triggerItem.PushBranch != "" {
 				log.Infof(" * push_branch: %s", triggerItem.PushBranch)
+				if triggerItem.ChangedFiles != nil {
+					log.Infof("   changed_files: %s", triggerItem.ChangedFiles)
+				}
+				log.Infof("   workflow: %s", triggerItem.WorkflowID)
+			} else if triggerItem.PushBranchRegex != "" {
+				log.Infof(" * push_branch_regex: %s", triggerItem.PushBranchRegex)
+				if triggerItem.ChangedFiles != nil {
+					log.Infof("   changed_files: %s", triggerItem.ChangedFiles)
+				}
 				log.Infof("   workflow: %s", triggerItem.WorkflowID)
 			} else if triggerItem.PullRequestSourceBranch != "" || triggerItem.PullRequestTargetBranch != "" {
 				log.Infof(" * pull_request_source_branch: %s", triggerItem.PullRequestSourceBranch)
 				log.Infof("   pull_request_target_branch: %s", triggerItem.PullRequestTargetBranch)
+				if triggerItem.ChangedFiles != nil {
+					log.Infof("   changed_files: %s", triggerItem.ChangedFiles)
+				}
 				log.Infof("   workflow: %s", triggerItem.WorkflowID)
 			} else if triggerItem.Tag != "" {
 				log.Infof(" * tag: %s", triggerItem.Tag)
 				log.Infof("   workflow: %s", triggerItem.WorkflowID)
+			} else if triggerItem.TagRegex != "" {
+				log.Infof(" * tag_regex: %s", triggerItem.TagRegex)
+				log.Infof("   workflow: %s", triggerItem.WorkflowID)
+			} else if triggerItem.Schedule != "" {
+				log.Infof(" * schedule: %s", triggerItem.Schedule)
+				log.Infof("   workflow: %s", triggerItem.WorkflowID)
 			}
 		}
 	}
@@ -129,6 +154,22 @@ func trigger(c *cli.Context) error {
 		return fmt.Errorf("Failed to parse trigger command params, error: %s", err)
 	}
 
+	triggerParams, err = overrideChangedFilesParams(triggerParams, c.StringSlice(ChangedFileKey), c.String(ChangedFilesDiffKey))
+	if err != nil {
+		return fmt.Errorf("Failed to parse trigger command params, error: %s", err)
+	}
+	triggerParams = overrideCommitAndPRParams(triggerParams, c.String(CommitMessageKey), c.StringSlice(PRLabelKey), c.Bool(PRDraftKey))
+
+	triggerParams, event, err := overrideEventPayloadParams(triggerParams, c.String(EventPayloadKey), c.String(ProviderKey))
+	if err != nil {
+		return fmt.Errorf("Failed to parse trigger command params, error: %s", err)
+	}
+	if event != nil {
+		if err := exportWebhookEventEnvs(*event); err != nil {
+			failf("Failed to export the webhook event envs, error: %s", err)
+		}
+	}
+
 	// Inventory validation
 	inventoryEnvironments, err := CreateInventoryFromCLIParams(triggerParams.InventoryBase64Data, triggerParams.InventoryPath)
 	if err != nil {
@@ -164,6 +205,11 @@ func trigger(c *cli.Context) error {
 		failf("Failed to check Secret Envs Filtering mode, error: %s", err)
 	}
 
+	stepLock, err := readConfigStepLock(triggerParams.BitriseConfigBase64Data, triggerParams.BitriseConfigPath)
+	if err != nil {
+		failf("Failed to read step lock, error: %s", err)
+	}
+
 	isPRMode, err := isPRMode(prGlobalFlagPtr, inventoryEnvironments)
 	if err != nil {
 		failf("Failed to check  PR mode, error: %s", err)
@@ -182,6 +228,9 @@ func trigger(c *cli.Context) error {
 		}
 		os.Exit(1)
 	}
+	if warning := unevaluatedTriggerConditionsWarning(bitriseConfig.TriggerMap, triggerParams, isPRMode); warning != "" {
+		log.Warnf("warning: %s", warning)
+	}
 
 	runConfig := RunConfig{
 		Modes: models.WorkflowRunModes{
@@ -195,6 +244,7 @@ func trigger(c *cli.Context) error {
 		Config:   bitriseConfig,
 		Workflow: workflowToRunID,
 		Secrets:  inventoryEnvironments,
+		StepLock: stepLock,
 	}
 
 	runner := NewWorkflowRunner(runConfig)
@@ -58,9 +58,32 @@ func migratePatternToParams(params RunAndTriggerParamsModel, isPullRequestMode b
 	return params
 }
 
+func triggerEventByParams(params RunAndTriggerParamsModel) (models.TriggerEventModel, error) {
+	changedFiles, err := changedFilesByParams(params)
+	if err != nil {
+		return models.TriggerEventModel{}, err
+	}
+
+	return models.TriggerEventModel{
+		PushBranch:     params.PushBranch,
+		PRSourceBranch: params.PRSourceBranch,
+		PRTargetBranch: params.PRTargetBranch,
+		Tag:            params.Tag,
+		ChangedFiles:   changedFiles,
+		CommitMessage:  params.CommitMessage,
+		PRLabels:       params.PRLabels,
+		IsDraftPR:      params.IsDraftPR,
+	}, nil
+}
+
 func getPipelineAndWorkflowIDByParams(triggerMap models.TriggerMapModel, params RunAndTriggerParamsModel) (string, string, error) {
+	event, err := triggerEventByParams(params)
+	if err != nil {
+		return "", "", err
+	}
+
 	for _, item := range triggerMap {
-		match, err := item.MatchWithParams(params.PushBranch, params.PRSourceBranch, params.PRTargetBranch, params.Tag)
+		match, err := item.Match(event)
 		if err != nil {
 			return "", "", err
 		}
@@ -72,6 +95,26 @@ func getPipelineAndWorkflowIDByParams(triggerMap models.TriggerMapModel, params
 	return "", "", fmt.Errorf("no matching pipeline & workflow found with trigger params: push-branch: %s, pr-source-branch: %s, pr-target-branch: %s, tag: %s", params.PushBranch, params.PRSourceBranch, params.PRTargetBranch, params.Tag)
 }
 
+// unevaluatedTriggerConditionsWarning returns a warning if the first matching trigger item has conditions,
+// which are not evaluated, as the related trigger params (like the changed files) are not specified.
+func unevaluatedTriggerConditionsWarning(triggerMap models.TriggerMapModel, params RunAndTriggerParamsModel, isPullRequestMode bool) string {
+	if params.TriggerPattern != "" {
+		params = migratePatternToParams(params, isPullRequestMode)
+	}
+
+	event, err := triggerEventByParams(params)
+	if err != nil {
+		return ""
+	}
+
+	for idx, item := range triggerMap {
+		if match, err := item.Match(event); err == nil && match {
+			return item.UnevaluatedConditionsWarning(idx, event)
+		}
+	}
+	return ""
+}
+
 // migrates deprecated params.TriggerPattern to params.PushBranch or params.PRSourceBranch based on isPullRequestMode
 // and returns the triggered workflow id
 func getPipelineAndWorkflowIDByParamsInCompatibleMode(triggerMap models.TriggerMapModel, params RunAndTriggerParamsModel, isPullRequestMode bool) (string, string, error) {
@@ -132,6 +175,17 @@ func triggerCheck(c *cli.Context) error {
 	if err != nil {
 		registerFatal(fmt.Sprintf("Failed to parse trigger check params, err: %s", err), warnings, triggerParams.Format)
 	}
+
+	triggerParams, err = overrideChangedFilesParams(triggerParams, c.StringSlice(ChangedFileKey), c.String(ChangedFilesDiffKey))
+	if err != nil {
+		registerFatal(fmt.Sprintf("Failed to parse trigger check params, err: %s", err), warnings, triggerParams.Format)
+	}
+	triggerParams = overrideCommitAndPRParams(triggerParams, c.String(CommitMessageKey), c.StringSlice(PRLabelKey), c.Bool(PRDraftKey))
+
+	triggerParams, _, err = overrideEventPayloadParams(triggerParams, c.String(EventPayloadKey), c.String(ProviderKey))
+	if err != nil {
+		registerFatal(fmt.Sprintf("Failed to parse trigger check params, err: %s", err), warnings, triggerParams.Format)
+	}
 	//
 
 	// Inventory validation
@@ -154,6 +208,16 @@ func triggerCheck(c *cli.Context) error {
 		registerFatal(fmt.Sprintf("Invalid format: %s", triggerParams.Format), warnings, output.FormatJSON)
 	}
 
+	if c.Bool(testKey) {
+		testTriggerMap(bitriseConfig, c.String(testsFileKey), triggerParams.Format, warnings)
+		return nil
+	}
+
+	if c.IsSet(atKey) {
+		scheduleTriggerCheck(bitriseConfig.TriggerMap, c.String(atKey), triggerParams.Format, warnings)
+		return nil
+	}
+
 	// Trigger filter validation
 	if triggerParams.TriggerPattern == "" &&
 		triggerParams.PushBranch == "" && triggerParams.PRSourceBranch == "" && triggerParams.PRTargetBranch == "" && triggerParams.Tag == "" {
@@ -168,6 +232,11 @@ func triggerCheck(c *cli.Context) error {
 		registerFatal(fmt.Sprintf("Failed to check  PR mode, err: %s", err), warnings, triggerParams.Format)
 	}
 
+	if c.Bool(explainKey) {
+		explainTriggerCheck(bitriseConfig.TriggerMap, triggerParams, isPRMode, warnings)
+		return nil
+	}
+
 	pipelineToRunID, workflowToRunID, err := getPipelineAndWorkflowIDByParamsInCompatibleMode(bitriseConfig.TriggerMap, triggerParams, isPRMode)
 	if err != nil {
 		registerFatal(err.Error(), warnings, triggerParams.Format)
@@ -209,6 +278,9 @@ func triggerCheck(c *cli.Context) error {
 			}
 		}
 		log.Print(msg)
+		if warning := unevaluatedTriggerConditionsWarning(bitriseConfig.TriggerMap, triggerParams, isPRMode); warning != "" {
+			log.Warnf("warning: %s", warning)
+		}
 		break
 	case output.FormatJSON:
 		bytes, err := json.Marshal(triggerModel)
@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/bitrise-io/go-utils/colorstring"
+	"github.com/tothszabi/bitrise-test/log"
+	"github.com/tothszabi/bitrise-test/models"
+	"github.com/tothszabi/bitrise-test/output"
+)
+
+const atKey = "at"
+
+// ScheduledTriggerModel ...
+type ScheduledTriggerModel struct {
+	Schedule   string `json:"schedule" yaml:"schedule"`
+	PipelineID string `json:"pipeline,omitempty" yaml:"pipeline,omitempty"`
+	WorkflowID string `json:"workflow,omitempty" yaml:"workflow,omitempty"`
+}
+
+// ScheduledTriggersModel ...
+type ScheduledTriggersModel struct {
+	At       string                  `json:"at" yaml:"at"`
+	Triggers []ScheduledTriggerModel `json:"triggers" yaml:"triggers"`
+}
+
+// parseScheduleTime parses an RFC3339 time (2006-01-02T15:04:05Z07:00) or the now keyword.
+func parseScheduleTime(value string) (time.Time, error) {
+	if value == "now" {
+		return time.Now().UTC(), nil
+	}
+
+	at, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time (%s), expected RFC3339 format (e.g. 2006-01-02T15:04:05Z) or now", value)
+	}
+	return at.UTC(), nil
+}
+
+func scheduledTriggers(triggerMap models.TriggerMapModel, at time.Time) (ScheduledTriggersModel, error) {
+	items, err := triggerMap.ScheduledItems(at)
+	if err != nil {
+		return ScheduledTriggersModel{}, err
+	}
+
+	scheduled := ScheduledTriggersModel{
+		At:       at.Format(time.RFC3339),
+		Triggers: []ScheduledTriggerModel{},
+	}
+	for _, item := range items {
+		scheduled.Triggers = append(scheduled.Triggers, ScheduledTriggerModel{
+			Schedule:   item.Schedule,
+			PipelineID: item.PipelineID,
+			WorkflowID: item.WorkflowID,
+		})
+	}
+	return scheduled, nil
+}
+
+// scheduleTriggerCheck prints the pipelines and workflows scheduled to the given time,
+// fails if none of the schedule trigger map items fire.
+func scheduleTriggerCheck(triggerMap models.TriggerMapModel, atValue, format string, warnings []string) {
+	at, err := parseScheduleTime(atValue)
+	if err != nil {
+		registerFatal(err.Error(), warnings, format)
+	}
+
+	scheduled, err := scheduledTriggers(triggerMap, at)
+	if err != nil {
+		registerFatal(err.Error(), warnings, format)
+	}
+	if len(scheduled.Triggers) == 0 {
+		registerFatal(fmt.Sprintf("no scheduled pipeline & workflow found at: %s", scheduled.At), warnings, format)
+	}
+
+	switch format {
+	case output.FormatRaw:
+		for _, trigger := range scheduled.Triggers {
+			log.Printf("schedule: %s -> %s", trigger.Schedule, colorstring.Blue(triggerTarget(trigger.PipelineID, trigger.WorkflowID)))
+		}
+	case output.FormatJSON:
+		bytes, err := json.Marshal(scheduled)
+		if err != nil {
+			registerFatal(fmt.Sprintf("Failed to parse scheduled triggers, err: %s", err), warnings, format)
+		}
+		log.Print(string(bytes))
+	default:
+		registerFatal(fmt.Sprintf("Invalid format: %s", format), warnings, output.FormatJSON)
+	}
+}
@@ -0,0 +1,132 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/bitrise-io/go-utils/colorstring"
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/tothszabi/bitrise-test/log"
+	"github.com/tothszabi/bitrise-test/models"
+	"github.com/tothszabi/bitrise-test/output"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	testKey      = "test"
+	testsFileKey = "tests-file"
+)
+
+// TriggerTestsReportModel ...
+type TriggerTestsReportModel struct {
+	Results  []models.TriggerTestResult `json:"results"`
+	Passed   int                        `json:"passed"`
+	Failed   int                        `json:"failed"`
+	Warnings []string                   `json:"warnings,omitempty"`
+}
+
+// readTriggerTests reads the trigger tests from the trigger_tests section of the given YAML (or JSON) file.
+func readTriggerTests(pth string) ([]models.TriggerTestModel, error) {
+	bytes, err := fileutil.ReadBytesFromFile(pth)
+	if err != nil {
+		return nil, err
+	}
+
+	var testsFile struct {
+		TriggerTests []models.TriggerTestModel `yaml:"trigger_tests"`
+	}
+	if err := yaml.Unmarshal(bytes, &testsFile); err != nil {
+		return nil, err
+	}
+
+	for _, test := range testsFile.TriggerTests {
+		if err := test.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	return testsFile.TriggerTests, nil
+}
+
+// testTriggerMap runs the trigger tests of the config (or of the tests file, if specified) and prints the report,
+// exits with 1 if any of the tests failed.
+func testTriggerMap(bitriseConfig models.BitriseDataModel, testsFilePath, format string, warnings []string) {
+	tests := bitriseConfig.TriggerTests
+	if testsFilePath != "" {
+		var err error
+		tests, err = readTriggerTests(testsFilePath)
+		if err != nil {
+			registerFatal(fmt.Sprintf("Failed to read trigger tests (%s), err: %s", testsFilePath, err), warnings, format)
+		}
+	}
+	if len(tests) == 0 {
+		registerFatal("No trigger tests defined", warnings, format)
+	}
+
+	report := TriggerTestsReportModel{
+		Results:  bitriseConfig.TriggerMap.RunTests(tests),
+		Warnings: warnings,
+	}
+	for _, result := range report.Results {
+		if result.Passed {
+			report.Passed++
+		} else {
+			report.Failed++
+		}
+	}
+
+	switch format {
+	case output.FormatRaw:
+		printTriggerTestsReport(report)
+	case output.FormatJSON:
+		bytes, err := json.Marshal(report)
+		if err != nil {
+			registerFatal(fmt.Sprintf("Failed to parse trigger tests report, err: %s", err), warnings, format)
+		}
+		log.Print(string(bytes))
+	default:
+		registerFatal(fmt.Sprintf("Invalid format: %s", format), warnings, output.FormatJSON)
+	}
+
+	if report.Failed > 0 {
+		os.Exit(1)
+	}
+}
+
+func triggerTestTarget(pipelineID, workflowID string) string {
+	if pipelineID == "" && workflowID == "" {
+		return "nothing"
+	}
+	return triggerTarget(pipelineID, workflowID)
+}
+
+func printTriggerTestsReport(report TriggerTestsReportModel) {
+	for _, warning := range report.Warnings {
+		log.Warnf("warning: %s", warning)
+	}
+
+	for _, result := range report.Results {
+		name := result.Event
+		if result.Title != "" {
+			name = fmt.Sprintf("%s (%s)", result.Title, result.Event)
+		}
+
+		switch {
+		case result.Error != "":
+			log.Printf("%s trigger_tests[%d] %s: %s", colorstring.Red("✗"), result.Index, name, result.Error)
+		case result.Passed:
+			log.Printf("%s trigger_tests[%d] %s -> %s", colorstring.Green("✓"), result.Index, name, triggerTestTarget(result.ActualPipeline, result.ActualWorkflow))
+		default:
+			log.Printf("%s trigger_tests[%d] %s: expected %s, triggered %s", colorstring.Red("✗"), result.Index, name,
+				triggerTestTarget(result.ExpectedPipeline, result.ExpectedWorkflow), triggerTestTarget(result.ActualPipeline, result.ActualWorkflow))
+		}
+	}
+	log.Print()
+
+	if report.Failed > 0 {
+		log.Errorf("%d of %d trigger tests failed", report.Failed, len(report.Results))
+	} else {
+		log.Donef("All the %d trigger tests passed", len(report.Results))
+	}
+}
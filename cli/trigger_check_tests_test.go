@@ -0,0 +1,51 @@
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tothszabi/bitrise-test/models"
+)
+
+func TestReadTriggerTests(t *testing.T) {
+	dir, err := ioutil.TempDir("", "trigger-tests")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+
+	t.Log("valid tests file")
+	{
+		pth := filepath.Join(dir, "trigger_tests.yml")
+		require.NoError(t, ioutil.WriteFile(pth, []byte(`trigger_tests:
+- title: release
+  tag: 1.0.0
+  pipeline: release
+- pull_request_source_branch: feature
+  pull_request_labels: [ci]
+  workflow: pr
+`), 0644))
+
+		tests, err := readTriggerTests(pth)
+		require.NoError(t, err)
+		require.Equal(t, []models.TriggerTestModel{
+			{Title: "release", Tag: "1.0.0", PipelineID: "release"},
+			{PullRequestSourceBranch: "feature", PullRequestLabels: []string{"ci"}, WorkflowID: "pr"},
+		}, tests)
+	}
+
+	t.Log("invalid test")
+	{
+		pth := filepath.Join(dir, "invalid.yml")
+		require.NoError(t, ioutil.WriteFile(pth, []byte(`trigger_tests:
+- push_branch: master
+  tag: 1.0.0
+`), 0644))
+
+		_, err := readTriggerTests(pth)
+		require.EqualError(t, err, "invalid trigger test (push-branch: master, tag: 1.0.0), error: push_branch (master) selects code-push trigger event, but tag (1.0.0) also provided")
+	}
+}
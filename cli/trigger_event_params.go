@@ -0,0 +1,142 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/command"
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/tothszabi/bitrise-test/log"
+	"github.com/tothszabi/bitrise-test/webhook"
+)
+
+// overrideChangedFilesParams overrides the changed files params (parsed from the json params) with the cli params.
+func overrideChangedFilesParams(params RunAndTriggerParamsModel, changedFiles []string, changedFilesDiff string) (RunAndTriggerParamsModel, error) {
+	if len(changedFiles) > 0 && changedFilesDiff != "" {
+		return RunAndTriggerParamsModel{}, fmt.Errorf("both %s and %s specified", ChangedFileKey, ChangedFilesDiffKey)
+	}
+
+	if len(changedFiles) > 0 {
+		params.ChangedFiles = changedFiles
+		params.ChangedFilesDiff = ""
+	}
+	if changedFilesDiff != "" {
+		params.ChangedFiles = nil
+		params.ChangedFilesDiff = changedFilesDiff
+	}
+
+	return params, nil
+}
+
+// changedFilesByParams returns the changed files of the git event,
+// either the explicitly specified ones or the files changed in the specified git revision range.
+// Returns nil if the changed files are unknown.
+func changedFilesByParams(params RunAndTriggerParamsModel) ([]string, error) {
+	if params.ChangedFilesDiff == "" {
+		return params.ChangedFiles, nil
+	}
+	if params.ChangedFiles != nil {
+		return nil, fmt.Errorf("both changed-files and changed-files-diff specified")
+	}
+	return changedFilesFromGitDiff("", params.ChangedFilesDiff)
+}
+
+// changedFilesFromGitDiff lists the files changed in the given revision range of the git repository in dir
+// (the current directory if dir is empty).
+func changedFilesFromGitDiff(dir, revisionRange string) ([]string, error) {
+	out, err := command.New("git", "diff", "--name-only", revisionRange, "--").SetDir(dir).RunAndReturnTrimmedCombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the changed files of %s: %s, output: %s", revisionRange, err, out)
+	}
+
+	changedFiles := []string{}
+	for _, line := range strings.Split(out, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			changedFiles = append(changedFiles, line)
+		}
+	}
+	return changedFiles, nil
+}
+
+// overrideCommitAndPRParams overrides the commit message and pull request params (parsed from the json params) with the cli params.
+func overrideCommitAndPRParams(params RunAndTriggerParamsModel, commitMessage string, prLabels []string, isDraftPR bool) RunAndTriggerParamsModel {
+	if commitMessage != "" {
+		params.CommitMessage = commitMessage
+	}
+	if len(prLabels) > 0 {
+		params.PRLabels = prLabels
+	}
+	if isDraftPR {
+		params.IsDraftPR = true
+	}
+	return params
+}
+
+// overrideEventPayloadParams overrides the trigger params with the ones parsed from the webhook payload file,
+// the parsed event is returned as well (nil if no payload is specified).
+func overrideEventPayloadParams(params RunAndTriggerParamsModel, payloadPath, provider string) (RunAndTriggerParamsModel, *webhook.Event, error) {
+	if payloadPath == "" {
+		return params, nil, nil
+	}
+	if provider == "" {
+		return RunAndTriggerParamsModel{}, nil, fmt.Errorf("%s specified without %s", EventPayloadKey, ProviderKey)
+	}
+	if params.TriggerPattern != "" || params.PushBranch != "" || params.PRSourceBranch != "" || params.PRTargetBranch != "" || params.Tag != "" {
+		return RunAndTriggerParamsModel{}, nil, errors.New("both event payload and trigger params specified")
+	}
+
+	webhookProvider, err := webhook.ParseProvider(provider)
+	if err != nil {
+		return RunAndTriggerParamsModel{}, nil, err
+	}
+
+	payload, err := fileutil.ReadBytesFromFile(payloadPath)
+	if err != nil {
+		return RunAndTriggerParamsModel{}, nil, err
+	}
+
+	event, err := webhook.Parse(webhookProvider, "", payload)
+	if err != nil {
+		return RunAndTriggerParamsModel{}, nil, fmt.Errorf("failed to parse event payload (%s): %s", payloadPath, err)
+	}
+
+	return paramsWithWebhookEvent(params, event), &event, nil
+}
+
+// paramsWithWebhookEvent returns the params with the trigger params of the webhook event.
+func paramsWithWebhookEvent(params RunAndTriggerParamsModel, event webhook.Event) RunAndTriggerParamsModel {
+	params.PushBranch = event.PushBranch
+	params.PRSourceBranch = event.PRSourceBranch
+	params.PRTargetBranch = event.PRTargetBranch
+	params.Tag = event.Tag
+	params.CommitMessage = event.CommitMessage
+	params.PRLabels = event.PRLabels
+	params.IsDraftPR = event.IsDraftPR
+	if event.ChangedFiles != nil {
+		params.ChangedFiles = event.ChangedFiles
+		params.ChangedFilesDiff = ""
+	}
+	return params
+}
+
+// exportWebhookEventEnvs exports the standard git related build envs of the webhook event.
+func exportWebhookEventEnvs(event webhook.Event) error {
+	envs := event.Envs()
+
+	keys := make([]string, 0, len(envs))
+	for key := range envs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		log.Debugf("Exporting %s=%s", key, envs[key])
+		if err := os.Setenv(key, envs[key]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/go-utils/command"
+	"github.com/stretchr/testify/require"
+	"github.com/tothszabi/bitrise-test/models"
+)
+
+func TestOverrideChangedFilesParams(t *testing.T) {
+	t.Log("json params are kept if no cli params specified")
+	{
+		params, err := parseRunAndTriggerJSONParams(`{"push-branch":"master","changed-files":["ios/Podfile","README.md"]}`)
+		require.NoError(t, err)
+
+		params, err = overrideChangedFilesParams(params, nil, "")
+		require.NoError(t, err)
+		require.Equal(t, []string{"ios/Podfile", "README.md"}, params.ChangedFiles)
+		require.Equal(t, "", params.ChangedFilesDiff)
+	}
+
+	t.Log("cli params override the json params")
+	{
+		params := RunAndTriggerParamsModel{ChangedFiles: []string{"ios/Podfile"}}
+
+		params, err := overrideChangedFilesParams(params, nil, "origin/master...HEAD")
+		require.NoError(t, err)
+		require.Nil(t, params.ChangedFiles)
+		require.Equal(t, "origin/master...HEAD", params.ChangedFilesDiff)
+
+		params, err = overrideChangedFilesParams(params, []string{"main.go"}, "")
+		require.NoError(t, err)
+		require.Equal(t, []string{"main.go"}, params.ChangedFiles)
+		require.Equal(t, "", params.ChangedFilesDiff)
+	}
+
+	t.Log("both cli params specified")
+	{
+		_, err := overrideChangedFilesParams(RunAndTriggerParamsModel{}, []string{"main.go"}, "HEAD~1..HEAD")
+		require.EqualError(t, err, "both changed-file and changed-files-diff specified")
+	}
+}
+
+func TestChangedFilesFromGitDiff(t *testing.T) {
+	dir, err := ioutil.TempDir("", "changed-files")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+
+	git := func(args ...string) {
+		args = append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)
+		out, err := command.New("git", args...).SetDir(dir).RunAndReturnTrimmedCombinedOutput()
+		require.NoError(t, err, out)
+	}
+	writeFile := func(pth string) {
+		pth = filepath.Join(dir, pth)
+		require.NoError(t, os.MkdirAll(filepath.Dir(pth), 0755))
+		require.NoError(t, ioutil.WriteFile(pth, []byte(pth), 0644))
+	}
+
+	git("init")
+	writeFile("README.md")
+	git("add", "-A")
+	git("commit", "-m", "initial")
+	writeFile("ios/Podfile")
+	writeFile("docs/index.md")
+	git("add", "-A")
+	git("commit", "-m", "ios")
+
+	changedFiles, err := changedFilesFromGitDiff(dir, "HEAD~1..HEAD")
+	require.NoError(t, err)
+	require.Equal(t, []string{"docs/index.md", "ios/Podfile"}, changedFiles)
+
+	_, err = changedFilesFromGitDiff(dir, "unknown..HEAD")
+	require.Error(t, err)
+}
+
+func TestGetPipelineAndWorkflowIDByParamsChangedFiles(t *testing.T) {
+	triggerMap := models.TriggerMapModel{
+		{PushBranch: "master", ChangedFiles: &models.ChangedFilesFilterModel{Include: []string{"ios/*"}}, WorkflowID: "ios"},
+		{PushBranch: "master", ChangedFiles: &models.ChangedFilesFilterModel{Exclude: []string{"docs/*"}}, WorkflowID: "primary"},
+	}
+
+	t.Log("first item matches")
+	{
+		_, workflowID, err := getPipelineAndWorkflowIDByParams(triggerMap, RunAndTriggerParamsModel{PushBranch: "master", ChangedFiles: []string{"ios/Podfile"}})
+		require.NoError(t, err)
+		require.Equal(t, "ios", workflowID)
+	}
+
+	t.Log("second item matches")
+	{
+		_, workflowID, err := getPipelineAndWorkflowIDByParams(triggerMap, RunAndTriggerParamsModel{PushBranch: "master", ChangedFiles: []string{"main.go"}})
+		require.NoError(t, err)
+		require.Equal(t, "primary", workflowID)
+	}
+
+	t.Log("no item matches")
+	{
+		_, _, err := getPipelineAndWorkflowIDByParams(triggerMap, RunAndTriggerParamsModel{PushBranch: "master", ChangedFiles: []string{"docs/index.md"}})
+		require.Error(t, err)
+	}
+
+	t.Log("unknown changed files")
+	{
+		_, workflowID, err := getPipelineAndWorkflowIDByParams(triggerMap, RunAndTriggerParamsModel{PushBranch: "master"})
+		require.NoError(t, err)
+		require.Equal(t, "ios", workflowID)
+		require.Equal(t, "trigger_map[0] (push_branch: master changed_files: include: [ios/*] -> workflow: ios) matches without evaluating its changed_files condition(s), as the related event properties are unknown",
+			unevaluatedTriggerConditionsWarning(triggerMap, RunAndTriggerParamsModel{PushBranch: "master"}, false))
+		require.Equal(t, "", unevaluatedTriggerConditionsWarning(triggerMap, RunAndTriggerParamsModel{PushBranch: "master", ChangedFiles: []string{"ios/Podfile"}}, false))
+	}
+}
+
+func TestOverrideCommitAndPRParams(t *testing.T) {
+	t.Log("json params are kept if no cli params specified")
+	{
+		params, err := parseRunAndTriggerJSONParams(`{"pr-source-branch":"feature","commit-message":"Fix [deploy]","pr-labels":["ci"],"pr-draft":true}`)
+		require.NoError(t, err)
+
+		params = overrideCommitAndPRParams(params, "", nil, false)
+		require.Equal(t, "Fix [deploy]", params.CommitMessage)
+		require.Equal(t, []string{"ci"}, params.PRLabels)
+		require.True(t, params.IsDraftPR)
+	}
+
+	t.Log("cli params override the json params")
+	{
+		params := RunAndTriggerParamsModel{CommitMessage: "Fix", PRLabels: []string{"ci"}}
+
+		params = overrideCommitAndPRParams(params, "Fix [skip ci]", []string{"bug"}, true)
+		require.Equal(t, "Fix [skip ci]", params.CommitMessage)
+		require.Equal(t, []string{"bug"}, params.PRLabels)
+		require.True(t, params.IsDraftPR)
+	}
+}
+
+func TestOverrideEventPayloadParams(t *testing.T) {
+	dir, err := ioutil.TempDir("", "event-payload")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+
+	payloadPath := filepath.Join(dir, "payload.json")
+	require.NoError(t, ioutil.WriteFile(payloadPath, []byte(`{
+  "ref": "refs/heads/master",
+  "after": "a1",
+  "head_commit": {"message": "Fix [skip ci]"},
+  "commits": [{"added": ["ios/Podfile"], "removed": [], "modified": []}]
+}`), 0644))
+
+	t.Log("no payload")
+	{
+		params, event, err := overrideEventPayloadParams(RunAndTriggerParamsModel{PushBranch: "develop"}, "", "")
+		require.NoError(t, err)
+		require.Nil(t, event)
+		require.Equal(t, RunAndTriggerParamsModel{PushBranch: "develop"}, params)
+	}
+
+	t.Log("github push payload")
+	{
+		params, event, err := overrideEventPayloadParams(RunAndTriggerParamsModel{ChangedFilesDiff: "HEAD~1..HEAD"}, payloadPath, "github")
+		require.NoError(t, err)
+		require.NotNil(t, event)
+		require.Equal(t, RunAndTriggerParamsModel{
+			PushBranch:    "master",
+			ChangedFiles:  []string{"ios/Podfile"},
+			CommitMessage: "Fix [skip ci]",
+		}, params)
+	}
+
+	t.Log("missing provider")
+	{
+		_, _, err := overrideEventPayloadParams(RunAndTriggerParamsModel{}, payloadPath, "")
+		require.EqualError(t, err, "event-payload specified without provider")
+	}
+
+	t.Log("both payload and trigger params")
+	{
+		_, _, err := overrideEventPayloadParams(RunAndTriggerParamsModel{Tag: "1.0.0"}, payloadPath, "github")
+		require.EqualError(t, err, "both event payload and trigger params specified")
+	}
+}
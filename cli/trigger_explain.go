@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/bitrise-io/go-utils/colorstring"
+	"github.com/tothszabi/bitrise-test/log"
+	"github.com/tothszabi/bitrise-test/models"
+	"github.com/tothszabi/bitrise-test/output"
+)
+
+const explainKey = "explain"
+
+func triggerTarget(pipelineID, workflowID string) string {
+	if pipelineID != "" {
+		return "pipeline: " + pipelineID
+	}
+	return "workflow: " + workflowID
+}
+
+// explainTriggerCheck prints how the trigger map items were matched against the trigger params,
+// exits with 1 if none of the items matched.
+func explainTriggerCheck(triggerMap models.TriggerMapModel, params RunAndTriggerParamsModel, isPRMode bool, warnings []string) {
+	if params.TriggerPattern != "" {
+		params = migratePatternToParams(params, isPRMode)
+	}
+
+	event, err := triggerEventByParams(params)
+	if err != nil {
+		registerFatal(err.Error(), warnings, params.Format)
+	}
+
+	explanation, err := triggerMap.Explain(event)
+	if err != nil {
+		registerFatal(err.Error(), warnings, params.Format)
+	}
+	explanation.Warnings = append(warnings, explanation.Warnings...)
+
+	switch params.Format {
+	case output.FormatRaw:
+		printTriggerMapExplanation(explanation)
+	case output.FormatJSON:
+		bytes, err := json.Marshal(explanation)
+		if err != nil {
+			registerFatal(fmt.Sprintf("Failed to parse trigger explanation, err: %s", err), warnings, params.Format)
+		}
+		log.Print(string(bytes))
+	default:
+		registerFatal(fmt.Sprintf("Invalid format: %s", params.Format), warnings, output.FormatJSON)
+	}
+
+	if explanation.FirstMatch() == nil {
+		os.Exit(1)
+	}
+}
+
+func printTriggerMapExplanation(explanation models.TriggerMapExplanation) {
+	log.Infof("Trigger event (%s): %s", explanation.EventType, explanation.Event)
+	log.Print()
+
+	for _, item := range explanation.Items {
+		result := colorstring.Red("no match")
+		if item.FirstMatch {
+			result = colorstring.Green("first match")
+		} else if item.Match {
+			result = colorstring.Yellow("match, but an earlier item matched first")
+		}
+		log.Printf("%d. %s -> %s (%s): %s", item.Index, item.Item, triggerTarget(item.PipelineID, item.WorkflowID), item.EventType, result)
+
+		for _, condition := range item.Conditions {
+			switch {
+			case condition.Skipped:
+				log.Printf("   - %s: %s, not evaluated: unknown event property", condition.Field, condition.Pattern)
+			case condition.Match:
+				log.Printf("   %s %s: %s matches %s", colorstring.Green("✓"), condition.Field, condition.Pattern, condition.Value)
+			default:
+				log.Printf("   %s %s: %s does not match %s", colorstring.Red("✗"), condition.Field, condition.Pattern, condition.Value)
+			}
+		}
+	}
+	log.Print()
+
+	for _, warning := range explanation.Warnings {
+		log.Warnf("warning: %s", warning)
+	}
+
+	if firstMatch := explanation.FirstMatch(); firstMatch != nil {
+		log.Donef("trigger_map[%d] triggers %s", firstMatch.Index, triggerTarget(firstMatch.PipelineID, firstMatch.WorkflowID))
+	} else {
+		log.Errorf("No matching pipeline & workflow found")
+	}
+}
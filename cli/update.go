@@ -45,7 +45,7 @@ var updateCommand = cli.Command{
 }
 
 func checkUpdate() error {
-	if configs.IsCIMode {
+	if configs.IsCIMode || configs.IsOfflineMode {
 		return nil
 	}
 	if configs.CheckIsCLIUpdateCheckRequired() {
@@ -216,6 +216,10 @@ func download(version string) error {
 }
 
 func update(c *cli.Context) error {
+	if err := configs.CheckNetworkAccess("updating the CLI"); err != nil {
+		return err
+	}
+
 	log.Infof("Updating Bitrise CLI...")
 
 	versionFlag := c.String("version")
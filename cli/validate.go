@@ -2,20 +2,39 @@ package cli
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
 
 	"github.com/bitrise-io/go-utils/colorstring"
+	"github.com/tothszabi/bitrise-test/models"
 	"github.com/tothszabi/bitrise-test/output"
 	"github.com/urfave/cli"
 )
 
+// ValidationIssueLevel ...
+type ValidationIssueLevel string
+
+const (
+	// ValidationIssueLevelError ...
+	ValidationIssueLevelError ValidationIssueLevel = "error"
+	// ValidationIssueLevelWarning ...
+	ValidationIssueLevelWarning ValidationIssueLevel = "warning"
+)
+
+// ValidationIssueModel is a validation error or warning with its position (file, line and column) in the config.
+type ValidationIssueModel struct {
+	Level                  ValidationIssueLevel `json:"level" yaml:"level"`
+	models.ValidationIssue `yaml:",inline"`
+}
+
 // ValidationItemModel ...
 type ValidationItemModel struct {
-	IsValid  bool     `json:"is_valid" yaml:"is_valid"`
-	Error    string   `json:"error,omitempty" yaml:"error,omitempty"`
-	Warnings []string `json:"warnings,omitempty" yaml:"warnings,omitempty"`
+	IsValid  bool                   `json:"is_valid" yaml:"is_valid"`
+	Error    string                 `json:"error,omitempty" yaml:"error,omitempty"`
+	Warnings []string               `json:"warnings,omitempty" yaml:"warnings,omitempty"`
+	Issues   []ValidationIssueModel `json:"issues,omitempty" yaml:"issues,omitempty"`
 }
 
 // ValidationModel ...
@@ -115,12 +134,12 @@ func (v ValidationModel) String() string {
 			msg += fmt.Sprintf("Config is valid: %s", colorstring.Greenf("%v", true))
 		} else {
 			msg += fmt.Sprintf("Config is valid: %s", colorstring.Redf("%v", false))
-			msg += fmt.Sprintf("\nError: %s", colorstring.Red(config.Error))
+			msg += fmt.Sprintf("\nError: %s", colorstring.Red(config.errorWithPosition()))
 		}
 
 		if len(config.Warnings) > 0 {
 			msg += "\nWarning(s):\n"
-			for i, warning := range config.Warnings {
+			for i, warning := range config.warningsWithPosition() {
 				msg += fmt.Sprintf("- %s", warning)
 				if i != len(config.Warnings)-1 {
 					msg += "\n"
@@ -146,7 +165,38 @@ func (v ValidationModel) String() string {
 	return msg
 }
 
-func validateBitriseYML(bitriseConfigPath string, bitriseConfigBase64Data string) (*ValidationItemModel, error) {
+func (v ValidationItemModel) errorWithPosition() string {
+	for _, issue := range v.Issues {
+		if issue.Level == ValidationIssueLevelError && issue.SourcePosition.String() != "" {
+			return fmt.Sprintf("%s: %s", issue.SourcePosition, v.Error)
+		}
+	}
+	return v.Error
+}
+
+func (v ValidationItemModel) warningsWithPosition() []string {
+	if len(v.Issues) == 0 {
+		return v.Warnings
+	}
+
+	var warnings []string
+	for _, issue := range v.Issues {
+		if issue.Level == ValidationIssueLevelWarning {
+			warnings = append(warnings, issue.String())
+		}
+	}
+	return warnings
+}
+
+// addWarnings adds warnings without source position (like the step input warnings) to the warnings and the issues.
+func (v *ValidationItemModel) addWarnings(warnings []string) {
+	for _, warning := range warnings {
+		v.Warnings = append(v.Warnings, warning)
+		v.Issues = append(v.Issues, ValidationIssueModel{Level: ValidationIssueLevelWarning, ValidationIssue: models.ValidationIssue{Message: warning}})
+	}
+}
+
+func validateBitriseYML(bitriseConfigPath string, bitriseConfigBase64Data string, isStepInputsValidation bool) (*ValidationItemModel, error) {
 	pth, err := GetBitriseConfigFilePath(bitriseConfigPath)
 	if err != nil && !strings.Contains(err.Error(), "bitrise.yml path not defined and not found on it's default path:") {
 		return nil, fmt.Errorf("Failed to get config path, err: %s", err)
@@ -154,14 +204,29 @@ func validateBitriseYML(bitriseConfigPath string, bitriseConfigBase64Data string
 
 	if pth != "" || (pth == "" && bitriseConfigBase64Data != "") {
 		// Config validation
-		_, warns, err := CreateBitriseConfigFromCLIParams(bitriseConfigBase64Data, bitriseConfigPath)
+		config, warns, err := createBitriseConfigWithIssuesFromCLIParams(bitriseConfigBase64Data, bitriseConfigPath)
 		configValidation := ValidationItemModel{
 			IsValid:  true,
-			Warnings: warns,
+			Warnings: models.ValidationIssueMessages(warns),
+		}
+		for _, warning := range warns {
+			configValidation.Issues = append(configValidation.Issues, ValidationIssueModel{Level: ValidationIssueLevelWarning, ValidationIssue: warning})
 		}
 		if err != nil {
 			configValidation.IsValid = false
 			configValidation.Error = err.Error()
+
+			var issue models.ValidationIssue
+			if errors.As(err, &issue) {
+				configValidation.Issues = append(configValidation.Issues, ValidationIssueModel{Level: ValidationIssueLevelError, ValidationIssue: issue})
+			}
+		} else if isStepInputsValidation {
+			inputWarnings, err := validateWorkflowsStepInputs(config, allWorkflowIDs(config), defaultStepSpecProvider)
+			configValidation.addWarnings(inputWarnings)
+			if err != nil {
+				configValidation.IsValid = false
+				configValidation.Error = err.Error()
+			}
 		}
 
 		return &configValidation, nil
@@ -193,7 +258,7 @@ func validateInventory(inventoryPath string, inventoryBase64Data string) (*Valid
 	return nil, nil
 }
 
-func runValidate(bitriseConfigPath string, deprecatedBitriseConfigPath string, bitriseConfigBase64Data string, inventoryPath string, inventoryBase64Data string) (*ValidationModel, []string, error) {
+func runValidate(bitriseConfigPath string, deprecatedBitriseConfigPath string, bitriseConfigBase64Data string, inventoryPath string, inventoryBase64Data string, isStepInputsValidation bool) (*ValidationModel, []string, error) {
 	warnings := []string{}
 
 	if bitriseConfigPath == "" && deprecatedBitriseConfigPath != "" {
@@ -203,7 +268,7 @@ func runValidate(bitriseConfigPath string, deprecatedBitriseConfigPath string, b
 
 	validation := ValidationModel{}
 
-	result, err := validateBitriseYML(bitriseConfigPath, bitriseConfigBase64Data)
+	result, err := validateBitriseYML(bitriseConfigPath, bitriseConfigBase64Data, isStepInputsValidation)
 	validation.Config = result
 	if err != nil {
 		return &validation, warnings, err
@@ -231,6 +296,8 @@ func validate(c *cli.Context) error {
 	inventoryBase64Data := c.String(InventoryBase64Key)
 	inventoryPath := c.String(InventoryKey)
 
+	isStepInputsValidation := c.Bool(StepInputsKey)
+
 	format := c.String(OuputFormatKey)
 	if format == "" {
 		format = output.FormatRaw
@@ -247,7 +314,7 @@ func validate(c *cli.Context) error {
 		os.Exit(1)
 	}
 
-	validation, warnings, err := runValidate(bitriseConfigPath, deprecatedBitriseConfigPath, bitriseConfigBase64Data, inventoryPath, inventoryBase64Data)
+	validation, warnings, err := runValidate(bitriseConfigPath, deprecatedBitriseConfigPath, bitriseConfigBase64Data, inventoryPath, inventoryBase64Data, isStepInputsValidation)
 	if err != nil {
 		log.Print(NewValidationError(err.Error(), warnings...))
 		os.Exit(1)
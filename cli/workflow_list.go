@@ -9,6 +9,7 @@ import (
 
 	"github.com/bitrise-io/go-utils/colorstring"
 	"github.com/tothszabi/bitrise-test/log"
+	"github.com/tothszabi/bitrise-test/models"
 	"github.com/tothszabi/bitrise-test/output"
 	"github.com/urfave/cli"
 )
@@ -44,9 +45,11 @@ var workflowListCommand = cli.Command{
 
 // WorkflowListOutputModel ...
 type WorkflowListOutputModel struct {
-	Data     map[string]map[string]string `json:"data,omitempty" yml:"data,omitempty"`
-	Warnings []string                     `json:"warnings,omitempty" yml:"warnings,omitempty"`
-	Error    string                       `json:"error,omitempty" yml:"error,omitempty"`
+	Data                  map[string]map[string]string       `json:"data,omitempty" yml:"data,omitempty"`
+	Workflows             map[string]models.WorkflowOverview `json:"workflows,omitempty" yml:"workflows,omitempty"`
+	UnreferencedWorkflows []string                           `json:"unreferenced_workflows,omitempty" yml:"unreferenced_workflows,omitempty"`
+	Warnings              []string                           `json:"warnings,omitempty" yml:"warnings,omitempty"`
+	Error                 string                             `json:"error,omitempty" yml:"error,omitempty"`
 }
 
 // NewOutput ...
@@ -65,7 +68,7 @@ func NewErrorOutput(err string, warnings ...string) WorkflowListOutputModel {
 	}
 }
 
-func printableRawWorkflow(id string, info map[string]string) string {
+func printableRawWorkflow(id string, info map[string]string, overview *models.WorkflowOverview) string {
 	message := ""
 	message += fmt.Sprintf("⚡️ %s\n", colorstring.Green(id))
 	message += fmt.Sprintf("  %s: %s\n", colorstring.Yellow("Title"), info["title"])
@@ -74,6 +77,18 @@ func printableRawWorkflow(id string, info map[string]string) string {
 	if info["description"] != "" {
 		message += fmt.Sprintf("  %s: %s\n", colorstring.Yellow("Description"), info["description"])
 	}
+	if overview != nil {
+		message += fmt.Sprintf("  %s: %d (%d with before_run and after_run)\n", colorstring.Yellow("Steps"), overview.StepCount, overview.ChainStepCount)
+		if len(overview.RunChain) > 1 {
+			message += fmt.Sprintf("  %s: %s\n", colorstring.Yellow("Run chain"), strings.Join(overview.RunChain, " -> "))
+		}
+		if len(overview.Pipelines) > 0 {
+			message += fmt.Sprintf("  %s: %s\n", colorstring.Yellow("Pipelines"), strings.Join(overview.Pipelines, ", "))
+		}
+		for _, trigger := range overview.TriggeredBy {
+			message += fmt.Sprintf("  %s: %s\n", colorstring.Yellow("Triggered by"), trigger)
+		}
+	}
 	message += fmt.Sprintf("  %s: bitrise run %s\n", colorstring.Yellow("Run with"), id)
 	message += "\n"
 	return message
@@ -122,7 +137,7 @@ func (output WorkflowListOutputModel) String() string {
 		message += "---------\n"
 		for _, id := range workflowIDs {
 			workflow := output.Data[id]
-			message += printableRawWorkflow(id, workflow)
+			message += printableRawWorkflow(id, workflow, output.workflowOverview(id))
 		}
 	}
 
@@ -131,8 +146,18 @@ func (output WorkflowListOutputModel) String() string {
 		message += "--------------\n"
 		for _, id := range utilityWorkflowIDs {
 			workflow := output.Data[id]
-			message += printableRawWorkflow(id, workflow)
+			message += printableRawWorkflow(id, workflow, output.workflowOverview(id))
+		}
+	}
+
+	if len(output.UnreferencedWorkflows) > 0 {
+		message += "Unreferenced Workflows\n"
+		message += "----------------------\n"
+		message += "Not referenced by any trigger map item, pipeline, before_run or after_run:\n"
+		for _, id := range output.UnreferencedWorkflows {
+			message += fmt.Sprintf("  * %s\n", colorstring.Yellow(id))
 		}
+		message += "\n"
 	}
 
 	if len(workflowIDs) == 0 && len(utilityWorkflowIDs) == 0 {
@@ -142,6 +167,14 @@ func (output WorkflowListOutputModel) String() string {
 	return message
 }
 
+func (output WorkflowListOutputModel) workflowOverview(id string) *models.WorkflowOverview {
+	overview, ok := output.Workflows[id]
+	if !ok {
+		return nil
+	}
+	return &overview
+}
+
 // JSON ...
 func (output WorkflowListOutputModel) JSON() string {
 	workflowIDs := []string{}
@@ -230,7 +263,14 @@ func workflowList(c *cli.Context) error {
 			workflowInfoMap[workflowID] = workflowInfo
 		}
 
-		logger.Print(NewOutput(workflowInfoMap, warnings...))
+		workflowListOutput := NewOutput(workflowInfoMap, warnings...)
+		if !idOnly && !minimal {
+			overview := bitriseConfig.Overview()
+			workflowListOutput.Workflows = overview.Workflows
+			workflowListOutput.UnreferencedWorkflows = overview.UnreferencedWorkflows
+		}
+
+		logger.Print(workflowListOutput)
 	}
 
 	return nil
@@ -0,0 +1,315 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	envmanModels "github.com/bitrise-io/envman/models"
+	"github.com/bitrise-io/go-utils/command"
+	"github.com/tothszabi/bitrise-test/configs"
+	"github.com/tothszabi/bitrise-test/log"
+	"github.com/tothszabi/bitrise-test/models"
+	"github.com/tothszabi/bitrise-test/tools"
+)
+
+const (
+	defaultServiceHealthCheckTimeout = 60 * time.Second
+	serviceHealthCheckInterval       = time.Second
+
+	// serviceHost is the loopback address, where the service ports are published
+	serviceHost = "127.0.0.1"
+)
+
+// workflowService is a started service container.
+type workflowService struct {
+	name          string
+	engine        string
+	containerName string
+	// networked is true if the service shares the per-build network with the step containers
+	networked bool
+}
+
+// startWorkflowServices starts the workflow's service containers and waits until they are ready.
+// If the workflow's steps run in a container, the services and the step containers share a per-build network,
+// the returned network is the name of this network (empty if the services are reached on the loopback address).
+// The returned stop function removes the started containers, it has to be called even if starting the services failed.
+func startWorkflowServices(executionID string, workflow models.WorkflowModel, environments []envmanModels.EnvironmentItemModel) ([]envmanModels.EnvironmentItemModel, string, func(), error) {
+	var started []workflowService
+	network := ""
+	networkEngine := ""
+	stop := func() {
+		for idx := len(started) - 1; idx >= 0; idx-- {
+			service := started[idx]
+			log.Printf("Removing service: %s", service.name)
+			if out, err := command.New(service.engine, "rm", "-f", "-v", service.containerName).RunAndReturnTrimmedCombinedOutput(); err != nil {
+				log.Warnf("Failed to remove service (%s) container: %s, output: %s", service.name, err, out)
+			}
+		}
+		if network != "" {
+			if out, err := command.New(networkEngine, "network", "rm", network).RunAndReturnTrimmedCombinedOutput(); err != nil {
+				log.Warnf("Failed to remove service network (%s): %s, output: %s", network, err, out)
+			}
+		}
+	}
+
+	if len(workflow.Services) == 0 {
+		return nil, "", stop, nil
+	}
+
+	if workflow.Container != nil && !hasContainerNetworkOption(workflow.Container.Options) {
+		engine := workflow.Container.Engine
+		if engine == "" {
+			engine = models.ContainerEngineDocker
+		}
+		for name, service := range workflow.Services {
+			if serviceEngine(service, workflow.Container) != engine {
+				return nil, "", stop, fmt.Errorf("service (%s) engine differs from the workflow container's engine (%s), they can not share a network", name, engine)
+			}
+		}
+
+		name := serviceNetworkName(executionID)
+		if out, err := command.New(engine, "network", "create", name).RunAndReturnTrimmedCombinedOutput(); err != nil {
+			return nil, "", stop, fmt.Errorf("failed to create service network: %s, output: %s", err, out)
+		}
+		network, networkEngine = name, engine
+	}
+
+	expandedEnvs, err := tools.ExpandEnvItems(environments, os.Environ())
+	if err != nil {
+		return nil, "", stop, fmt.Errorf("failed to expand envs: %s", err)
+	}
+	var externalEnvs []string
+	for key, value := range expandedEnvs {
+		externalEnvs = append(externalEnvs, key+"="+value)
+	}
+	externalEnvs = append(os.Environ(), externalEnvs...)
+
+	var names []string
+	for name := range workflow.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var serviceEnvs []envmanModels.EnvironmentItemModel
+	for _, name := range names {
+		service := workflow.Services[name]
+		started = append(started, workflowService{
+			name:          name,
+			engine:        serviceEngine(service, workflow.Container),
+			containerName: serviceContainerName(name, executionID),
+			networked:     network != "",
+		})
+		current := started[len(started)-1]
+
+		log.Infof("Starting service: %s (%s)", name, service.Image)
+
+		envs, err := tools.ExpandEnvItems(service.Envs, externalEnvs)
+		if err != nil {
+			return nil, "", stop, fmt.Errorf("failed to expand service (%s) envs: %s", name, err)
+		}
+		var envKeys []string
+		var cmdEnvs []string
+		for key, value := range envs {
+			envKeys = append(envKeys, key)
+			cmdEnvs = append(cmdEnvs, key+"="+value)
+		}
+
+		args, err := serviceRunCommandArguments(current.containerName, service, envKeys, network)
+		if err != nil {
+			return nil, "", stop, fmt.Errorf("failed to start service (%s): %s", name, err)
+		}
+		cmd := command.New(current.engine, args...).SetEnvs(append(os.Environ(), cmdEnvs...)...)
+		log.Debugf("$ %s", cmd.PrintableCommandArgs())
+		if out, err := cmd.RunAndReturnTrimmedCombinedOutput(); err != nil {
+			return nil, "", stop, fmt.Errorf("failed to start service (%s): %s, output: %s", name, err, out)
+		}
+
+		if err := waitForService(current, service); err != nil {
+			logs, _ := command.New(current.engine, "logs", "--tail", "20", current.containerName).RunAndReturnTrimmedCombinedOutput()
+			if logs != "" {
+				log.Printf("Service (%s) logs:\n%s", name, logs)
+			}
+			return nil, "", stop, fmt.Errorf("service (%s) is not ready: %s", name, err)
+		}
+		log.Donef("Service ready: %s", name)
+
+		envsOfService, err := serviceEnvironments(name, current.containerName, service, network != "")
+		if err != nil {
+			return nil, "", stop, err
+		}
+		serviceEnvs = append(serviceEnvs, envsOfService...)
+	}
+
+	return serviceEnvs, network, stop, nil
+}
+
+// waitForService waits until the service's health check command succeeds in the service container,
+// or if no health check is defined, until its published ports accept connections.
+func waitForService(started workflowService, service models.ServiceModel) error {
+	timeout := defaultServiceHealthCheckTimeout
+	if service.HealthCheck != nil && service.HealthCheck.Timeout > 0 {
+		timeout = time.Duration(service.HealthCheck.Timeout) * time.Second
+	}
+
+	isReady := func() (bool, error) {
+		running, err := command.New(started.engine, "inspect", "-f", "{{.State.Running}}", started.containerName).RunAndReturnTrimmedCombinedOutput()
+		if err != nil {
+			return false, fmt.Errorf("failed to inspect container: %s, output: %s", err, running)
+		}
+		if running != "true" {
+			return false, errors.New("container exited")
+		}
+
+		if service.HealthCheck != nil {
+			return command.New(started.engine, "exec", started.containerName, "sh", "-c", service.HealthCheck.Command).Run() == nil, nil
+		}
+
+		for _, port := range service.Ports {
+			hostPort, containerPort, err := models.ParseServicePort(port)
+			if err != nil {
+				return false, err
+			}
+			address := net.JoinHostPort(serviceHost, strconv.Itoa(hostPort))
+			if started.networked {
+				// the networked services' ports are published on an ephemeral loopback port, only for this check
+				out, err := command.New(started.engine, "port", started.containerName, fmt.Sprintf("%d/tcp", containerPort)).RunAndReturnTrimmedCombinedOutput()
+				if err != nil {
+					return false, nil
+				}
+				address = strings.TrimSpace(strings.Split(out, "\n")[0])
+			}
+			conn, err := net.DialTimeout("tcp", address, serviceHealthCheckInterval)
+			if err != nil {
+				return false, nil
+			}
+			if err := conn.Close(); err != nil {
+				log.Debugf("Failed to close connection: %s", err)
+			}
+		}
+		return true, nil
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		ready, err := isReady()
+		if err != nil {
+			return err
+		}
+		if ready {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("health check timed out after %s", timeout)
+		}
+		time.Sleep(serviceHealthCheckInterval)
+	}
+}
+
+func serviceEngine(service models.ServiceModel, container *models.ContainerModel) string {
+	if service.Engine != "" {
+		return service.Engine
+	}
+	if container != nil && container.Engine != "" {
+		return container.Engine
+	}
+	return models.ContainerEngineDocker
+}
+
+func serviceContainerName(name, executionID string) string {
+	return "bitrise-" + name + "-" + executionID
+}
+
+func serviceNetworkName(executionID string) string {
+	return "bitrise-services-" + executionID
+}
+
+// hasContainerNetworkOption returns true if the container options select a network (like --network=host).
+func hasContainerNetworkOption(options []string) bool {
+	for _, option := range options {
+		if option == "--network" || option == "--net" || strings.HasPrefix(option, "--network=") || strings.HasPrefix(option, "--net=") {
+			return true
+		}
+	}
+	return false
+}
+
+// containerWithNetwork returns a copy of the container, which is connected to the network.
+func containerWithNetwork(container models.ContainerModel, network string) models.ContainerModel {
+	container.Options = append([]string{"--network", network}, container.Options...)
+	return container
+}
+
+// serviceRunCommandArguments returns the container engine arguments, which start the service container in the background,
+// connected to the network if it is not empty.
+// The envs are passed by key, the container engine reads their values from its own environment.
+func serviceRunCommandArguments(containerName string, service models.ServiceModel, envKeys []string, network string) ([]string, error) {
+	args := []string{"run", "-d", "--name", containerName}
+	if configs.IsOfflineMode {
+		args = append(args, "--pull=never")
+	}
+	if network != "" {
+		args = append(args, "--network", network)
+	}
+
+	for _, port := range service.Ports {
+		hostPort, containerPort, err := models.ParseServicePort(port)
+		if err != nil {
+			return nil, err
+		}
+		// the ports are published only on the loopback address, not to the host's network
+		switch {
+		case network == "":
+			args = append(args, "-p", fmt.Sprintf("%s:%d:%d", serviceHost, hostPort, containerPort))
+		case service.HealthCheck == nil:
+			// the steps reach the networked service on its container port,
+			// an ephemeral loopback port is published only for the readiness check
+			args = append(args, "-p", fmt.Sprintf("%s::%d", serviceHost, containerPort))
+		}
+	}
+
+	sort.Strings(envKeys)
+	for _, key := range envKeys {
+		args = append(args, "-e", key)
+	}
+
+	args = append(args, service.Options...)
+	return append(args, service.Image), nil
+}
+
+// serviceEnvironments returns the connection envs of the service:
+// BITRISE_SERVICE_<NAME>_HOST, BITRISE_SERVICE_<NAME>_CONTAINER,
+// BITRISE_SERVICE_<NAME>_PORT (the first published host port) and BITRISE_SERVICE_<NAME>_PORT_<CONTAINER_PORT>.
+// If the service shares a network with the step containers, the host is the service container
+// and the ports are the container ports.
+func serviceEnvironments(name, containerName string, service models.ServiceModel, isNetworked bool) ([]envmanModels.EnvironmentItemModel, error) {
+	prefix := "BITRISE_SERVICE_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+
+	host := serviceHost
+	if isNetworked {
+		host = containerName
+	}
+	envs := []envmanModels.EnvironmentItemModel{
+		{prefix + "_HOST": host},
+		{prefix + "_CONTAINER": containerName},
+	}
+	for idx, port := range service.Ports {
+		hostPort, containerPort, err := models.ParseServicePort(port)
+		if err != nil {
+			return nil, err
+		}
+		if isNetworked {
+			hostPort = containerPort
+		}
+		if idx == 0 {
+			envs = append(envs, envmanModels.EnvironmentItemModel{prefix + "_PORT": strconv.Itoa(hostPort)})
+		}
+		envs = append(envs, envmanModels.EnvironmentItemModel{fmt.Sprintf("%s_PORT_%d", prefix, containerPort): strconv.Itoa(hostPort)})
+	}
+	return envs, nil
+}
@@ -0,0 +1,177 @@
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	envmanModels "github.com/bitrise-io/envman/models"
+	"github.com/stretchr/testify/require"
+	"github.com/tothszabi/bitrise-test/models"
+)
+
+func TestServiceRunCommandArguments(t *testing.T) {
+	service := models.ServiceModel{
+		Image:   "postgres:14",
+		Ports:   []string{"15432:5432", "8080"},
+		Options: []string{"--tmpfs", "/var/lib/postgresql/data"},
+	}
+	args, err := serviceRunCommandArguments("bitrise-db-1", service, []string{"POSTGRES_USER", "POSTGRES_PASSWORD"}, "")
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"run", "-d", "--name", "bitrise-db-1",
+		"-p", "127.0.0.1:15432:5432",
+		"-p", "127.0.0.1:8080:8080",
+		"-e", "POSTGRES_PASSWORD",
+		"-e", "POSTGRES_USER",
+		"--tmpfs", "/var/lib/postgresql/data",
+		"postgres:14",
+	}, args)
+
+	_, err = serviceRunCommandArguments("bitrise-db-1", models.ServiceModel{Image: "postgres", Ports: []string{"db"}}, nil, "")
+	require.Error(t, err)
+
+	args, err = serviceRunCommandArguments("bitrise-db-1", models.ServiceModel{Image: "postgres:14"}, nil, "bitrise-services-1")
+	require.NoError(t, err)
+	require.Equal(t, []string{"run", "-d", "--name", "bitrise-db-1", "--network", "bitrise-services-1", "postgres:14"}, args)
+
+	t.Log("networked service ports")
+	{
+		networkedService := models.ServiceModel{Image: "postgres:14", Ports: []string{"15432:5432"}}
+		args, err := serviceRunCommandArguments("bitrise-db-1", networkedService, nil, "bitrise-services-1")
+		require.NoError(t, err)
+		require.Equal(t, []string{"run", "-d", "--name", "bitrise-db-1", "--network", "bitrise-services-1", "-p", "127.0.0.1::5432", "postgres:14"}, args)
+
+		networkedService.HealthCheck = &models.ServiceHealthCheckModel{Command: "pg_isready"}
+		args, err = serviceRunCommandArguments("bitrise-db-1", networkedService, nil, "bitrise-services-1")
+		require.NoError(t, err)
+		require.Equal(t, []string{"run", "-d", "--name", "bitrise-db-1", "--network", "bitrise-services-1", "postgres:14"}, args)
+	}
+}
+
+func TestServiceEnvironments(t *testing.T) {
+	t.Log("ports")
+	{
+		envs, err := serviceEnvironments("mock-server", "bitrise-mock-server-1", models.ServiceModel{Image: "mock", Ports: []string{"18080:80", "443"}}, false)
+		require.NoError(t, err)
+		require.Equal(t, []envmanModels.EnvironmentItemModel{
+			{"BITRISE_SERVICE_MOCK_SERVER_HOST": "127.0.0.1"},
+			{"BITRISE_SERVICE_MOCK_SERVER_CONTAINER": "bitrise-mock-server-1"},
+			{"BITRISE_SERVICE_MOCK_SERVER_PORT": "18080"},
+			{"BITRISE_SERVICE_MOCK_SERVER_PORT_80": "18080"},
+			{"BITRISE_SERVICE_MOCK_SERVER_PORT_443": "443"},
+		}, envs)
+	}
+
+	t.Log("no ports")
+	{
+		envs, err := serviceEnvironments("redis", "bitrise-redis-1", models.ServiceModel{Image: "redis"}, false)
+		require.NoError(t, err)
+		require.Equal(t, []envmanModels.EnvironmentItemModel{
+			{"BITRISE_SERVICE_REDIS_HOST": "127.0.0.1"},
+			{"BITRISE_SERVICE_REDIS_CONTAINER": "bitrise-redis-1"},
+		}, envs)
+	}
+
+	t.Log("shared network with the step containers")
+	{
+		envs, err := serviceEnvironments("mock-server", "bitrise-mock-server-1", models.ServiceModel{Image: "mock", Ports: []string{"18080:80", "443"}}, true)
+		require.NoError(t, err)
+		require.Equal(t, []envmanModels.EnvironmentItemModel{
+			{"BITRISE_SERVICE_MOCK_SERVER_HOST": "bitrise-mock-server-1"},
+			{"BITRISE_SERVICE_MOCK_SERVER_CONTAINER": "bitrise-mock-server-1"},
+			{"BITRISE_SERVICE_MOCK_SERVER_PORT": "80"},
+			{"BITRISE_SERVICE_MOCK_SERVER_PORT_80": "80"},
+			{"BITRISE_SERVICE_MOCK_SERVER_PORT_443": "443"},
+		}, envs)
+	}
+}
+
+func TestServiceEngine(t *testing.T) {
+	require.Equal(t, "docker", serviceEngine(models.ServiceModel{}, nil))
+	require.Equal(t, "podman", serviceEngine(models.ServiceModel{}, &models.ContainerModel{Image: "alpine", Engine: "podman"}))
+	require.Equal(t, "docker", serviceEngine(models.ServiceModel{Engine: "docker"}, &models.ContainerModel{Image: "alpine", Engine: "podman"}))
+}
+
+func TestStartWorkflowServicesWithoutServices(t *testing.T) {
+	envs, network, stop, err := startWorkflowServices("1", models.WorkflowModel{}, nil)
+	require.NoError(t, err)
+	require.Equal(t, 0, len(envs))
+	require.Equal(t, "", network)
+	stop()
+}
+
+func TestStartWorkflowServicesWithContainer(t *testing.T) {
+	binDir, err := ioutil.TempDir("", "services")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(binDir))
+	}()
+
+	// the fake container engine records its calls
+	callsPth := filepath.Join(binDir, "calls")
+	script := "#!/bin/sh\necho \"$@\" >> " + callsPth + "\nif [ \"$1\" = inspect ]; then echo true; fi\n"
+	require.NoError(t, ioutil.WriteFile(filepath.Join(binDir, "docker"), []byte(script), 0755))
+	originalPath := os.Getenv("PATH")
+	require.NoError(t, os.Setenv("PATH", binDir+string(os.PathListSeparator)+originalPath))
+	defer func() {
+		require.NoError(t, os.Setenv("PATH", originalPath))
+	}()
+
+	workflow := models.WorkflowModel{
+		Container: &models.ContainerModel{Image: "golang:1.17"},
+		Services: map[string]models.ServiceModel{
+			"db": {Image: "postgres:14", Ports: []string{"15432:5432"}, HealthCheck: &models.ServiceHealthCheckModel{Command: "pg_isready"}},
+		},
+	}
+
+	t.Log("services and step containers share a network")
+	{
+		envs, network, stop, err := startWorkflowServices("1", workflow, nil)
+		require.NoError(t, err)
+		require.Equal(t, "bitrise-services-1", network)
+		require.Equal(t, []envmanModels.EnvironmentItemModel{
+			{"BITRISE_SERVICE_DB_HOST": "bitrise-db-1"},
+			{"BITRISE_SERVICE_DB_CONTAINER": "bitrise-db-1"},
+			{"BITRISE_SERVICE_DB_PORT": "5432"},
+			{"BITRISE_SERVICE_DB_PORT_5432": "5432"},
+		}, envs)
+
+		container := containerWithNetwork(*workflow.Container, network)
+		require.Equal(t, []string{"--network", "bitrise-services-1"}, container.Options)
+		require.Equal(t, 0, len(workflow.Container.Options))
+
+		stop()
+
+		calls, err := ioutil.ReadFile(callsPth)
+		require.NoError(t, err)
+		require.Equal(t, `network create bitrise-services-1
+run -d --name bitrise-db-1 --network bitrise-services-1 postgres:14
+inspect -f {{.State.Running}} bitrise-db-1
+exec bitrise-db-1 sh -c pg_isready
+rm -f -v bitrise-db-1
+network rm bitrise-services-1
+`, string(calls))
+		require.NoError(t, os.Remove(callsPth))
+	}
+
+	t.Log("container with its own network option")
+	{
+		workflow.Container.Options = []string{"--network=host"}
+		envs, network, stop, err := startWorkflowServices("2", workflow, nil)
+		require.NoError(t, err)
+		require.Equal(t, "", network)
+		require.Equal(t, envmanModels.EnvironmentItemModel{"BITRISE_SERVICE_DB_HOST": "127.0.0.1"}, envs[0])
+		stop()
+	}
+
+	t.Log("service engine differs from the container engine")
+	{
+		workflow.Container.Options = nil
+		workflow.Services["db"] = models.ServiceModel{Image: "postgres:14", Engine: "podman"}
+		_, _, stop, err := startWorkflowServices("3", workflow, nil)
+		require.EqualError(t, err, "service (db) engine differs from the workflow container's engine (docker), they can not share a network")
+		stop()
+	}
+}
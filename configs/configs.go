@@ -28,6 +28,8 @@ var (
 	IsDebugMode = false
 	// IsPullRequestMode ...
 	IsPullRequestMode = false
+	// IsOfflineMode ...
+	IsOfflineMode = false
 
 	// IsSecretFiltering ...
 	IsSecretFiltering = false
@@ -53,6 +55,13 @@ const (
 	IsSecretEnvsFilteringKey = "BITRISE_SECRET_ENVS_FILTERING"
 	// NoOutputTimeoutEnvKey ...
 	NoOutputTimeoutEnvKey = "BITRISE_NO_OUTPUT_TIMEOUT"
+	// OfflineModeEnvKey ...
+	OfflineModeEnvKey = "BITRISE_OFFLINE"
+	// GitMirrorsDirEnvKey is the directory of the local git mirrors, used to activate the git steps.
+	// The mirror of a repository is expected at <dir>/<host>/<repository path>(.git).
+	GitMirrorsDirEnvKey = "BITRISE_GIT_MIRRORS_DIR"
+	// GitStepKeyringEnvKey is the default keyring (exported public keys) used to verify the tag signatures of the git steps.
+	GitStepKeyringEnvKey = "BITRISE_GIT_STEP_KEYRING"
 
 	// --- Debug Options
 
@@ -64,6 +73,14 @@ const (
 	bitriseConfigFileName = "config.json"
 )
 
+// CheckNetworkAccess returns an error if the given network operation is not allowed, because offline mode is enabled.
+func CheckNetworkAccess(operation string) error {
+	if IsOfflineMode {
+		return fmt.Errorf("offline mode: %s requires network access", operation)
+	}
+	return nil
+}
+
 // IsDebugUseSystemTools ...
 func IsDebugUseSystemTools() bool {
 	return os.Getenv(DebugUseSystemTools) == "true"
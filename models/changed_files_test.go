@@ -0,0 +1,113 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestChangedFilesFilterModelMatch(t *testing.T) {
+	t.Log("include patterns")
+	{
+		filter := ChangedFilesFilterModel{Include: []string{"ios/*", "*.swift"}}
+		require.True(t, filter.Match([]string{"README.md", "ios/App/AppDelegate.m"}))
+		require.True(t, filter.Match([]string{"Sources/main.swift"}))
+		require.False(t, filter.Match([]string{"README.md", "android/app/build.gradle"}))
+		require.False(t, filter.Match([]string{}))
+	}
+
+	t.Log("exclude patterns")
+	{
+		filter := ChangedFilesFilterModel{Exclude: []string{"docs/*", "*.md"}}
+		require.True(t, filter.Match([]string{"README.md", "main.go"}))
+		require.False(t, filter.Match([]string{"README.md", "docs/index.html"}))
+	}
+
+	t.Log("include and exclude patterns")
+	{
+		filter := ChangedFilesFilterModel{Include: []string{"ios/*"}, Exclude: []string{"*.md"}}
+		require.True(t, filter.Match([]string{"ios/README.md", "ios/Podfile"}))
+		require.False(t, filter.Match([]string{"ios/README.md", "Podfile"}))
+	}
+}
+
+func TestTriggerMapItemModelMatchChangedFiles(t *testing.T) {
+	item := TriggerMapItemModel{
+		PushBranch:   "master",
+		ChangedFiles: &ChangedFilesFilterModel{Include: []string{"ios/*"}},
+		WorkflowID:   "ios",
+	}
+
+	t.Log("matching changed files")
+	{
+		match, err := item.Match(TriggerEventModel{PushBranch: "master", ChangedFiles: []string{"ios/Podfile"}})
+		require.NoError(t, err)
+		require.True(t, match)
+	}
+
+	t.Log("not matching changed files")
+	{
+		match, err := item.Match(TriggerEventModel{PushBranch: "master", ChangedFiles: []string{"android/build.gradle"}})
+		require.NoError(t, err)
+		require.False(t, match)
+	}
+
+	t.Log("unknown changed files: the filter is not evaluated")
+	{
+		match, err := item.Match(TriggerEventModel{PushBranch: "master"})
+		require.NoError(t, err)
+		require.True(t, match)
+	}
+
+	t.Log("not matching branch")
+	{
+		match, err := item.Match(TriggerEventModel{PushBranch: "develop", ChangedFiles: []string{"ios/Podfile"}})
+		require.NoError(t, err)
+		require.False(t, match)
+	}
+}
+
+func TestTriggerMapItemModelValidateChangedFiles(t *testing.T) {
+	t.Log("valid filter")
+	{
+		item := TriggerMapItemModel{PullRequestTargetBranch: "master", ChangedFiles: &ChangedFilesFilterModel{Exclude: []string{"*.md"}}, WorkflowID: "primary"}
+		require.NoError(t, item.Validate())
+	}
+
+	t.Log("empty filter")
+	{
+		item := TriggerMapItemModel{PushBranch: "master", ChangedFiles: &ChangedFilesFilterModel{}, WorkflowID: "primary"}
+		require.EqualError(t, item.Validate(), "trigger map item (push_branch: master changed_files: {} -> workflow: primary) validate failed, error: invalid changed_files filter: neither include nor exclude pattern defined")
+	}
+
+	t.Log("tag item")
+	{
+		item := TriggerMapItemModel{Tag: "*", ChangedFiles: &ChangedFilesFilterModel{Include: []string{"ios/*"}}, WorkflowID: "primary"}
+		require.EqualError(t, item.Validate(), "trigger map item (tag: * changed_files: include: [ios/*] -> workflow: primary) validate failed, error: changed_files filter is only supported on push_branch and pull request trigger items")
+	}
+
+	t.Log("tag regex item")
+	{
+		item := TriggerMapItemModel{TagRegex: "^v[0-9.]+$", ChangedFiles: &ChangedFilesFilterModel{Include: []string{"ios/*"}}, WorkflowID: "primary"}
+		require.EqualError(t, item.Validate(), "trigger map item (tag_regex: ^v[0-9.]+$ changed_files: include: [ios/*] -> workflow: primary) validate failed, error: changed_files filter is only supported on push_branch and pull request trigger items")
+	}
+
+	t.Log("schedule item")
+	{
+		item := TriggerMapItemModel{Schedule: "0 2 * * *", ChangedFiles: &ChangedFilesFilterModel{Include: []string{"ios/*"}}, WorkflowID: "primary"}
+		require.Error(t, item.Validate())
+	}
+
+	t.Log("same branch with different changed files filters is not a duplicate")
+	{
+		triggerMap := TriggerMapModel{
+			{PushBranch: "master", ChangedFiles: &ChangedFilesFilterModel{Include: []string{"ios/*"}}, WorkflowID: "ios"},
+			{PushBranch: "master", ChangedFiles: &ChangedFilesFilterModel{Include: []string{"android/*"}}, WorkflowID: "android"},
+			{PushBranch: "master", WorkflowID: "primary"},
+		}
+		require.NoError(t, checkDuplicatedTriggerMapItems(triggerMap))
+
+		triggerMap = append(triggerMap, TriggerMapItemModel{PushBranch: "master", ChangedFiles: &ChangedFilesFilterModel{Include: []string{"ios/*"}}, WorkflowID: "primary"})
+		require.EqualError(t, checkDuplicatedTriggerMapItems(triggerMap), "duplicated trigger item found (push_branch: master changed_files: include: [ios/*])")
+	}
+}
@@ -0,0 +1,601 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	envmanModels "github.com/bitrise-io/envman/models"
+	stepmanModels "github.com/bitrise-io/stepman/models"
+)
+
+// ConfigChangeType ...
+type ConfigChangeType string
+
+const (
+	// ConfigChangeTypeAdded ...
+	ConfigChangeTypeAdded ConfigChangeType = "added"
+	// ConfigChangeTypeRemoved ...
+	ConfigChangeTypeRemoved ConfigChangeType = "removed"
+	// ConfigChangeTypeChanged ...
+	ConfigChangeTypeChanged ConfigChangeType = "changed"
+	// ConfigChangeTypeMoved ...
+	ConfigChangeTypeMoved ConfigChangeType = "moved"
+)
+
+const redactedValue = "[REDACTED]"
+
+// secretKeyRegexp matches the env and input keys, whose values are masked in the diff.
+var secretKeyRegexp = regexp.MustCompile(`(?i)(secret|token|passw|pwd|credential|private|api_?key|access_?key|auth)`)
+
+// secretValueRegexp matches the well-known token formats, which are masked in the diff regardless of their key.
+var secretValueRegexp = regexp.MustCompile(`(ghp_|gho_|ghs_|github_pat_|glpat-|xox[abpr]-|AKIA[0-9A-Z]{12}|-----BEGIN)`)
+
+// ConfigChange is a change between two configs, the Path identifies the changed element (like workflows.primary.steps[script].inputs.content),
+// Old and New are the changed values (positions in case of moved elements).
+type ConfigChange struct {
+	Type ConfigChangeType `json:"type" yaml:"type"`
+	Path string           `json:"path" yaml:"path"`
+	Old  string           `json:"old,omitempty" yaml:"old,omitempty"`
+	New  string           `json:"new,omitempty" yaml:"new,omitempty"`
+}
+
+// ConfigDiff ...
+type ConfigDiff struct {
+	Changes []ConfigChange `json:"changes" yaml:"changes"`
+}
+
+// HasChanges ...
+func (diff ConfigDiff) HasChanges() bool {
+	return len(diff.Changes) > 0
+}
+
+func (diff *ConfigDiff) add(changeType ConfigChangeType, path, oldValue, newValue string) {
+	diff.Changes = append(diff.Changes, ConfigChange{Type: changeType, Path: path, Old: oldValue, New: newValue})
+}
+
+func (diff *ConfigDiff) compareValue(path, oldValue, newValue string) {
+	if oldValue == newValue {
+		return
+	}
+	switch {
+	case oldValue == "":
+		diff.add(ConfigChangeTypeAdded, path, "", newValue)
+	case newValue == "":
+		diff.add(ConfigChangeTypeRemoved, path, oldValue, "")
+	default:
+		diff.add(ConfigChangeTypeChanged, path, oldValue, newValue)
+	}
+}
+
+// String returns the human readable diff, one change per line:
+// + added, - removed, ~ changed and > moved elements.
+func (diff ConfigDiff) String() string {
+	if !diff.HasChanges() {
+		return "No changes"
+	}
+
+	var lines []string
+	for _, change := range diff.Changes {
+		switch change.Type {
+		case ConfigChangeTypeAdded:
+			line := "+ " + change.Path
+			if change.New != "" {
+				line += ": " + shortDiffValue(change.New)
+			}
+			lines = append(lines, line)
+		case ConfigChangeTypeRemoved:
+			line := "- " + change.Path
+			if change.Old != "" {
+				line += ": " + shortDiffValue(change.Old)
+			}
+			lines = append(lines, line)
+		case ConfigChangeTypeChanged:
+			lines = append(lines, fmt.Sprintf("~ %s: %s -> %s", change.Path, shortDiffValue(change.Old), shortDiffValue(change.New)))
+		case ConfigChangeTypeMoved:
+			lines = append(lines, fmt.Sprintf("> %s: moved from position %s to %s", change.Path, change.Old, change.New))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func shortDiffValue(value string) string {
+	const maxLength = 80
+	if idx := strings.Index(value, "\n"); idx != -1 {
+		value = fmt.Sprintf("%s... (%d lines)", value[:idx], strings.Count(value, "\n")+1)
+	}
+	if runes := []rune(value); len(runes) > maxLength {
+		value = string(runes[:maxLength]) + "..."
+	}
+	return value
+}
+
+// DiffConfigs compares the configs at the model level: the workflows with their steps, inputs and envs,
+// the trigger map, the pipelines and the stages. The secret looking values are masked.
+func DiffConfigs(oldConfig, newConfig BitriseDataModel) ConfigDiff {
+	diff := ConfigDiff{Changes: []ConfigChange{}}
+
+	diff.compareValue("format_version", oldConfig.FormatVersion, newConfig.FormatVersion)
+	diff.compareValue("default_step_lib_source", oldConfig.DefaultStepLibSource, newConfig.DefaultStepLibSource)
+	diff.compareEnvs("app.envs", oldConfig.App.Environments, newConfig.App.Environments)
+	diff.compareTriggerMaps(oldConfig.TriggerMap, newConfig.TriggerMap)
+	diff.compareValue("git_steps", gitStepsString(oldConfig.GitSteps), gitStepsString(newConfig.GitSteps))
+
+	for _, id := range unionKeys(pipelineIDs(oldConfig), pipelineIDs(newConfig)) {
+		path := "pipelines." + id
+		oldPipeline, oldOK := oldConfig.Pipelines[id]
+		newPipeline, newOK := newConfig.Pipelines[id]
+		switch {
+		case !oldOK:
+			diff.add(ConfigChangeTypeAdded, path, "", "")
+		case !newOK:
+			diff.add(ConfigChangeTypeRemoved, path, "", "")
+		default:
+			diff.compareValue(path+".stages", stageListString(oldPipeline.Stages), stageListString(newPipeline.Stages))
+		}
+	}
+
+	for _, id := range unionKeys(stageIDs(oldConfig), stageIDs(newConfig)) {
+		path := "stages." + id
+		oldStage, oldOK := oldConfig.Stages[id]
+		newStage, newOK := newConfig.Stages[id]
+		switch {
+		case !oldOK:
+			diff.add(ConfigChangeTypeAdded, path, "", "")
+		case !newOK:
+			diff.add(ConfigChangeTypeRemoved, path, "", "")
+		default:
+			diff.compareValue(path+".workflows", workflowListString(oldStage.Workflows), workflowListString(newStage.Workflows))
+			diff.compareValue(path+".should_always_run", fmt.Sprintf("%v", oldStage.ShouldAlwaysRun), fmt.Sprintf("%v", newStage.ShouldAlwaysRun))
+			diff.compareValue(path+".abort_on_fail", fmt.Sprintf("%v", oldStage.AbortOnFail), fmt.Sprintf("%v", newStage.AbortOnFail))
+			diff.compareValue(path+".run_if", oldStage.RunIf, newStage.RunIf)
+		}
+	}
+
+	for _, id := range unionKeys(workflowIDs(oldConfig), workflowIDs(newConfig)) {
+		path := "workflows." + id
+		oldWorkflow, oldOK := oldConfig.Workflows[id]
+		newWorkflow, newOK := newConfig.Workflows[id]
+		switch {
+		case !oldOK:
+			diff.add(ConfigChangeTypeAdded, path, "", "")
+		case !newOK:
+			diff.add(ConfigChangeTypeRemoved, path, "", "")
+		default:
+			diff.compareWorkflows(path, oldWorkflow, newWorkflow, oldConfig.DefaultStepLibSource, newConfig.DefaultStepLibSource)
+		}
+	}
+
+	return diff
+}
+
+func (diff *ConfigDiff) compareTriggerMaps(oldTriggerMap, newTriggerMap TriggerMapModel) {
+	// the trigger items are identified by their conditions, as the order of the items matters the moves are reported too
+	oldKeys, oldItems := triggerItemKeys(oldTriggerMap)
+	newKeys, newItems := triggerItemKeys(newTriggerMap)
+
+	for _, key := range oldKeys {
+		if _, ok := newItems[key]; !ok {
+			oldItem := oldItems[key]
+			diff.add(ConfigChangeTypeRemoved, fmt.Sprintf("trigger_map[%s]", key), triggerTargetString(oldItem), "")
+		}
+	}
+	for _, key := range newKeys {
+		newItem := newItems[key]
+		path := fmt.Sprintf("trigger_map[%s]", key)
+		oldItem, ok := oldItems[key]
+		if !ok {
+			diff.add(ConfigChangeTypeAdded, path, "", triggerTargetString(newItem))
+			continue
+		}
+		diff.compareValue(path, triggerTargetString(oldItem), triggerTargetString(newItem))
+	}
+	diff.compareOrder("trigger_map", oldKeys, newKeys, func(key string) string { return fmt.Sprintf("trigger_map[%s]", key) })
+}
+
+func triggerItemKeys(triggerMap TriggerMapModel) ([]string, map[string]TriggerMapItemModel) {
+	var keys []string
+	items := map[string]TriggerMapItemModel{}
+	for _, item := range triggerMap {
+		key := uniqueKey(item.String(false), func(key string) bool { _, ok := items[key]; return ok })
+		keys = append(keys, key)
+		items[key] = item
+	}
+	return keys, items
+}
+
+func triggerTargetString(item TriggerMapItemModel) string {
+	if item.PipelineID != "" {
+		return "pipeline: " + item.PipelineID
+	}
+	return "workflow: " + item.WorkflowID
+}
+
+func (diff *ConfigDiff) compareWorkflows(path string, oldWorkflow, newWorkflow WorkflowModel, oldDefaultStepLibSource, newDefaultStepLibSource string) {
+	diff.compareValue(path+".title", oldWorkflow.Title, newWorkflow.Title)
+	diff.compareValue(path+".summary", oldWorkflow.Summary, newWorkflow.Summary)
+	diff.compareValue(path+".description", oldWorkflow.Description, newWorkflow.Description)
+	diff.compareValue(path+".before_run", strings.Join(oldWorkflow.BeforeRun, ", "), strings.Join(newWorkflow.BeforeRun, ", "))
+	diff.compareValue(path+".after_run", strings.Join(oldWorkflow.AfterRun, ", "), strings.Join(newWorkflow.AfterRun, ", "))
+	diff.compareEnvs(path+".envs", oldWorkflow.Environments, newWorkflow.Environments)
+	diff.compareContainers(path+".container", oldWorkflow.Container, newWorkflow.Container)
+	diff.compareValue(path+".services", servicesString(oldWorkflow.Services), servicesString(newWorkflow.Services))
+
+	oldKeys, oldSteps := workflowStepKeys(oldWorkflow, oldDefaultStepLibSource)
+	newKeys, newSteps := workflowStepKeys(newWorkflow, newDefaultStepLibSource)
+	stepPath := func(key string) string { return fmt.Sprintf("%s.steps[%s]", path, key) }
+
+	for _, key := range oldKeys {
+		if _, ok := newSteps[key]; !ok {
+			diff.add(ConfigChangeTypeRemoved, stepPath(key), oldSteps[key].compositeID, "")
+		}
+	}
+	for _, key := range newKeys {
+		newStep := newSteps[key]
+		oldStep, ok := oldSteps[key]
+		if !ok {
+			diff.add(ConfigChangeTypeAdded, stepPath(key), "", newStep.compositeID)
+			continue
+		}
+		diff.compareSteps(stepPath(key), oldStep, newStep)
+	}
+	diff.compareOrder(path+".steps", oldKeys, newKeys, stepPath)
+}
+
+type diffStep struct {
+	compositeID string
+	version     string
+	step        stepmanModels.StepModel
+}
+
+// workflowStepKeys identifies the steps by their source and ID (without the version),
+// the repeated steps are identified by their occurrence (script, script#2).
+func workflowStepKeys(workflow WorkflowModel, defaultStepLibSource string) ([]string, map[string]diffStep) {
+	var keys []string
+	steps := map[string]diffStep{}
+	for _, stepListItem := range workflow.Steps {
+		compositeID, step := stepListItem.GetStepIDAndStep()
+
+		key := getStepID(compositeID)
+		if source := getStepSource(compositeID); source != "" && source != defaultStepLibSource {
+			key = source + "::" + key
+		}
+		key = uniqueKey(key, func(key string) bool { _, ok := steps[key]; return ok })
+
+		keys = append(keys, key)
+		steps[key] = diffStep{compositeID: compositeID, version: getStepVersion(compositeID), step: step}
+	}
+	return keys, steps
+}
+
+func (diff *ConfigDiff) compareSteps(path string, oldStep, newStep diffStep) {
+	diff.compareValue(path+".version", oldStep.version, newStep.version)
+	diff.compareValue(path+".title", stringPtrValue(oldStep.step.Title), stringPtrValue(newStep.step.Title))
+	diff.compareValue(path+".run_if", stringPtrValue(oldStep.step.RunIf), stringPtrValue(newStep.step.RunIf))
+	diff.compareValue(path+".is_always_run", boolPtrValue(oldStep.step.IsAlwaysRun), boolPtrValue(newStep.step.IsAlwaysRun))
+	diff.compareValue(path+".is_skippable", boolPtrValue(oldStep.step.IsSkippable), boolPtrValue(newStep.step.IsSkippable))
+	diff.compareValue(path+".timeout", intPtrValue(oldStep.step.Timeout), intPtrValue(newStep.step.Timeout))
+	diff.compareValue(path+".no_output_timeout", intPtrValue(oldStep.step.NoOutputTimeout), intPtrValue(newStep.step.NoOutputTimeout))
+	diff.compareEnvs(path+".inputs", oldStep.step.Inputs, newStep.step.Inputs)
+}
+
+type diffEnv struct {
+	value   string
+	options string
+	secret  bool
+}
+
+func envKeys(envs []envmanModels.EnvironmentItemModel) ([]string, map[string]diffEnv) {
+	var keys []string
+	items := map[string]diffEnv{}
+	for _, env := range envs {
+		key, value, err := env.GetKeyValuePair()
+		if err != nil {
+			continue
+		}
+		options, err := env.GetOptions()
+		if err != nil {
+			continue
+		}
+		optionsBytes, err := json.Marshal(options)
+		if err != nil {
+			continue
+		}
+
+		secret := (options.IsSensitive != nil && *options.IsSensitive) || secretKeyRegexp.MatchString(key) || secretValueRegexp.MatchString(value)
+
+		key = uniqueKey(key, func(key string) bool { _, ok := items[key]; return ok })
+		keys = append(keys, key)
+		items[key] = diffEnv{value: value, options: string(optionsBytes), secret: secret}
+	}
+	return keys, items
+}
+
+func (env diffEnv) printableValue() string {
+	if env.secret && env.value != "" {
+		return redactedValue
+	}
+	return env.value
+}
+
+func (diff *ConfigDiff) compareEnvs(path string, oldEnvs, newEnvs []envmanModels.EnvironmentItemModel) {
+	oldKeys, oldItems := envKeys(oldEnvs)
+	newKeys, newItems := envKeys(newEnvs)
+
+	for _, key := range oldKeys {
+		if _, ok := newItems[key]; !ok {
+			diff.add(ConfigChangeTypeRemoved, path+"."+key, oldItems[key].printableValue(), "")
+		}
+	}
+	for _, key := range newKeys {
+		newEnv := newItems[key]
+		oldEnv, ok := oldItems[key]
+		if !ok {
+			diff.add(ConfigChangeTypeAdded, path+"."+key, "", newEnv.printableValue())
+			continue
+		}
+
+		if oldEnv.value != newEnv.value {
+			oldValue, newValue := oldEnv.printableValue(), newEnv.printableValue()
+			if oldEnv.secret || newEnv.secret {
+				// the masked values would be the same, mark the change only
+				oldValue, newValue = redactedValue, redactedValue+" (changed)"
+			}
+			diff.add(ConfigChangeTypeChanged, path+"."+key, oldValue, newValue)
+		}
+		diff.compareValue(path+"."+key+".opts", oldEnv.options, newEnv.options)
+	}
+	// the order matters, as the envs can reference the preceding envs
+	diff.compareOrder(path, oldKeys, newKeys, func(key string) string { return path + "." + key })
+}
+
+// compareOrder reports the elements, which were moved relative to the other common elements of the lists.
+// The elements outside of the longest common subsequence of the lists are reported as moved.
+func (diff *ConfigDiff) compareOrder(path string, oldKeys, newKeys []string, elementPath func(string) string) {
+	oldPositions := map[string]int{}
+	for idx, key := range oldKeys {
+		oldPositions[key] = idx
+	}
+	newPositions := map[string]int{}
+	for idx, key := range newKeys {
+		newPositions[key] = idx
+	}
+
+	var oldCommon, newCommon []string
+	for _, key := range oldKeys {
+		if _, ok := newPositions[key]; ok {
+			oldCommon = append(oldCommon, key)
+		}
+	}
+	for _, key := range newKeys {
+		if _, ok := oldPositions[key]; ok {
+			newCommon = append(newCommon, key)
+		}
+	}
+
+	inPlace := longestCommonSubsequence(oldCommon, newCommon)
+	for _, key := range newCommon {
+		if !inPlace[key] {
+			diff.add(ConfigChangeTypeMoved, elementPath(key), fmt.Sprintf("%d", oldPositions[key]+1), fmt.Sprintf("%d", newPositions[key]+1))
+		}
+	}
+}
+
+func longestCommonSubsequence(a, b []string) map[string]bool {
+	lengths := make([][]int, len(a)+1)
+	for i := range lengths {
+		lengths[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else if lengths[i+1][j] >= lengths[i][j+1] {
+				lengths[i][j] = lengths[i+1][j]
+			} else {
+				lengths[i][j] = lengths[i][j+1]
+			}
+		}
+	}
+
+	subsequence := map[string]bool{}
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] == b[j]:
+			subsequence[a[i]] = true
+			i++
+			j++
+		case lengths[i+1][j] >= lengths[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return subsequence
+}
+
+// uniqueKey returns the key with its occurrence (key#2, key#3) if the key already exists.
+func uniqueKey(key string, exists func(string) bool) string {
+	unique := key
+	for occurrence := 2; exists(unique); occurrence++ {
+		unique = fmt.Sprintf("%s#%d", key, occurrence)
+	}
+	return unique
+}
+
+func unionKeys(a, b []string) []string {
+	keys := append([]string{}, a...)
+	for _, key := range b {
+		found := false
+		for _, existing := range a {
+			if existing == key {
+				found = true
+				break
+			}
+		}
+		if !found {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func pipelineIDs(config BitriseDataModel) []string {
+	var ids []string
+	for id := range config.Pipelines {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func stageIDs(config BitriseDataModel) []string {
+	var ids []string
+	for id := range config.Stages {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func workflowIDs(config BitriseDataModel) []string {
+	var ids []string
+	for id := range config.Workflows {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func stageListString(stages []StageListItemModel) string {
+	var ids []string
+	for _, stage := range stages {
+		id, err := GetStageIDFromListItemModel(stage)
+		if err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return strings.Join(ids, ", ")
+}
+
+func workflowListString(workflows []WorkflowListItemModel) string {
+	var ids []string
+	for _, workflow := range workflows {
+		id, err := GetWorkflowIDFromListItemModel(workflow)
+		if err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return strings.Join(ids, ", ")
+}
+
+// compareContainers compares the containers with masked secret options,
+// a change of the masked options only is marked with the (changed) suffix.
+func (diff *ConfigDiff) compareContainers(path string, oldContainer, newContainer *ContainerModel) {
+	oldValue, newValue := containerString(oldContainer, true), containerString(newContainer, true)
+	if oldValue == newValue && containerString(oldContainer, false) != containerString(newContainer, false) {
+		newValue += " (changed)"
+	}
+	diff.compareValue(path, oldValue, newValue)
+}
+
+func containerString(container *ContainerModel, mask bool) string {
+	if container == nil {
+		return ""
+	}
+	str := container.Image
+	if container.Engine != "" {
+		str = container.Engine + ": " + str
+	}
+	for idx, option := range container.Options {
+		if mask {
+			option = maskedContainerOption(option, idx > 0 && isSecretContainerFlag(container.Options[idx-1]))
+		}
+		str += " " + option
+	}
+	return str
+}
+
+// maskedContainerOption masks the value of the secret looking container run options,
+// like -e API_TOKEN=abc, --env=API_TOKEN=abc, --password=abc or the value following a --password flag.
+func maskedContainerOption(option string, isSecretFlagValue bool) string {
+	if isSecretFlagValue {
+		return redactedValue
+	}
+
+	parts := strings.Split(option, "=")
+	for idx := 0; idx < len(parts)-1; idx++ {
+		if key := strings.Join(parts[:idx+1], "="); secretKeyRegexp.MatchString(key) {
+			return key + "=" + redactedValue
+		}
+	}
+
+	if loc := secretValueRegexp.FindStringIndex(option); loc != nil {
+		if idx := strings.LastIndex(option[:loc[0]], "="); idx != -1 {
+			return option[:idx+1] + redactedValue
+		}
+		return redactedValue
+	}
+	return option
+}
+
+// isSecretContainerFlag returns true if the option is a flag (without value), whose value is the next option, like --password.
+func isSecretContainerFlag(option string) bool {
+	return strings.HasPrefix(option, "-") && !strings.Contains(option, "=") && secretKeyRegexp.MatchString(option)
+}
+
+func servicesString(services map[string]ServiceModel) string {
+	var items []string
+	for name, service := range services {
+		item := name + ": " + service.Image
+		if len(service.Ports) > 0 {
+			item += " (" + strings.Join(service.Ports, ", ") + ")"
+		}
+		items = append(items, item)
+	}
+	sort.Strings(items)
+	return strings.Join(items, ", ")
+}
+
+func gitStepsString(gitSteps map[string]GitStepModel) string {
+	var items []string
+	for stepID, gitStep := range gitSteps {
+		var verifications []string
+		if gitStep.Commit != "" {
+			verifications = append(verifications, "commit: "+gitStep.Commit)
+		}
+		if gitStep.Tree != "" {
+			verifications = append(verifications, "tree: "+gitStep.Tree)
+		}
+		if gitStep.VerifyTagSignature {
+			verifications = append(verifications, "signed tag")
+		}
+		if gitStep.Keyring != "" {
+			verifications = append(verifications, "keyring: "+gitStep.Keyring)
+		}
+		items = append(items, stepID+" ("+strings.Join(verifications, ", ")+")")
+	}
+	sort.Strings(items)
+	return strings.Join(items, ", ")
+}
+
+func stringPtrValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+func boolPtrValue(value *bool) string {
+	if value == nil {
+		return ""
+	}
+	return fmt.Sprintf("%v", *value)
+}
+
+func intPtrValue(value *int) string {
+	if value == nil {
+		return ""
+	}
+	return fmt.Sprintf("%d", *value)
+}
@@ -0,0 +1,205 @@
+package models
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	envmanModels "github.com/bitrise-io/envman/models"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+func parseDiffTestConfig(t *testing.T, content string) BitriseDataModel {
+	var config BitriseDataModel
+	require.NoError(t, yaml.Unmarshal([]byte(content), &config))
+	return config
+}
+
+func TestDiffConfigs(t *testing.T) {
+	oldConfig := parseDiffTestConfig(t, `format_version: "12"
+default_step_lib_source: https://github.com/bitrise-io/bitrise-steplib.git
+app:
+  envs:
+  - API_TOKEN: abc
+  - PROJECT: app
+trigger_map:
+- push_branch: master
+  workflow: primary
+- tag: "*"
+  pipeline: release
+pipelines:
+  release:
+    stages:
+    - build: {}
+stages:
+  build:
+    workflows:
+    - primary: {}
+workflows:
+  primary:
+    steps:
+    - git-clone@6: {}
+    - script@1.1:
+        inputs:
+        - content: echo hi
+        - password: secret
+    - cache-push@2: {}
+  old: {}
+`)
+	newConfig := parseDiffTestConfig(t, `format_version: "12"
+default_step_lib_source: https://github.com/bitrise-io/bitrise-steplib.git
+app:
+  envs:
+  - API_TOKEN: xyz
+  - PROJECT: app
+  - SIGNING: ghp_0123456789
+trigger_map:
+- tag: "*"
+  pipeline: release
+- push_branch: master
+  workflow: ci
+git_steps:
+  git::https://github.com/bitrise-steplib/steps-script.git@1.0.0:
+    commit: 8ac5a22
+pipelines:
+  release:
+    stages:
+    - build: {}
+    - deploy: {}
+stages:
+  build:
+    workflows:
+    - primary: {}
+  deploy:
+    workflows:
+    - ci: {}
+workflows:
+  primary:
+    steps:
+    - cache-push@2: {}
+    - git-clone@7: {}
+    - script@1.1:
+        is_always_run: true
+        inputs:
+        - content: echo hello
+        - password: other
+    - script@1: {}
+  ci: {}
+`)
+
+	t.Log("changes")
+	{
+		diff := DiffConfigs(oldConfig, newConfig)
+		require.Equal(t, []ConfigChange{
+			{Type: ConfigChangeTypeChanged, Path: "app.envs.API_TOKEN", Old: "[REDACTED]", New: "[REDACTED] (changed)"},
+			{Type: ConfigChangeTypeAdded, Path: "app.envs.SIGNING", New: "[REDACTED]"},
+			{Type: ConfigChangeTypeChanged, Path: "trigger_map[push_branch: master]", Old: "workflow: primary", New: "workflow: ci"},
+			{Type: ConfigChangeTypeMoved, Path: "trigger_map[push_branch: master]", Old: "1", New: "2"},
+			{Type: ConfigChangeTypeAdded, Path: "git_steps", New: "git::https://github.com/bitrise-steplib/steps-script.git@1.0.0 (commit: 8ac5a22)"},
+			{Type: ConfigChangeTypeChanged, Path: "pipelines.release.stages", Old: "build", New: "build, deploy"},
+			{Type: ConfigChangeTypeAdded, Path: "stages.deploy"},
+			{Type: ConfigChangeTypeAdded, Path: "workflows.ci"},
+			{Type: ConfigChangeTypeRemoved, Path: "workflows.old"},
+			{Type: ConfigChangeTypeChanged, Path: "workflows.primary.steps[git-clone].version", Old: "6", New: "7"},
+			{Type: ConfigChangeTypeAdded, Path: "workflows.primary.steps[script].is_always_run", New: "true"},
+			{Type: ConfigChangeTypeChanged, Path: "workflows.primary.steps[script].inputs.content", Old: "echo hi", New: "echo hello"},
+			{Type: ConfigChangeTypeChanged, Path: "workflows.primary.steps[script].inputs.password", Old: "[REDACTED]", New: "[REDACTED] (changed)"},
+			{Type: ConfigChangeTypeAdded, Path: "workflows.primary.steps[script#2]", New: "script@1"},
+			{Type: ConfigChangeTypeMoved, Path: "workflows.primary.steps[cache-push]", Old: "3", New: "1"},
+		}, diff.Changes)
+
+		require.Equal(t, `~ app.envs.API_TOKEN: [REDACTED] -> [REDACTED] (changed)
++ app.envs.SIGNING: [REDACTED]
+~ trigger_map[push_branch: master]: workflow: primary -> workflow: ci
+> trigger_map[push_branch: master]: moved from position 1 to 2
++ git_steps: git::https://github.com/bitrise-steplib/steps-script.git@1.0.0 (commit: 8ac5a22)
+~ pipelines.release.stages: build -> build, deploy
++ stages.deploy
++ workflows.ci
+- workflows.old
+~ workflows.primary.steps[git-clone].version: 6 -> 7
++ workflows.primary.steps[script].is_always_run: true
+~ workflows.primary.steps[script].inputs.content: echo hi -> echo hello
+~ workflows.primary.steps[script].inputs.password: [REDACTED] -> [REDACTED] (changed)
++ workflows.primary.steps[script#2]: script@1
+> workflows.primary.steps[cache-push]: moved from position 3 to 1`, diff.String())
+	}
+
+	t.Log("no changes")
+	{
+		diff := DiffConfigs(oldConfig, oldConfig)
+		require.False(t, diff.HasChanges())
+		require.Equal(t, "No changes", diff.String())
+	}
+}
+
+func TestDiffConfigsContainerOptions(t *testing.T) {
+	configWithContainer := func(options ...string) BitriseDataModel {
+		return BitriseDataModel{
+			FormatVersion: "12",
+			Workflows: map[string]WorkflowModel{
+				"primary": {Container: &ContainerModel{Image: "golang:1.17", Options: options}},
+			},
+		}
+	}
+
+	t.Log("secret options are masked")
+	{
+		diff := DiffConfigs(
+			configWithContainer("--network=host"),
+			configWithContainer("--network=host", "-e", "API_TOKEN=abc", "--env=GITHUB_AUTH=a=b", "-e", "SIGNING=ghp_0123456789", "--password", "p4ss", "-e", "CI=true"),
+		)
+		require.Equal(t, []ConfigChange{
+			{
+				Type: ConfigChangeTypeChanged, Path: "workflows.primary.container",
+				Old: "golang:1.17 --network=host",
+				New: "golang:1.17 --network=host -e API_TOKEN=[REDACTED] --env=GITHUB_AUTH=[REDACTED] -e SIGNING=[REDACTED] --password [REDACTED] -e CI=true",
+			},
+		}, diff.Changes)
+	}
+
+	t.Log("change of a secret option only")
+	{
+		diff := DiffConfigs(configWithContainer("-e", "API_TOKEN=abc"), configWithContainer("-e", "API_TOKEN=xyz"))
+		require.Equal(t, []ConfigChange{
+			{Type: ConfigChangeTypeChanged, Path: "workflows.primary.container", Old: "golang:1.17 -e API_TOKEN=[REDACTED]", New: "golang:1.17 -e API_TOKEN=[REDACTED] (changed)"},
+		}, diff.Changes)
+	}
+}
+
+func TestDiffConfigsEnvOrder(t *testing.T) {
+	configWithEnvs := func(envs ...string) BitriseDataModel {
+		config := BitriseDataModel{FormatVersion: "12"}
+		for _, key := range envs {
+			config.App.Environments = append(config.App.Environments, envmanModels.EnvironmentItemModel{key: "$" + key + "_BASE"})
+		}
+		return config
+	}
+
+	t.Log("moved env")
+	{
+		diff := DiffConfigs(configWithEnvs("BASE_URL", "API_URL", "PROJECT"), configWithEnvs("API_URL", "BASE_URL", "PROJECT"))
+		require.Equal(t, []ConfigChange{
+			{Type: ConfigChangeTypeMoved, Path: "app.envs.BASE_URL", Old: "1", New: "2"},
+		}, diff.Changes)
+	}
+
+	t.Log("unchanged order with added and removed envs")
+	{
+		diff := DiffConfigs(configWithEnvs("BASE_URL", "API_URL"), configWithEnvs("API_URL", "PROJECT"))
+		require.Equal(t, []ConfigChange{
+			{Type: ConfigChangeTypeRemoved, Path: "app.envs.BASE_URL", Old: "$BASE_URL_BASE"},
+			{Type: ConfigChangeTypeAdded, Path: "app.envs.PROJECT", New: "$PROJECT_BASE"},
+		}, diff.Changes)
+	}
+}
+
+func TestConfigDiffShortValue(t *testing.T) {
+	require.Equal(t, "echo a... (3 lines)", shortDiffValue("echo a\necho b\necho c"))
+	require.Equal(t, 83, len(shortDiffValue(string(make([]byte, 100)))))
+
+	value := shortDiffValue(strings.Repeat("á", 100))
+	require.True(t, utf8.ValidString(value))
+	require.Equal(t, strings.Repeat("á", 80)+"...", value)
+}
@@ -0,0 +1,203 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// GraphNodeType ...
+type GraphNodeType string
+
+const (
+	// GraphNodeTypeTrigger ...
+	GraphNodeTypeTrigger GraphNodeType = "trigger"
+	// GraphNodeTypePipeline ...
+	GraphNodeTypePipeline GraphNodeType = "pipeline"
+	// GraphNodeTypeStage ...
+	GraphNodeTypeStage GraphNodeType = "stage"
+	// GraphNodeTypeWorkflow ...
+	GraphNodeTypeWorkflow GraphNodeType = "workflow"
+	// GraphNodeTypeStep ...
+	GraphNodeTypeStep GraphNodeType = "step"
+)
+
+// GraphNode ...
+type GraphNode struct {
+	ID    string        `json:"id" yaml:"id"`
+	Type  GraphNodeType `json:"type" yaml:"type"`
+	Label string        `json:"label" yaml:"label"`
+}
+
+// GraphEdge ...
+type GraphEdge struct {
+	From  string `json:"from" yaml:"from"`
+	To    string `json:"to" yaml:"to"`
+	Label string `json:"label,omitempty" yaml:"label,omitempty"`
+}
+
+// ConfigGraph is the graph of the trigger map items, pipelines, stages, workflows (and optionally steps) of a config.
+type ConfigGraph struct {
+	Nodes []GraphNode `json:"nodes" yaml:"nodes"`
+	Edges []GraphEdge `json:"edges" yaml:"edges"`
+}
+
+func graphNodeID(nodeType GraphNodeType, id string) string {
+	return fmt.Sprintf("%s:%s", nodeType, id)
+}
+
+// Graph returns the graph of the config, the edges point from the trigger map items to the pipelines and workflows,
+// from the pipelines to their stages, from the stages to their workflows, and from the workflows to their before_run and after_run workflows.
+// If includeSteps is true, the workflows' steps are included as well.
+func (config BitriseDataModel) Graph(includeSteps bool) ConfigGraph {
+	graph := ConfigGraph{Nodes: []GraphNode{}, Edges: []GraphEdge{}}
+
+	for idx, item := range config.TriggerMap {
+		nodeID := graphNodeID(GraphNodeTypeTrigger, fmt.Sprintf("%d", idx))
+		graph.Nodes = append(graph.Nodes, GraphNode{ID: nodeID, Type: GraphNodeTypeTrigger, Label: item.String(false)})
+		if item.PipelineID != "" {
+			graph.Edges = append(graph.Edges, GraphEdge{From: nodeID, To: graphNodeID(GraphNodeTypePipeline, item.PipelineID)})
+		} else if item.WorkflowID != "" {
+			graph.Edges = append(graph.Edges, GraphEdge{From: nodeID, To: graphNodeID(GraphNodeTypeWorkflow, item.WorkflowID)})
+		}
+	}
+
+	var pipelineIDs []string
+	for pipelineID := range config.Pipelines {
+		pipelineIDs = append(pipelineIDs, pipelineID)
+	}
+	sort.Strings(pipelineIDs)
+
+	for _, pipelineID := range pipelineIDs {
+		nodeID := graphNodeID(GraphNodeTypePipeline, pipelineID)
+		graph.Nodes = append(graph.Nodes, GraphNode{ID: nodeID, Type: GraphNodeTypePipeline, Label: pipelineID})
+		for idx, stage := range config.pipelineStages(pipelineID) {
+			graph.Edges = append(graph.Edges, GraphEdge{From: nodeID, To: graphNodeID(GraphNodeTypeStage, stage.ID), Label: fmt.Sprintf("%d", idx+1)})
+		}
+	}
+
+	var stageIDs []string
+	for stageID := range config.Stages {
+		stageIDs = append(stageIDs, stageID)
+	}
+	sort.Strings(stageIDs)
+
+	for _, stageID := range stageIDs {
+		nodeID := graphNodeID(GraphNodeTypeStage, stageID)
+		graph.Nodes = append(graph.Nodes, GraphNode{ID: nodeID, Type: GraphNodeTypeStage, Label: stageID})
+		for _, workflowListItem := range config.Stages[stageID].Workflows {
+			workflowID, err := GetWorkflowIDFromListItemModel(workflowListItem)
+			if err != nil {
+				continue
+			}
+			graph.Edges = append(graph.Edges, GraphEdge{From: nodeID, To: graphNodeID(GraphNodeTypeWorkflow, workflowID)})
+		}
+	}
+
+	var workflowIDs []string
+	for workflowID := range config.Workflows {
+		workflowIDs = append(workflowIDs, workflowID)
+	}
+	sort.Strings(workflowIDs)
+
+	for _, workflowID := range workflowIDs {
+		workflow := config.Workflows[workflowID]
+		nodeID := graphNodeID(GraphNodeTypeWorkflow, workflowID)
+		graph.Nodes = append(graph.Nodes, GraphNode{ID: nodeID, Type: GraphNodeTypeWorkflow, Label: workflowID})
+
+		for _, before := range workflow.BeforeRun {
+			graph.Edges = append(graph.Edges, GraphEdge{From: nodeID, To: graphNodeID(GraphNodeTypeWorkflow, before), Label: "before_run"})
+		}
+		for _, after := range workflow.AfterRun {
+			graph.Edges = append(graph.Edges, GraphEdge{From: nodeID, To: graphNodeID(GraphNodeTypeWorkflow, after), Label: "after_run"})
+		}
+
+		if includeSteps {
+			for idx, stepListItem := range workflow.Steps {
+				stepID, step := stepListItem.GetStepIDAndStep()
+				label := stepID
+				if step.Title != nil && *step.Title != "" {
+					label = *step.Title
+				}
+
+				stepNodeID := graphNodeID(GraphNodeTypeStep, fmt.Sprintf("%s/%d", workflowID, idx))
+				graph.Nodes = append(graph.Nodes, GraphNode{ID: stepNodeID, Type: GraphNodeTypeStep, Label: label})
+				graph.Edges = append(graph.Edges, GraphEdge{From: nodeID, To: stepNodeID, Label: fmt.Sprintf("%d", idx+1)})
+			}
+		}
+	}
+
+	return graph
+}
+
+var dotNodeShapes = map[GraphNodeType]string{
+	GraphNodeTypeTrigger:  "parallelogram",
+	GraphNodeTypePipeline: "hexagon",
+	GraphNodeTypeStage:    "tab",
+	GraphNodeTypeWorkflow: "box",
+	GraphNodeTypeStep:     "note",
+}
+
+func dotQuote(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value) + `"`
+}
+
+// DOT renders the graph in the Graphviz DOT language.
+func (graph ConfigGraph) DOT() string {
+	var builder strings.Builder
+	builder.WriteString("digraph bitrise {\n")
+	builder.WriteString("  rankdir=LR;\n")
+	for _, node := range graph.Nodes {
+		builder.WriteString(fmt.Sprintf("  %s [label=%s, shape=%s];\n", dotQuote(node.ID), dotQuote(node.Label), dotNodeShapes[node.Type]))
+	}
+	for _, edge := range graph.Edges {
+		builder.WriteString(fmt.Sprintf("  %s -> %s", dotQuote(edge.From), dotQuote(edge.To)))
+		if edge.Label != "" {
+			builder.WriteString(fmt.Sprintf(" [label=%s]", dotQuote(edge.Label)))
+		}
+		builder.WriteString(";\n")
+	}
+	builder.WriteString("}\n")
+	return builder.String()
+}
+
+var mermaidNodeShapes = map[GraphNodeType][2]string{
+	GraphNodeTypeTrigger:  {">", "]"},
+	GraphNodeTypePipeline: {"{{", "}}"},
+	GraphNodeTypeStage:    {"[/", "/]"},
+	GraphNodeTypeWorkflow: {"[", "]"},
+	GraphNodeTypeStep:     {"(", ")"},
+}
+
+func mermaidQuote(value string) string {
+	return `"` + strings.NewReplacer(`"`, "#quot;", "\n", " ").Replace(value) + `"`
+}
+
+// Mermaid renders the graph as a Mermaid flowchart.
+// Mermaid node ids are generated (n0, n1, ...), as the graph node ids may contain characters not supported by Mermaid.
+func (graph ConfigGraph) Mermaid() string {
+	mermaidIDs := map[string]string{}
+	mermaidID := func(nodeID string) string {
+		id, ok := mermaidIDs[nodeID]
+		if !ok {
+			id = fmt.Sprintf("n%d", len(mermaidIDs))
+			mermaidIDs[nodeID] = id
+		}
+		return id
+	}
+
+	var builder strings.Builder
+	builder.WriteString("flowchart LR\n")
+	for _, node := range graph.Nodes {
+		shape := mermaidNodeShapes[node.Type]
+		builder.WriteString(fmt.Sprintf("  %s%s%s%s\n", mermaidID(node.ID), shape[0], mermaidQuote(node.Label), shape[1]))
+	}
+	for _, edge := range graph.Edges {
+		if edge.Label != "" {
+			builder.WriteString(fmt.Sprintf("  %s -->|%s| %s\n", mermaidID(edge.From), mermaidQuote(edge.Label), mermaidID(edge.To)))
+		} else {
+			builder.WriteString(fmt.Sprintf("  %s --> %s\n", mermaidID(edge.From), mermaidID(edge.To)))
+		}
+	}
+	return builder.String()
+}
@@ -0,0 +1,103 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+const graphTestConfig = `format_version: "12"
+trigger_map:
+- push_branch: master
+  pipeline: release
+- tag: "*"
+  workflow: deploy
+pipelines:
+  release:
+    stages:
+    - build: {}
+stages:
+  build:
+    workflows:
+    - deploy: {}
+workflows:
+  _setup: {}
+  deploy:
+    before_run: [_setup]
+    steps:
+    - script:
+        title: Say "hi"
+    - git::https://github.com/bitrise-steplib/steps-deploy.git@main: {}
+`
+
+func TestBitriseDataModelGraph(t *testing.T) {
+	var config BitriseDataModel
+	require.NoError(t, yaml.Unmarshal([]byte(graphTestConfig), &config))
+
+	t.Log("without steps")
+	{
+		graph := config.Graph(false)
+		require.Equal(t, []GraphNode{
+			{ID: "trigger:0", Type: GraphNodeTypeTrigger, Label: "push_branch: master"},
+			{ID: "trigger:1", Type: GraphNodeTypeTrigger, Label: "tag: *"},
+			{ID: "pipeline:release", Type: GraphNodeTypePipeline, Label: "release"},
+			{ID: "stage:build", Type: GraphNodeTypeStage, Label: "build"},
+			{ID: "workflow:_setup", Type: GraphNodeTypeWorkflow, Label: "_setup"},
+			{ID: "workflow:deploy", Type: GraphNodeTypeWorkflow, Label: "deploy"},
+		}, graph.Nodes)
+		require.Equal(t, []GraphEdge{
+			{From: "trigger:0", To: "pipeline:release"},
+			{From: "trigger:1", To: "workflow:deploy"},
+			{From: "pipeline:release", To: "stage:build", Label: "1"},
+			{From: "stage:build", To: "workflow:deploy"},
+			{From: "workflow:deploy", To: "workflow:_setup", Label: "before_run"},
+		}, graph.Edges)
+	}
+
+	t.Log("with steps")
+	{
+		graph := config.Graph(true)
+		require.Equal(t, GraphNode{ID: "step:deploy/0", Type: GraphNodeTypeStep, Label: `Say "hi"`}, graph.Nodes[6])
+		require.Equal(t, GraphNode{ID: "step:deploy/1", Type: GraphNodeTypeStep, Label: "git::https://github.com/bitrise-steplib/steps-deploy.git@main"}, graph.Nodes[7])
+		require.Equal(t, GraphEdge{From: "workflow:deploy", To: "step:deploy/1", Label: "2"}, graph.Edges[len(graph.Edges)-1])
+	}
+}
+
+func TestConfigGraphRender(t *testing.T) {
+	graph := ConfigGraph{
+		Nodes: []GraphNode{
+			{ID: "trigger:0", Type: GraphNodeTypeTrigger, Label: "tag: *"},
+			{ID: "workflow:deploy", Type: GraphNodeTypeWorkflow, Label: "deploy"},
+			{ID: "step:deploy/0", Type: GraphNodeTypeStep, Label: `Say "hi"`},
+		},
+		Edges: []GraphEdge{
+			{From: "trigger:0", To: "workflow:deploy"},
+			{From: "workflow:deploy", To: "step:deploy/0", Label: "1"},
+		},
+	}
+
+	t.Log("dot")
+	{
+		require.Equal(t, `digraph bitrise {
+  rankdir=LR;
+  "trigger:0" [label="tag: *", shape=parallelogram];
+  "workflow:deploy" [label="deploy", shape=box];
+  "step:deploy/0" [label="Say \"hi\"", shape=note];
+  "trigger:0" -> "workflow:deploy";
+  "workflow:deploy" -> "step:deploy/0" [label="1"];
+}
+`, graph.DOT())
+	}
+
+	t.Log("mermaid")
+	{
+		require.Equal(t, `flowchart LR
+  n0>"tag: *"]
+  n1["deploy"]
+  n2("Say #quot;hi#quot;")
+  n0 --> n1
+  n1 -->|"1"| n2
+`, graph.Mermaid())
+	}
+}
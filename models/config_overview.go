@@ -0,0 +1,205 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+)
+
+// StageOverview ...
+type StageOverview struct {
+	ID        string   `json:"id" yaml:"id"`
+	Workflows []string `json:"workflows" yaml:"workflows"`
+}
+
+// PipelineOverview describes a pipeline with its stages and the trigger map items triggering it.
+type PipelineOverview struct {
+	ID          string          `json:"id" yaml:"id"`
+	Stages      []StageOverview `json:"stages" yaml:"stages"`
+	TriggeredBy []string        `json:"triggered_by,omitempty" yaml:"triggered_by,omitempty"`
+}
+
+// WorkflowOverview describes a workflow with its resolved before_run and after_run chain,
+// the pipelines and trigger map items reaching it.
+type WorkflowOverview struct {
+	ID          string `json:"id" yaml:"id"`
+	Title       string `json:"title,omitempty" yaml:"title,omitempty"`
+	Summary     string `json:"summary,omitempty" yaml:"summary,omitempty"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	IsUtility   bool   `json:"is_utility,omitempty" yaml:"is_utility,omitempty"`
+	// RunChain is the ordered list of the workflows run by the workflow, including the nested before_run and after_run workflows.
+	RunChain       []string `json:"run_chain" yaml:"run_chain"`
+	StepCount      int      `json:"step_count" yaml:"step_count"`
+	ChainStepCount int      `json:"chain_step_count" yaml:"chain_step_count"`
+	Pipelines      []string `json:"pipelines,omitempty" yaml:"pipelines,omitempty"`
+	TriggeredBy    []string `json:"triggered_by,omitempty" yaml:"triggered_by,omitempty"`
+	// Unreferenced is true if no trigger map item, pipeline or other workflow's before_run or after_run refers to the workflow.
+	Unreferenced bool `json:"unreferenced,omitempty" yaml:"unreferenced,omitempty"`
+}
+
+// ConfigOverview ...
+type ConfigOverview struct {
+	Pipelines             map[string]PipelineOverview `json:"pipelines" yaml:"pipelines"`
+	Workflows             map[string]WorkflowOverview `json:"workflows" yaml:"workflows"`
+	UnreferencedWorkflows []string                    `json:"unreferenced_workflows" yaml:"unreferenced_workflows"`
+}
+
+// RunChain returns the ordered list of the workflows run by the given workflow,
+// the before_run and after_run workflows are resolved recursively.
+func (config BitriseDataModel) RunChain(workflowID string) []string {
+	return config.walkRunChain(workflowID, []string{}, nil)
+}
+
+func (config BitriseDataModel) walkRunChain(workflowID string, chain, stack []string) []string {
+	if containsWorkflowName(workflowID, stack) {
+		// reference cycles are reported by the config validation
+		return chain
+	}
+	stack = append(stack, workflowID)
+
+	workflow := config.Workflows[workflowID]
+	for _, before := range workflow.BeforeRun {
+		chain = config.walkRunChain(before, chain, stack)
+	}
+	chain = append(chain, workflowID)
+	for _, after := range workflow.AfterRun {
+		chain = config.walkRunChain(after, chain, stack)
+	}
+	return chain
+}
+
+// PipelineWorkflows returns the workflows of the pipeline's stages, in the order of the stages.
+func (config BitriseDataModel) PipelineWorkflows(pipelineID string) []string {
+	var workflowIDs []string
+	for _, stage := range config.pipelineStages(pipelineID) {
+		workflowIDs = append(workflowIDs, stage.Workflows...)
+	}
+	return workflowIDs
+}
+
+func (config BitriseDataModel) pipelineStages(pipelineID string) []StageOverview {
+	stages := []StageOverview{}
+	for _, stageListItem := range config.Pipelines[pipelineID].Stages {
+		stageID, err := GetStageIDFromListItemModel(stageListItem)
+		if err != nil {
+			continue
+		}
+
+		stage := StageOverview{ID: stageID, Workflows: []string{}}
+		for _, workflowListItem := range config.Stages[stageID].Workflows {
+			workflowID, err := GetWorkflowIDFromListItemModel(workflowListItem)
+			if err != nil {
+				continue
+			}
+			stage.Workflows = append(stage.Workflows, workflowID)
+		}
+		stages = append(stages, stage)
+	}
+	return stages
+}
+
+// Overview describes the pipelines and workflows of the config and how they are reached from the trigger map.
+func (config BitriseDataModel) Overview() ConfigOverview {
+	overview := ConfigOverview{
+		Pipelines:             map[string]PipelineOverview{},
+		Workflows:             map[string]WorkflowOverview{},
+		UnreferencedWorkflows: []string{},
+	}
+
+	var pipelineIDs []string
+	for pipelineID := range config.Pipelines {
+		pipelineIDs = append(pipelineIDs, pipelineID)
+		overview.Pipelines[pipelineID] = PipelineOverview{
+			ID:     pipelineID,
+			Stages: config.pipelineStages(pipelineID),
+		}
+	}
+	sort.Strings(pipelineIDs)
+
+	var workflowIDs []string
+	referenced := map[string]bool{}
+	for workflowID, workflow := range config.Workflows {
+		workflowIDs = append(workflowIDs, workflowID)
+		workflowOverview := WorkflowOverview{
+			ID:          workflowID,
+			Title:       workflow.Title,
+			Summary:     workflow.Summary,
+			Description: workflow.Description,
+			IsUtility:   isUtilityWorkflow(workflowID),
+			RunChain:    config.RunChain(workflowID),
+			StepCount:   len(workflow.Steps),
+		}
+		for _, chainWorkflowID := range workflowOverview.RunChain {
+			workflowOverview.ChainStepCount += len(config.Workflows[chainWorkflowID].Steps)
+		}
+		overview.Workflows[workflowID] = workflowOverview
+
+		for _, referencedID := range append(append([]string{}, workflow.BeforeRun...), workflow.AfterRun...) {
+			if referencedID != workflowID {
+				referenced[referencedID] = true
+			}
+		}
+	}
+
+	sort.Strings(workflowIDs)
+
+	for _, pipelineID := range pipelineIDs {
+		for _, workflowID := range config.PipelineWorkflows(pipelineID) {
+			referenced[workflowID] = true
+			overview.addWorkflowReach(config, workflowID, func(workflowOverview *WorkflowOverview) {
+				workflowOverview.Pipelines = appendIfMissing(workflowOverview.Pipelines, pipelineID)
+			})
+		}
+	}
+
+	for idx, item := range config.TriggerMap {
+		itemDescription := fmt.Sprintf("trigger_map[%d] (%s)", idx, item.String(false))
+		addTrigger := func(workflowOverview *WorkflowOverview) {
+			workflowOverview.TriggeredBy = appendIfMissing(workflowOverview.TriggeredBy, itemDescription)
+		}
+
+		if item.PipelineID != "" {
+			if pipelineOverview, ok := overview.Pipelines[item.PipelineID]; ok {
+				pipelineOverview.TriggeredBy = append(pipelineOverview.TriggeredBy, itemDescription)
+				overview.Pipelines[item.PipelineID] = pipelineOverview
+			}
+			for _, workflowID := range config.PipelineWorkflows(item.PipelineID) {
+				overview.addWorkflowReach(config, workflowID, addTrigger)
+			}
+		} else if item.WorkflowID != "" {
+			referenced[item.WorkflowID] = true
+			overview.addWorkflowReach(config, item.WorkflowID, addTrigger)
+		}
+	}
+
+	for _, workflowID := range workflowIDs {
+		if !referenced[workflowID] {
+			workflowOverview := overview.Workflows[workflowID]
+			workflowOverview.Unreferenced = true
+			overview.Workflows[workflowID] = workflowOverview
+			overview.UnreferencedWorkflows = append(overview.UnreferencedWorkflows, workflowID)
+		}
+	}
+
+	return overview
+}
+
+// addWorkflowReach updates every workflow in the run chain of the given workflow.
+func (overview ConfigOverview) addWorkflowReach(config BitriseDataModel, workflowID string, update func(*WorkflowOverview)) {
+	for _, chainWorkflowID := range config.RunChain(workflowID) {
+		workflowOverview, ok := overview.Workflows[chainWorkflowID]
+		if !ok {
+			continue
+		}
+		update(&workflowOverview)
+		overview.Workflows[chainWorkflowID] = workflowOverview
+	}
+}
+
+func appendIfMissing(list []string, item string) []string {
+	for _, existing := range list {
+		if existing == item {
+			return list
+		}
+	}
+	return append(list, item)
+}
@@ -0,0 +1,118 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+const overviewTestConfig = `format_version: "12"
+trigger_map:
+- push_branch: master
+  pipeline: release
+- pull_request_source_branch: "*"
+  workflow: test
+pipelines:
+  release:
+    stages:
+    - build: {}
+    - deploy: {}
+stages:
+  build:
+    workflows:
+    - test: {}
+  deploy:
+    workflows:
+    - deploy: {}
+workflows:
+  _setup:
+    steps:
+    - script: {}
+    - script: {}
+  test:
+    before_run: [_setup]
+    steps:
+    - script: {}
+  deploy:
+    before_run: [_setup]
+    after_run: [_notify]
+    steps:
+    - script: {}
+  _notify:
+    steps:
+    - script: {}
+  old: {}
+  _unused: {}
+`
+
+func TestBitriseDataModelOverview(t *testing.T) {
+	var config BitriseDataModel
+	require.NoError(t, yaml.Unmarshal([]byte(overviewTestConfig), &config))
+
+	overview := config.Overview()
+
+	t.Log("pipelines")
+	{
+		require.Equal(t, map[string]PipelineOverview{
+			"release": {
+				ID: "release",
+				Stages: []StageOverview{
+					{ID: "build", Workflows: []string{"test"}},
+					{ID: "deploy", Workflows: []string{"deploy"}},
+				},
+				TriggeredBy: []string{"trigger_map[0] (push_branch: master)"},
+			},
+		}, overview.Pipelines)
+	}
+
+	t.Log("resolved run chain and step count")
+	{
+		deploy := overview.Workflows["deploy"]
+		require.Equal(t, []string{"_setup", "deploy", "_notify"}, deploy.RunChain)
+		require.Equal(t, 1, deploy.StepCount)
+		require.Equal(t, 4, deploy.ChainStepCount)
+		require.Equal(t, []string{"release"}, deploy.Pipelines)
+		require.False(t, deploy.IsUtility)
+	}
+
+	t.Log("trigger items reaching the workflow through pipelines and before_run")
+	{
+		setup := overview.Workflows["_setup"]
+		require.True(t, setup.IsUtility)
+		require.Equal(t, []string{
+			"trigger_map[0] (push_branch: master)",
+			"trigger_map[1] (pull_request_source_branch: *)",
+		}, setup.TriggeredBy)
+	}
+
+	t.Log("unreferenced workflows")
+	{
+		require.Equal(t, []string{"_unused", "old"}, overview.UnreferencedWorkflows)
+		require.True(t, overview.Workflows["old"].Unreferenced)
+		require.False(t, overview.Workflows["_notify"].Unreferenced)
+		require.Nil(t, overview.Workflows["old"].TriggeredBy)
+	}
+}
+
+func TestBitriseDataModelRunChain(t *testing.T) {
+	t.Log("nested before_run and after_run")
+	{
+		config := BitriseDataModel{Workflows: map[string]WorkflowModel{
+			"a": {BeforeRun: []string{"b"}, AfterRun: []string{"c"}},
+			"b": {BeforeRun: []string{"d"}},
+			"c": {},
+			"d": {},
+		}}
+		require.Equal(t, []string{"d", "b", "a", "c"}, config.RunChain("a"))
+	}
+
+	t.Log("reference cycle")
+	{
+		config := BitriseDataModel{Workflows: map[string]WorkflowModel{
+			"a": {BeforeRun: []string{"b"}},
+			"b": {BeforeRun: []string{"a"}},
+		}}
+		require.Equal(t, []string{"b", "a"}, config.RunChain("a"))
+	}
+}
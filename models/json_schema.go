@@ -0,0 +1,188 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	envmanModels "github.com/bitrise-io/envman/models"
+)
+
+// JSONSchemaDraft ...
+const JSONSchemaDraft = "http://json-schema.org/draft-07/schema#"
+
+// JSONSchema is a (draft-07) JSON Schema document or sub-schema.
+type JSONSchema struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	Ref                  string                 `json:"$ref,omitempty"`
+	Title                string                 `json:"title,omitempty"`
+	Description          string                 `json:"description,omitempty"`
+	Type                 interface{}            `json:"type,omitempty"`
+	Format               string                 `json:"format,omitempty"`
+	Properties           map[string]*JSONSchema `json:"properties,omitempty"`
+	AdditionalProperties interface{}            `json:"additionalProperties,omitempty"`
+	MinProperties        int                    `json:"minProperties,omitempty"`
+	MaxProperties        int                    `json:"maxProperties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	Items                *JSONSchema            `json:"items,omitempty"`
+	AnyOf                []*JSONSchema          `json:"anyOf,omitempty"`
+	Definitions          map[string]*JSONSchema `json:"definitions,omitempty"`
+}
+
+var (
+	// list items, which are single key maps: the key is the ID of the referenced step, stage or workflow
+	singleKeyMapTypes = map[reflect.Type]bool{
+		reflect.TypeOf(StepListItemModel{}):     true,
+		reflect.TypeOf(StageListItemModel{}):    true,
+		reflect.TypeOf(WorkflowListItemModel{}): true,
+	}
+	environmentItemType = reflect.TypeOf(envmanModels.EnvironmentItemModel{})
+	timeType            = reflect.TypeOf(time.Time{})
+)
+
+type jsonSchemaGenerator struct {
+	definitions map[string]*JSONSchema
+}
+
+// GenerateJSONSchema generates the JSON Schema of the bitrise config (BitriseDataModel) of the current FormatVersion.
+func GenerateJSONSchema() *JSONSchema {
+	generator := jsonSchemaGenerator{definitions: map[string]*JSONSchema{}}
+
+	rootType := reflect.TypeOf(BitriseDataModel{})
+	generator.schemaForType(rootType)
+
+	root := generator.definitions[rootType.Name()]
+	delete(generator.definitions, rootType.Name())
+
+	root.Schema = JSONSchemaDraft
+	root.Title = "bitrise.yml"
+	root.Description = fmt.Sprintf("Bitrise CLI configuration, format version: %s", FormatVersion)
+	root.Required = []string{"format_version"}
+	// format_version is commonly written as a YAML number (format_version: 11)
+	root.Properties["format_version"].Type = []string{"string", "number"}
+	root.Definitions = generator.definitions
+
+	return root
+}
+
+// GenerateJSONSchemaBytes returns the indented JSON of the generated JSON Schema.
+func GenerateJSONSchemaBytes() ([]byte, error) {
+	schemaBytes, err := json.MarshalIndent(GenerateJSONSchema(), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(schemaBytes, '\n'), nil
+}
+
+func (generator *jsonSchemaGenerator) schemaForType(t reflect.Type) *JSONSchema {
+	if t.Kind() == reflect.Ptr {
+		return generator.schemaForType(t.Elem())
+	}
+
+	switch {
+	case t == timeType:
+		return &JSONSchema{Type: "string", Format: "date-time"}
+	case t == environmentItemType:
+		return generator.environmentItemSchema()
+	case singleKeyMapTypes[t]:
+		return &JSONSchema{
+			Type:                 "object",
+			MinProperties:        1,
+			MaxProperties:        1,
+			AdditionalProperties: generator.nullableSchemaForType(t.Elem()),
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &JSONSchema{Type: "string"}
+	case reflect.Bool:
+		return &JSONSchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &JSONSchema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &JSONSchema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &JSONSchema{Type: "array", Items: generator.schemaForType(t.Elem())}
+	case reflect.Map:
+		schema := &JSONSchema{Type: "object"}
+		if t.Elem().Kind() != reflect.Interface {
+			schema.AdditionalProperties = generator.nullableSchemaForType(t.Elem())
+		}
+		return schema
+	case reflect.Struct:
+		return generator.structSchema(t)
+	}
+
+	// interface{}: any value
+	return &JSONSchema{}
+}
+
+// nullableSchemaForType allows empty (null) values for map values, like an empty workflow: `workflows: { test: }`
+func (generator *jsonSchemaGenerator) nullableSchemaForType(t reflect.Type) *JSONSchema {
+	schema := generator.schemaForType(t)
+	if schema.Ref == "" {
+		return schema
+	}
+	return &JSONSchema{AnyOf: []*JSONSchema{schema, {Type: "null"}}}
+}
+
+func (generator *jsonSchemaGenerator) structSchema(t reflect.Type) *JSONSchema {
+	ref := &JSONSchema{Ref: "#/definitions/" + t.Name()}
+	if _, found := generator.definitions[t.Name()]; found {
+		return ref
+	}
+
+	schema := &JSONSchema{
+		Type:                 "object",
+		Properties:           map[string]*JSONSchema{},
+		AdditionalProperties: false,
+	}
+	// register the definition before processing the fields, to support recursive types
+	generator.definitions[t.Name()] = schema
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+
+		schema.Properties[name] = generator.schemaForType(field.Type)
+	}
+
+	return ref
+}
+
+func (generator *jsonSchemaGenerator) environmentItemSchema() *JSONSchema {
+	name := environmentItemType.Name()
+	ref := &JSONSchema{Ref: "#/definitions/" + name}
+	if _, found := generator.definitions[name]; found {
+		return ref
+	}
+
+	schema := &JSONSchema{
+		Description: "Environment item: a single KEY: value pair, with optional opts.",
+		Type:        "object",
+		Properties:  map[string]*JSONSchema{},
+		// the value of the env item can be any scalar value
+		AdditionalProperties: &JSONSchema{},
+		MinProperties:        1,
+		MaxProperties:        2,
+	}
+	generator.definitions[name] = schema
+
+	schema.Properties[envmanModels.OptionsKey] = generator.schemaForType(reflect.TypeOf(envmanModels.EnvironmentItemOptionsModel{}))
+
+	return ref
+}
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateJSONSchema(t *testing.T) {
+	schema := GenerateJSONSchema()
+
+	t.Log("root properties match the config model")
+	{
+		configType := reflect.TypeOf(BitriseDataModel{})
+		require.Equal(t, configType.NumField(), len(schema.Properties))
+		for i := 0; i < configType.NumField(); i++ {
+			name := strings.Split(configType.Field(i).Tag.Get("yaml"), ",")[0]
+			_, found := schema.Properties[name]
+			require.True(t, found, name)
+		}
+
+		require.Equal(t, []string{"format_version"}, schema.Required)
+		require.Equal(t, "Bitrise CLI configuration, format version: "+FormatVersion, schema.Description)
+	}
+
+	t.Log("step, stage and workflow list items are single key maps")
+	{
+		workflowSchema := schema.Definitions["WorkflowModel"]
+		require.NotNil(t, workflowSchema)
+
+		stepListItemSchema := workflowSchema.Properties["steps"].Items
+		require.Equal(t, 1, stepListItemSchema.MinProperties)
+		require.Equal(t, 1, stepListItemSchema.MaxProperties)
+		require.Equal(t, "#/definitions/StepModel", stepListItemSchema.AdditionalProperties.(*JSONSchema).AnyOf[0].Ref)
+
+		stageListItemSchema := schema.Definitions["PipelineModel"].Properties["stages"].Items
+		require.Equal(t, "#/definitions/StageModel", stageListItemSchema.AdditionalProperties.(*JSONSchema).AnyOf[0].Ref)
+
+		workflowListItemSchema := schema.Definitions["StageModel"].Properties["workflows"].Items
+		require.Equal(t, "#/definitions/WorkflowModel", workflowListItemSchema.AdditionalProperties.(*JSONSchema).AnyOf[0].Ref)
+	}
+
+	t.Log("environment items")
+	{
+		envSchema := schema.Definitions["EnvironmentItemModel"]
+		require.NotNil(t, envSchema)
+		require.Equal(t, "#/definitions/EnvironmentItemOptionsModel", envSchema.Properties["opts"].Ref)
+		require.Equal(t, "#/definitions/EnvironmentItemModel", schema.Definitions["AppModel"].Properties["envs"].Items.Ref)
+	}
+}
+
+func TestJSONSchemaInSync(t *testing.T) {
+	schemaBytes, err := GenerateJSONSchemaBytes()
+	require.NoError(t, err)
+
+	committedSchemaBytes, err := os.ReadFile(filepath.Join("..", "_docs", "bitrise.schema.json"))
+	require.NoError(t, err)
+
+	require.Equal(t, string(committedSchemaBytes), string(schemaBytes), "_docs/bitrise.schema.json is out of date, regenerate it with: bitrise schema --outpath _docs/bitrise.schema.json")
+}
@@ -45,9 +45,47 @@ type WorkflowModel struct {
 	AfterRun     []string                            `json:"after_run,omitempty" yaml:"after_run,omitempty"`
 	Environments []envmanModels.EnvironmentItemModel `json:"envs,omitempty" yaml:"envs,omitempty"`
 	Steps        []StepListItemModel                 `json:"steps,omitempty" yaml:"steps,omitempty"`
+	Container    *ContainerModel                     `json:"container,omitempty" yaml:"container,omitempty"`
+	Services     map[string]ServiceModel             `json:"services,omitempty" yaml:"services,omitempty"`
 	Meta         map[string]interface{}              `json:"meta,omitempty" yaml:"meta,omitempty"`
 }
 
+const (
+	// ContainerEngineDocker ...
+	ContainerEngineDocker = "docker"
+	// ContainerEnginePodman ...
+	ContainerEnginePodman = "podman"
+)
+
+// ContainerModel defines the container image the workflow's steps run in.
+type ContainerModel struct {
+	Image string `json:"image" yaml:"image"`
+	// Engine is the container engine's CLI: docker (default) or podman.
+	Engine string `json:"engine,omitempty" yaml:"engine,omitempty"`
+	// Options are additional container run options (like --network=host).
+	Options []string `json:"options,omitempty" yaml:"options,omitempty"`
+}
+
+// ServiceModel defines a service container (like a database), which runs while the workflow's steps run.
+type ServiceModel struct {
+	Image string `json:"image" yaml:"image"`
+	// Engine is the container engine's CLI: docker (default) or podman.
+	Engine string `json:"engine,omitempty" yaml:"engine,omitempty"`
+	// Ports are the published ports, in HOST_PORT:CONTAINER_PORT or PORT format.
+	Ports   []string                            `json:"ports,omitempty" yaml:"ports,omitempty"`
+	Envs    []envmanModels.EnvironmentItemModel `json:"envs,omitempty" yaml:"envs,omitempty"`
+	Options []string                            `json:"options,omitempty" yaml:"options,omitempty"`
+	// HealthCheck is the command (run in the service container) which succeeds once the service is ready.
+	HealthCheck *ServiceHealthCheckModel `json:"health_check,omitempty" yaml:"health_check,omitempty"`
+}
+
+// ServiceHealthCheckModel ...
+type ServiceHealthCheckModel struct {
+	Command string `json:"command" yaml:"command"`
+	// Timeout is the maximum wait time in seconds, 60 by default.
+	Timeout int `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+}
+
 // AppModel ...
 type AppModel struct {
 	Title        string                              `json:"title,omitempty" yaml:"title,omitempty"`
@@ -66,6 +104,8 @@ const (
 	TriggerEventTypePullRequest TriggerEventType = "pull-request"
 	// TriggerEventTypeTag ...
 	TriggerEventTypeTag TriggerEventType = "tag"
+	// TriggerEventTypeSchedule ...
+	TriggerEventTypeSchedule TriggerEventType = "schedule"
 	// TriggerEventTypeUnknown ...
 	TriggerEventTypeUnknown TriggerEventType = "unknown"
 )
@@ -73,17 +113,73 @@ const (
 // TriggerMapItemModel ...
 type TriggerMapItemModel struct {
 	PushBranch              string `json:"push_branch,omitempty" yaml:"push_branch,omitempty"`
+	PushBranchRegex         string `json:"push_branch_regex,omitempty" yaml:"push_branch_regex,omitempty"`
 	PullRequestSourceBranch string `json:"pull_request_source_branch,omitempty" yaml:"pull_request_source_branch,omitempty"`
 	PullRequestTargetBranch string `json:"pull_request_target_branch,omitempty" yaml:"pull_request_target_branch,omitempty"`
 	Tag                     string `json:"tag,omitempty" yaml:"tag,omitempty"`
+	TagRegex                string `json:"tag_regex,omitempty" yaml:"tag_regex,omitempty"`
+	Schedule                string `json:"schedule,omitempty" yaml:"schedule,omitempty"`
 	PipelineID              string `json:"pipeline,omitempty" yaml:"pipeline,omitempty"`
 	WorkflowID              string `json:"workflow,omitempty" yaml:"workflow,omitempty"`
 
+	ChangedFiles            *ChangedFilesFilterModel  `json:"changed_files,omitempty" yaml:"changed_files,omitempty"`
+	CommitMessage           *CommitMessageFilterModel `json:"commit_message,omitempty" yaml:"commit_message,omitempty"`
+	PullRequestLabel        string                    `json:"pull_request_label,omitempty" yaml:"pull_request_label,omitempty"`
+	DraftPullRequestEnabled *bool                     `json:"draft_pull_request_enabled,omitempty" yaml:"draft_pull_request_enabled,omitempty"`
+
 	// deprecated
 	Pattern              string `json:"pattern,omitempty" yaml:"pattern,omitempty"`
 	IsPullRequestAllowed bool   `json:"is_pull_request_allowed,omitempty" yaml:"is_pull_request_allowed,omitempty"`
 }
 
+// ChangedFilesFilterModel restricts a trigger map item to the events, which change at least one file
+// that matches any of the Include patterns (every file, if no Include pattern is defined) and none of the Exclude patterns.
+type ChangedFilesFilterModel struct {
+	Include []string `json:"include,omitempty" yaml:"include,omitempty"`
+	Exclude []string `json:"exclude,omitempty" yaml:"exclude,omitempty"`
+}
+
+// CommitMessageFilterModel restricts a trigger map item to the events, whose commit message
+// matches any of the Include patterns (every message, if no Include pattern is defined) and none of the Exclude patterns.
+type CommitMessageFilterModel struct {
+	Include []string `json:"include,omitempty" yaml:"include,omitempty"`
+	Exclude []string `json:"exclude,omitempty" yaml:"exclude,omitempty"`
+}
+
+// TriggerEventModel describes the git event, which is matched against the trigger map items.
+type TriggerEventModel struct {
+	PushBranch     string
+	PRSourceBranch string
+	PRTargetBranch string
+	Tag            string
+	// ChangedFiles is nil if the changed files of the event are unknown,
+	// in this case the changed_files filters of the trigger map items are not evaluated.
+	ChangedFiles []string
+	// CommitMessage is empty if the commit message of the event is unknown,
+	// in this case the commit_message filters of the trigger map items are not evaluated.
+	CommitMessage string
+	// PRLabels is nil if the labels of the pull request are unknown,
+	// in this case the pull_request_label conditions of the trigger map items are not evaluated.
+	PRLabels  []string
+	IsDraftPR bool
+}
+
+// TriggerTestModel is a sample git event with the pipeline or workflow it is expected to trigger,
+// if neither the pipeline nor the workflow is defined, the event is expected to trigger nothing.
+type TriggerTestModel struct {
+	Title                   string   `json:"title,omitempty" yaml:"title,omitempty"`
+	PushBranch              string   `json:"push_branch,omitempty" yaml:"push_branch,omitempty"`
+	PullRequestSourceBranch string   `json:"pull_request_source_branch,omitempty" yaml:"pull_request_source_branch,omitempty"`
+	PullRequestTargetBranch string   `json:"pull_request_target_branch,omitempty" yaml:"pull_request_target_branch,omitempty"`
+	Tag                     string   `json:"tag,omitempty" yaml:"tag,omitempty"`
+	ChangedFiles            []string `json:"changed_files,omitempty" yaml:"changed_files,omitempty"`
+	CommitMessage           string   `json:"commit_message,omitempty" yaml:"commit_message,omitempty"`
+	PullRequestLabels       []string `json:"pull_request_labels,omitempty" yaml:"pull_request_labels,omitempty"`
+	DraftPullRequest        bool     `json:"draft_pull_request,omitempty" yaml:"draft_pull_request,omitempty"`
+	PipelineID              string   `json:"pipeline,omitempty" yaml:"pipeline,omitempty"`
+	WorkflowID              string   `json:"workflow,omitempty" yaml:"workflow,omitempty"`
+}
+
 // TriggerMapModel ...
 type TriggerMapModel []TriggerMapItemModel
 
@@ -97,12 +193,28 @@ type BitriseDataModel struct {
 	Summary     string `json:"summary,omitempty" yaml:"summary,omitempty"`
 	Description string `json:"description,omitempty" yaml:"description,omitempty"`
 	//
-	App        AppModel                 `json:"app,omitempty" yaml:"app,omitempty"`
-	Meta       map[string]interface{}   `json:"meta,omitempty" yaml:"meta,omitempty"`
-	TriggerMap TriggerMapModel          `json:"trigger_map,omitempty" yaml:"trigger_map,omitempty"`
-	Pipelines  map[string]PipelineModel `json:"pipelines,omitempty" yaml:"pipelines,omitempty"`
-	Stages     map[string]StageModel    `json:"stages,omitempty" yaml:"stages,omitempty"`
-	Workflows  map[string]WorkflowModel `json:"workflows,omitempty" yaml:"workflows,omitempty"`
+	App          AppModel                 `json:"app,omitempty" yaml:"app,omitempty"`
+	Meta         map[string]interface{}   `json:"meta,omitempty" yaml:"meta,omitempty"`
+	TriggerMap   TriggerMapModel          `json:"trigger_map,omitempty" yaml:"trigger_map,omitempty"`
+	TriggerTests []TriggerTestModel       `json:"trigger_tests,omitempty" yaml:"trigger_tests,omitempty"`
+	Pipelines    map[string]PipelineModel `json:"pipelines,omitempty" yaml:"pipelines,omitempty"`
+	Stages       map[string]StageModel    `json:"stages,omitempty" yaml:"stages,omitempty"`
+	Workflows    map[string]WorkflowModel `json:"workflows,omitempty" yaml:"workflows,omitempty"`
+	// GitSteps are the integrity verifications of the git steps, keyed by the step references (like git::URL@REF).
+	GitSteps map[string]GitStepModel `json:"git_steps,omitempty" yaml:"git_steps,omitempty"`
+}
+
+// GitStepModel defines how a git step (git::URL@REF or _::URL@REF) is verified after cloning.
+type GitStepModel struct {
+	// Commit is the expected commit hash of the step's ref.
+	Commit string `json:"commit,omitempty" yaml:"commit,omitempty"`
+	// Tree is the expected tree hash of the step's ref, it does not change when the commit is rewritten with the same content.
+	Tree string `json:"tree,omitempty" yaml:"tree,omitempty"`
+	// VerifyTagSignature requires the step's ref to be a tag, signed with a key of the keyring.
+	VerifyTagSignature bool `json:"verify_tag_signature,omitempty" yaml:"verify_tag_signature,omitempty"`
+	// Keyring is the path of the trusted public keys, exported with gpg --export.
+	// BITRISE_GIT_STEP_KEYRING is used if not defined.
+	Keyring string `json:"keyring,omitempty" yaml:"keyring,omitempty"`
 }
 
 // StepIDData ...
@@ -3,7 +3,9 @@ package models
 import (
 	"errors"
 	"fmt"
+	"reflect"
 	"regexp"
+	"strconv"
 	"strings"
 
 	envmanModels "github.com/bitrise-io/envman/models"
@@ -20,6 +22,14 @@ func (triggerItem TriggerMapItemModel) String(printTarget bool) string {
 		str = fmt.Sprintf("push_branch: %s", triggerItem.PushBranch)
 	}
 
+	if triggerItem.PushBranchRegex != "" {
+		if str != "" {
+			str += " "
+		}
+
+		str += fmt.Sprintf("push_branch_regex: %s", triggerItem.PushBranchRegex)
+	}
+
 	if triggerItem.PullRequestSourceBranch != "" || triggerItem.PullRequestTargetBranch != "" {
 		if str != "" {
 			str += " "
@@ -45,6 +55,22 @@ func (triggerItem TriggerMapItemModel) String(printTarget bool) string {
 		str += fmt.Sprintf("tag: %s", triggerItem.Tag)
 	}
 
+	if triggerItem.TagRegex != "" {
+		if str != "" {
+			str += " "
+		}
+
+		str += fmt.Sprintf("tag_regex: %s", triggerItem.TagRegex)
+	}
+
+	if triggerItem.Schedule != "" {
+		if str != "" {
+			str += " "
+		}
+
+		str += fmt.Sprintf("schedule: %s", triggerItem.Schedule)
+	}
+
 	if triggerItem.Pattern != "" {
 		if str != "" {
 			str += " "
@@ -53,6 +79,38 @@ func (triggerItem TriggerMapItemModel) String(printTarget bool) string {
 		str += fmt.Sprintf("pattern: %s && is_pull_request_allowed: %v", triggerItem.Pattern, triggerItem.IsPullRequestAllowed)
 	}
 
+	if triggerItem.ChangedFiles != nil {
+		if str != "" {
+			str += " "
+		}
+
+		str += fmt.Sprintf("changed_files: %s", triggerItem.ChangedFiles)
+	}
+
+	if triggerItem.CommitMessage != nil {
+		if str != "" {
+			str += " "
+		}
+
+		str += fmt.Sprintf("commit_message: %s", triggerItem.CommitMessage)
+	}
+
+	if triggerItem.PullRequestLabel != "" {
+		if str != "" {
+			str += " "
+		}
+
+		str += fmt.Sprintf("pull_request_label: %s", triggerItem.PullRequestLabel)
+	}
+
+	if triggerItem.DraftPullRequestEnabled != nil {
+		if str != "" {
+			str += " "
+		}
+
+		str += fmt.Sprintf("draft_pull_request_enabled: %v", *triggerItem.DraftPullRequestEnabled)
+	}
+
 	if printTarget {
 		if triggerItem.PipelineID != "" {
 			str += fmt.Sprintf(" -> pipeline: %s", triggerItem.PipelineID)
@@ -110,8 +168,186 @@ func migrateDeprecatedTriggerItem(triggerItem TriggerMapItemModel) []TriggerMapI
 	return migratedItems
 }
 
+func includeExcludeString(include, exclude []string) string {
+	var parts []string
+	if len(include) > 0 {
+		parts = append(parts, fmt.Sprintf("include: [%s]", strings.Join(include, ", ")))
+	}
+	if len(exclude) > 0 {
+		parts = append(parts, fmt.Sprintf("exclude: [%s]", strings.Join(exclude, ", ")))
+	}
+	if len(parts) == 0 {
+		return "{}"
+	}
+	return strings.Join(parts, " && ")
+}
+
+func validateIncludeExclude(include, exclude []string) error {
+	if len(include) == 0 && len(exclude) == 0 {
+		return errors.New("neither include nor exclude pattern defined")
+	}
+	for _, pattern := range append(append([]string{}, include...), exclude...) {
+		if pattern == "" {
+			return errors.New("empty pattern defined")
+		}
+	}
+	return nil
+}
+
+// matchIncludeExclude returns true if the value matches any of the include patterns
+// (every value is included if no include pattern is defined) and none of the exclude patterns.
+func matchIncludeExclude(include, exclude []string, value string) bool {
+	matchAny := func(patterns []string) bool {
+		for _, pattern := range patterns {
+			if glob.Glob(pattern, value) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if len(include) > 0 && !matchAny(include) {
+		return false
+	}
+	return !matchAny(exclude)
+}
+
+// String ...
+func (filter ChangedFilesFilterModel) String() string {
+	return includeExcludeString(filter.Include, filter.Exclude)
+}
+
+// Validate ...
+func (filter ChangedFilesFilterModel) Validate() error {
+	return validateIncludeExclude(filter.Include, filter.Exclude)
+}
+
+// Match returns true if any of the changed files matches any of the include patterns
+// (every file is included if no include pattern is defined) and none of the exclude patterns.
+// The patterns follow the glob rules of the branch filters: * matches any sequence of characters, including the path separator.
+func (filter ChangedFilesFilterModel) Match(changedFiles []string) bool {
+	for _, pth := range changedFiles {
+		if matchIncludeExclude(filter.Include, filter.Exclude, pth) {
+			return true
+		}
+	}
+	return false
+}
+
+// String ...
+func (filter CommitMessageFilterModel) String() string {
+	return includeExcludeString(filter.Include, filter.Exclude)
+}
+
+// Validate ...
+func (filter CommitMessageFilterModel) Validate() error {
+	return validateIncludeExclude(filter.Include, filter.Exclude)
+}
+
+// Match returns true if the commit message matches any of the include patterns
+// (every message is included if no include pattern is defined) and none of the exclude patterns.
+// The patterns follow the glob rules of the branch filters, like: *[skip ci]*.
+func (filter CommitMessageFilterModel) Match(commitMessage string) bool {
+	return matchIncludeExclude(filter.Include, filter.Exclude, commitMessage)
+}
+
 // MatchWithParams ...
 func (triggerItem TriggerMapItemModel) MatchWithParams(pushBranch, prSourceBranch, prTargetBranch, tag string) (bool, error) {
+	return triggerItem.Match(TriggerEventModel{
+		PushBranch:     pushBranch,
+		PRSourceBranch: prSourceBranch,
+		PRTargetBranch: prTargetBranch,
+		Tag:            tag,
+	})
+}
+
+// Match returns true if the trigger item matches the given event,
+// the changed_files, commit_message and pull_request_label conditions are only evaluated if the related event properties are known.
+func (triggerItem TriggerMapItemModel) Match(event TriggerEventModel) (bool, error) {
+	match, err := triggerItem.matchBranchesAndTag(event.PushBranch, event.PRSourceBranch, event.PRTargetBranch, event.Tag)
+	if err != nil || !match {
+		return false, err
+	}
+
+	if triggerItem.ChangedFiles != nil && event.ChangedFiles != nil && !triggerItem.ChangedFiles.Match(event.ChangedFiles) {
+		return false, nil
+	}
+
+	if triggerItem.CommitMessage != nil && event.CommitMessage != "" && !triggerItem.CommitMessage.Match(event.CommitMessage) {
+		return false, nil
+	}
+
+	if triggerItem.PullRequestLabel != "" && event.PRLabels != nil {
+		labelMatch := false
+		for _, label := range event.PRLabels {
+			if glob.Glob(triggerItem.PullRequestLabel, label) {
+				labelMatch = true
+				break
+			}
+		}
+		if !labelMatch {
+			return false, nil
+		}
+	}
+
+	if triggerItem.DraftPullRequestEnabled != nil && !*triggerItem.DraftPullRequestEnabled && event.IsDraftPR {
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// UnevaluatedConditionsWarning returns a warning if the trigger item has conditions, which are not evaluated against the event
+// (so they do not prevent the item from matching), as the related event properties (like the changed files) are unknown.
+func (triggerItem TriggerMapItemModel) UnevaluatedConditionsWarning(idx int, event TriggerEventModel) string {
+	var conditions []string
+	if triggerItem.ChangedFiles != nil && event.ChangedFiles == nil {
+		conditions = append(conditions, "changed_files")
+	}
+	if triggerItem.CommitMessage != nil && event.CommitMessage == "" {
+		conditions = append(conditions, "commit_message")
+	}
+	if triggerItem.PullRequestLabel != "" && event.PRLabels == nil {
+		conditions = append(conditions, "pull_request_label")
+	}
+	if len(conditions) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("trigger_map[%d] (%s) matches without evaluating its %s condition(s), as the related event properties are unknown",
+		idx, triggerItem.String(true), strings.Join(conditions, ", "))
+}
+
+// eventType returns the type of the events selected by the trigger item, including the regex and schedule conditions.
+func (triggerItem TriggerMapItemModel) eventType() (TriggerEventType, error) {
+	if triggerItem.Schedule != "" {
+		if triggerItem.PushBranch != "" || triggerItem.PushBranchRegex != "" ||
+			triggerItem.PullRequestSourceBranch != "" || triggerItem.PullRequestTargetBranch != "" ||
+			triggerItem.Tag != "" || triggerItem.TagRegex != "" {
+			return TriggerEventTypeUnknown, fmt.Errorf("schedule (%s) selects scheduled trigger event, but branch or tag conditions also provided", triggerItem.Schedule)
+		}
+		return TriggerEventTypeSchedule, nil
+	}
+
+	pushBranch := triggerItem.PushBranch
+	if pushBranch == "" {
+		pushBranch = triggerItem.PushBranchRegex
+	}
+	tag := triggerItem.Tag
+	if tag == "" {
+		tag = triggerItem.TagRegex
+	}
+	return triggerEventType(pushBranch, triggerItem.PullRequestSourceBranch, triggerItem.PullRequestTargetBranch, tag)
+}
+
+// hasSameConditions returns true if the non branch and tag related conditions of the items are the same.
+func (triggerItem TriggerMapItemModel) hasSameConditions(other TriggerMapItemModel) bool {
+	return reflect.DeepEqual(triggerItem.ChangedFiles, other.ChangedFiles) &&
+		reflect.DeepEqual(triggerItem.CommitMessage, other.CommitMessage) &&
+		triggerItem.PullRequestLabel == other.PullRequestLabel &&
+		reflect.DeepEqual(triggerItem.DraftPullRequestEnabled, other.DraftPullRequestEnabled)
+}
+
+func (triggerItem TriggerMapItemModel) matchBranchesAndTag(pushBranch, prSourceBranch, prTargetBranch, tag string) (bool, error) {
 	paramsEventType, err := triggerEventType(pushBranch, prSourceBranch, prTargetBranch, tag)
 	if err != nil {
 		return false, err
@@ -123,7 +359,7 @@ func (triggerItem TriggerMapItemModel) MatchWithParams(pushBranch, prSourceBranc
 	}
 
 	for _, migratedTriggerItem := range migratedTriggerItems {
-		itemEventType, err := triggerEventType(migratedTriggerItem.PushBranch, migratedTriggerItem.PullRequestSourceBranch, migratedTriggerItem.PullRequestTargetBranch, migratedTriggerItem.Tag)
+		itemEventType, err := migratedTriggerItem.eventType()
 		if err != nil {
 			return false, err
 		}
@@ -134,6 +370,9 @@ func (triggerItem TriggerMapItemModel) MatchWithParams(pushBranch, prSourceBranc
 
 		switch itemEventType {
 		case TriggerEventTypeCodePush:
+			if migratedTriggerItem.PushBranchRegex != "" {
+				return regexp.MatchString(migratedTriggerItem.PushBranchRegex, pushBranch)
+			}
 			match := glob.Glob(migratedTriggerItem.PushBranch, pushBranch)
 			return match, nil
 		case TriggerEventTypePullRequest:
@@ -153,6 +392,9 @@ func (triggerItem TriggerMapItemModel) MatchWithParams(pushBranch, prSourceBranc
 
 			return (sourceMatch && targetMatch), nil
 		case TriggerEventTypeTag:
+			if migratedTriggerItem.TagRegex != "" {
+				return regexp.MatchString(migratedTriggerItem.TagRegex, tag)
+			}
 			match := glob.Glob(migratedTriggerItem.Tag, tag)
 			return match, nil
 		}
@@ -231,6 +473,14 @@ func (workflow *WorkflowModel) Normalize() error {
 		}
 	}
 
+	for _, service := range workflow.Services {
+		for _, env := range service.Envs {
+			if err := env.Normalize(); err != nil {
+				return err
+			}
+		}
+	}
+
 	for _, stepListItem := range workflow.Steps {
 		stepID, step, err := GetStepIDStepDataPair(stepListItem)
 		if err != nil {
@@ -280,39 +530,65 @@ func (config *BitriseDataModel) Normalize() error {
 
 // Validate ...
 func (workflow *WorkflowModel) Validate() ([]string, error) {
-	for _, env := range workflow.Environments {
+	issues, err := workflow.validate("", nil)
+	return ValidationIssueMessages(issues), err
+}
+
+// serviceNameRegexp matches the service names, which are used in the service container names and connection env keys.
+var serviceNameRegexp = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+func (workflow *WorkflowModel) validate(path string, sourceMap SourceMap) ([]ValidationIssue, error) {
+	for idx, env := range workflow.Environments {
 		if err := env.Validate(); err != nil {
-			return []string{}, err
+			return []ValidationIssue{}, newValidationIssue(sourceMap, fmt.Sprintf("%s.envs[%d]", path, idx), "%s", err)
 		}
 	}
 
-	warnings := []string{}
-	for _, stepListItem := range workflow.Steps {
+	if workflow.Container != nil {
+		if err := workflow.Container.Validate(); err != nil {
+			return []ValidationIssue{}, newValidationIssue(sourceMap, path+".container", "%s", err)
+		}
+	}
+
+	for name, service := range workflow.Services {
+		if !serviceNameRegexp.MatchString(name) {
+			return []ValidationIssue{}, newValidationIssue(sourceMap, path+".services", "invalid service name (%s), only letters, digits, '_' and '-' are allowed", name)
+		}
+		if err := service.Validate(); err != nil {
+			return []ValidationIssue{}, newValidationIssue(sourceMap, path+".services."+name, "%s", err)
+		}
+	}
+
+	warnings := []ValidationIssue{}
+	for idx, stepListItem := range workflow.Steps {
+		stepPath := fmt.Sprintf("%s.steps[%d]", path, idx)
+
 		stepID, step, err := GetStepIDStepDataPair(stepListItem)
 		if err != nil {
-			return warnings, err
+			return warnings, newValidationIssue(sourceMap, stepPath, "%s", err)
 		}
 
 		if ver, src := getStepVersion(stepID), getStepSource(stepID); len(ver) > 0 && isStepLibSource(src) {
 			if _, err := stepmanModels.ParseRequiredVersion(ver); err != nil {
-				return warnings, fmt.Errorf("invalid version format (%s) specified for step ID: %s", ver, stepID)
+				return warnings, newValidationIssue(sourceMap, stepPath, "invalid version format (%s) specified for step ID: %s", ver, stepID)
 			}
 		}
 
 		if err := step.ValidateInputAndOutputEnvs(false); err != nil {
-			return warnings, err
+			return warnings, newValidationIssue(sourceMap, stepPath, "%s", err)
 		}
 
 		stepInputMap := map[string]bool{}
-		for _, input := range step.Inputs {
+		for inputIdx, input := range step.Inputs {
 			key, _, err := input.GetKeyValuePair()
 			if err != nil {
-				return warnings, err
+				return warnings, newValidationIssue(sourceMap, stepPath, "%s", err)
 			}
 
 			_, found := stepInputMap[key]
 			if found {
-				warnings = append(warnings, fmt.Sprintf("invalid step: duplicated input found: (%s)", key))
+				inputPath := fmt.Sprintf("%s.%s.inputs[%d]", stepPath, stepID, inputIdx)
+				warnings = append(warnings, newValidationIssue(sourceMap, inputPath, "invalid step: duplicated input found: (%s)", key))
 			}
 			stepInputMap[key] = true
 		}
@@ -323,6 +599,129 @@ func (workflow *WorkflowModel) Validate() ([]string, error) {
 	return warnings, nil
 }
 
+// Validate ...
+func (container ContainerModel) Validate() error {
+	if container.Image == "" {
+		return errors.New("container image not defined")
+	}
+	if container.Engine != "" && container.Engine != ContainerEngineDocker && container.Engine != ContainerEnginePodman {
+		return fmt.Errorf("invalid container engine (%s), supported engines: %s, %s", container.Engine, ContainerEngineDocker, ContainerEnginePodman)
+	}
+	return nil
+}
+
+// Validate ...
+func (service ServiceModel) Validate() error {
+	if service.Image == "" {
+		return errors.New("service image not defined")
+	}
+	if service.Engine != "" && service.Engine != ContainerEngineDocker && service.Engine != ContainerEnginePodman {
+		return fmt.Errorf("invalid container engine (%s), supported engines: %s, %s", service.Engine, ContainerEngineDocker, ContainerEnginePodman)
+	}
+	for _, port := range service.Ports {
+		if _, _, err := ParseServicePort(port); err != nil {
+			return err
+		}
+	}
+	for _, env := range service.Envs {
+		if err := env.Validate(); err != nil {
+			return err
+		}
+	}
+	if service.HealthCheck != nil {
+		if service.HealthCheck.Command == "" {
+			return errors.New("health check command not defined")
+		}
+		if service.HealthCheck.Timeout < 0 {
+			return fmt.Errorf("invalid health check timeout (%d)", service.HealthCheck.Timeout)
+		}
+	}
+	return nil
+}
+
+// gitHashRegexp matches the (abbreviated) SHA-1 and SHA-256 git object hashes.
+var gitHashRegexp = regexp.MustCompile(`^[0-9a-fA-F]{7,64}$`)
+
+// Validate ...
+func (gitStep GitStepModel) Validate() error {
+	if gitStep.Commit == "" && gitStep.Tree == "" && !gitStep.VerifyTagSignature {
+		return errors.New("no verification defined, commit, tree or verify_tag_signature is required")
+	}
+	if gitStep.Commit != "" && !gitHashRegexp.MatchString(gitStep.Commit) {
+		return fmt.Errorf("invalid commit hash (%s)", gitStep.Commit)
+	}
+	if gitStep.Tree != "" && !gitHashRegexp.MatchString(gitStep.Tree) {
+		return fmt.Errorf("invalid tree hash (%s)", gitStep.Tree)
+	}
+	return nil
+}
+
+func validateGitSteps(config *BitriseDataModel, sourceMap SourceMap) ([]ValidationIssue, error) {
+	warnings := []ValidationIssue{}
+
+	referencedSteps := map[string]bool{}
+	for _, workflow := range config.Workflows {
+		for _, stepListItem := range workflow.Steps {
+			for stepID := range stepListItem {
+				referencedSteps[stepID] = true
+			}
+		}
+	}
+
+	for stepID, gitStep := range config.GitSteps {
+		gitStepPath := "git_steps." + stepID
+
+		stepIDData, err := CreateStepIDDataFromString(stepID, "")
+		if err != nil || (stepIDData.SteplibSource != "git" && stepIDData.SteplibSource != "_") {
+			return warnings, newValidationIssue(sourceMap, gitStepPath, "invalid git step reference (%s), should be git::URL@REF or _::URL@REF", stepID)
+		}
+		if err := gitStep.Validate(); err != nil {
+			return warnings, newValidationIssue(sourceMap, gitStepPath, "%s", err)
+		}
+		if gitStep.VerifyTagSignature && stepIDData.Version == "" {
+			return warnings, newValidationIssue(sourceMap, gitStepPath, "tag signature verification requires a tag reference (%s@TAG)", stepID)
+		}
+
+		if !referencedSteps[stepID] {
+			warnings = append(warnings, newValidationIssue(sourceMap, gitStepPath, "git step (%s) is not used by any workflow", stepID))
+		}
+	}
+
+	return warnings, nil
+}
+
+// GitStep returns the verification of the git step, if defined.
+func (config *BitriseDataModel) GitStep(stepIDData StepIDData) (GitStepModel, bool) {
+	for stepID, gitStep := range config.GitSteps {
+		if data, err := CreateStepIDDataFromString(stepID, ""); err == nil && data == stepIDData {
+			return gitStep, true
+		}
+	}
+	return GitStepModel{}, false
+}
+
+// ParseServicePort parses a HOST_PORT:CONTAINER_PORT or PORT (same port on the host and in the container) port mapping.
+func ParseServicePort(port string) (int, int, error) {
+	split := strings.Split(port, ":")
+	if len(split) > 2 {
+		return 0, 0, fmt.Errorf("invalid port (%s), should be in HOST_PORT:CONTAINER_PORT or PORT format", port)
+	}
+
+	var ports []int
+	for _, str := range split {
+		p, err := strconv.Atoi(str)
+		if err != nil || p < 1 || p > 65535 {
+			return 0, 0, fmt.Errorf("invalid port (%s), should be in HOST_PORT:CONTAINER_PORT or PORT format", port)
+		}
+		ports = append(ports, p)
+	}
+
+	if len(ports) == 1 {
+		return ports[0], ports[0], nil
+	}
+	return ports[0], ports[1], nil
+}
+
 // Validate ...
 func (app *AppModel) Validate() error {
 	for _, env := range app.Environments {
@@ -343,13 +742,56 @@ func (triggerItem TriggerMapItemModel) Validate() error {
 	}
 
 	if triggerItem.Pattern == "" {
-		_, err := triggerEventType(triggerItem.PushBranch, triggerItem.PullRequestSourceBranch, triggerItem.PullRequestTargetBranch, triggerItem.Tag)
+		if triggerItem.PushBranch != "" && triggerItem.PushBranchRegex != "" {
+			return fmt.Errorf("trigger map item (%s) validate failed, error: push_branch and push_branch_regex both defined", triggerItem.String(true))
+		}
+		if triggerItem.Tag != "" && triggerItem.TagRegex != "" {
+			return fmt.Errorf("trigger map item (%s) validate failed, error: tag and tag_regex both defined", triggerItem.String(true))
+		}
+		if _, err := regexp.Compile(triggerItem.PushBranchRegex); err != nil {
+			return fmt.Errorf("trigger map item (%s) validate failed, error: invalid push_branch_regex: %s", triggerItem.String(true), err)
+		}
+		if _, err := regexp.Compile(triggerItem.TagRegex); err != nil {
+			return fmt.Errorf("trigger map item (%s) validate failed, error: invalid tag_regex: %s", triggerItem.String(true), err)
+		}
+
+		eventType, err := triggerItem.eventType()
 		if err != nil {
 			return fmt.Errorf("trigger map item (%s) validate failed, error: %s", triggerItem.String(true), err)
 		}
-	} else if triggerItem.PushBranch != "" ||
-		triggerItem.PullRequestSourceBranch != "" || triggerItem.PullRequestTargetBranch != "" || triggerItem.Tag != "" {
-		return fmt.Errorf("deprecated trigger item (pattern defined), mixed with trigger params (push_branch: %s, pull_request_source_branch: %s, pull_request_target_branch: %s, tag: %s)", triggerItem.PushBranch, triggerItem.PullRequestSourceBranch, triggerItem.PullRequestTargetBranch, triggerItem.Tag)
+
+		if eventType != TriggerEventTypePullRequest && (triggerItem.PullRequestLabel != "" || triggerItem.DraftPullRequestEnabled != nil) {
+			return fmt.Errorf("trigger map item (%s) validate failed, error: pull_request_label and draft_pull_request_enabled are only supported on pull request trigger items", triggerItem.String(true))
+		}
+
+		if eventType == TriggerEventTypeSchedule {
+			if triggerItem.ChangedFiles != nil || triggerItem.CommitMessage != nil {
+				return fmt.Errorf("trigger map item (%s) validate failed, error: changed_files and commit_message are not supported on schedule trigger items", triggerItem.String(true))
+			}
+			if _, err := ParseCronSchedule(triggerItem.Schedule); err != nil {
+				return fmt.Errorf("trigger map item (%s) validate failed, error: invalid schedule: %s", triggerItem.String(true), err)
+			}
+		}
+	} else if triggerItem.PushBranch != "" || triggerItem.PushBranchRegex != "" ||
+		triggerItem.PullRequestSourceBranch != "" || triggerItem.PullRequestTargetBranch != "" || triggerItem.Tag != "" || triggerItem.TagRegex != "" || triggerItem.Schedule != "" {
+		return fmt.Errorf("deprecated trigger item (pattern defined), mixed with trigger params (push_branch: %s, push_branch_regex: %s, pull_request_source_branch: %s, pull_request_target_branch: %s, tag: %s, tag_regex: %s, schedule: %s)", triggerItem.PushBranch, triggerItem.PushBranchRegex, triggerItem.PullRequestSourceBranch, triggerItem.PullRequestTargetBranch, triggerItem.Tag, triggerItem.TagRegex, triggerItem.Schedule)
+	} else if triggerItem.CommitMessage != nil || triggerItem.PullRequestLabel != "" || triggerItem.DraftPullRequestEnabled != nil {
+		return fmt.Errorf("trigger map item (%s) validate failed, error: commit_message, pull_request_label and draft_pull_request_enabled are not supported on deprecated trigger items", triggerItem.String(true))
+	}
+
+	if triggerItem.ChangedFiles != nil {
+		if triggerItem.Pattern != "" || triggerItem.Tag != "" || triggerItem.TagRegex != "" || triggerItem.Schedule != "" {
+			return fmt.Errorf("trigger map item (%s) validate failed, error: changed_files filter is only supported on push_branch and pull request trigger items", triggerItem.String(true))
+		}
+		if err := triggerItem.ChangedFiles.Validate(); err != nil {
+			return fmt.Errorf("trigger map item (%s) validate failed, error: invalid changed_files filter: %s", triggerItem.String(true), err)
+		}
+	}
+
+	if triggerItem.CommitMessage != nil {
+		if err := triggerItem.CommitMessage.Validate(); err != nil {
+			return fmt.Errorf("trigger map item (%s) validate failed, error: invalid commit_message filter: %s", triggerItem.String(true), err)
+		}
 	}
 
 	return nil
@@ -357,9 +799,13 @@ func (triggerItem TriggerMapItemModel) Validate() error {
 
 // Validate ...
 func (triggerMap TriggerMapModel) Validate() error {
-	for _, item := range triggerMap {
+	return triggerMap.validate(nil)
+}
+
+func (triggerMap TriggerMapModel) validate(sourceMap SourceMap) error {
+	for idx, item := range triggerMap {
 		if err := item.Validate(); err != nil {
-			return err
+			return newValidationIssue(sourceMap, fmt.Sprintf("trigger_map[%d]", idx), "%s", err)
 		}
 	}
 
@@ -371,7 +817,7 @@ func checkDuplicatedTriggerMapItems(triggerMap TriggerMapModel) error {
 
 	for _, triggerItem := range triggerMap {
 		if triggerItem.Pattern == "" {
-			triggerType, err := triggerEventType(triggerItem.PushBranch, triggerItem.PullRequestSourceBranch, triggerItem.PullRequestTargetBranch, triggerItem.Tag)
+			triggerType, err := triggerItem.eventType()
 			if err != nil {
 				return fmt.Errorf("trigger map item (%v) validate failed, error: %s", triggerItem, err)
 			}
@@ -379,9 +825,14 @@ func checkDuplicatedTriggerMapItems(triggerMap TriggerMapModel) error {
 			triggerItems := triggeTypeItemMap[string(triggerType)]
 
 			for _, item := range triggerItems {
+				if !triggerItem.hasSameConditions(item) {
+					// the same branch filters with different additional conditions select different events
+					continue
+				}
+
 				switch triggerType {
 				case TriggerEventTypeCodePush:
-					if triggerItem.PushBranch == item.PushBranch {
+					if triggerItem.PushBranch == item.PushBranch && triggerItem.PushBranchRegex == item.PushBranchRegex {
 						return fmt.Errorf("duplicated trigger item found (%s)", triggerItem.String(false))
 					}
 				case TriggerEventTypePullRequest:
@@ -390,9 +841,15 @@ func checkDuplicatedTriggerMapItems(triggerMap TriggerMapModel) error {
 						return fmt.Errorf("duplicated trigger item found (%s)", triggerItem.String(false))
 					}
 				case TriggerEventTypeTag:
-					if triggerItem.Tag == item.Tag {
+					if triggerItem.Tag == item.Tag && triggerItem.TagRegex == item.TagRegex {
 						return fmt.Errorf("duplicated trigger item found (%s)", triggerItem.String(false))
 					}
+				case TriggerEventTypeSchedule:
+					// a schedule can trigger multiple pipelines and workflows
+					if triggerItem.Schedule == item.Schedule &&
+						triggerItem.PipelineID == item.PipelineID && triggerItem.WorkflowID == item.WorkflowID {
+						return fmt.Errorf("duplicated trigger item found (%s)", triggerItem.String(true))
+					}
 				}
 			}
 
@@ -418,20 +875,29 @@ func checkDuplicatedTriggerMapItems(triggerMap TriggerMapModel) error {
 
 // Validate ...
 func (config *BitriseDataModel) Validate() ([]string, error) {
-	warnings := []string{}
+	issues, err := config.ValidateWithSourceMap(nil)
+	return ValidationIssueMessages(issues), err
+}
+
+// ValidateWithSourceMap validates the config like Validate,
+// the returned warnings and the returned error (ValidationIssue) carry the source position of the related config element.
+func (config *BitriseDataModel) ValidateWithSourceMap(sourceMap SourceMap) ([]ValidationIssue, error) {
+	warnings := []ValidationIssue{}
 
 	if config.FormatVersion == "" {
-		return warnings, fmt.Errorf("missing format_version")
+		return warnings, newValidationIssue(sourceMap, "", "missing format_version")
 	}
 
 	// trigger map
-	if err := config.TriggerMap.Validate(); err != nil {
+	if err := config.TriggerMap.validate(sourceMap); err != nil {
 		return warnings, err
 	}
 
-	for _, triggerMapItem := range config.TriggerMap {
+	for idx, triggerMapItem := range config.TriggerMap {
+		triggerItemPath := fmt.Sprintf("trigger_map[%d]", idx)
+
 		if strings.HasPrefix(triggerMapItem.WorkflowID, "_") {
-			warnings = append(warnings, fmt.Sprintf("workflow (%s) defined in trigger item (%s), but utility workflows can't be triggered directly", triggerMapItem.WorkflowID, triggerMapItem.String(true)))
+			warnings = append(warnings, newValidationIssue(sourceMap, triggerItemPath+".workflow", "workflow (%s) defined in trigger item (%s), but utility workflows can't be triggered directly", triggerMapItem.WorkflowID, triggerMapItem.String(true)))
 		}
 
 		found := false
@@ -444,7 +910,7 @@ func (config *BitriseDataModel) Validate() ([]string, error) {
 			}
 
 			if !found {
-				return warnings, fmt.Errorf("pipeline (%s) defined in trigger item (%s), but does not exist", triggerMapItem.PipelineID, triggerMapItem.String(true))
+				return warnings, newValidationIssue(sourceMap, triggerItemPath+".pipeline", "pipeline (%s) defined in trigger item (%s), but does not exist", triggerMapItem.PipelineID, triggerMapItem.String(true))
 			}
 		} else {
 			for workflowID := range config.Workflows {
@@ -455,24 +921,48 @@ func (config *BitriseDataModel) Validate() ([]string, error) {
 			}
 
 			if !found {
-				return warnings, fmt.Errorf("workflow (%s) defined in trigger item (%s), but does not exist", triggerMapItem.WorkflowID, triggerMapItem.String(true))
+				return warnings, newValidationIssue(sourceMap, triggerItemPath+".workflow", "workflow (%s) defined in trigger item (%s), but does not exist", triggerMapItem.WorkflowID, triggerMapItem.String(true))
 			}
 		}
 	}
 
 	if err := checkDuplicatedTriggerMapItems(config.TriggerMap); err != nil {
+		return warnings, newValidationIssue(sourceMap, "trigger_map", "%s", err)
+	}
+	// ---
+
+	// trigger tests
+	for idx, test := range config.TriggerTests {
+		testPath := fmt.Sprintf("trigger_tests[%d]", idx)
+
+		if err := test.Validate(); err != nil {
+			return warnings, newValidationIssue(sourceMap, testPath, "%s", err)
+		}
+		if _, found := config.Pipelines[test.PipelineID]; test.PipelineID != "" && !found {
+			return warnings, newValidationIssue(sourceMap, testPath+".pipeline", "pipeline (%s) defined in trigger test (%s), but does not exist", test.PipelineID, test)
+		}
+		if _, found := config.Workflows[test.WorkflowID]; test.WorkflowID != "" && !found {
+			return warnings, newValidationIssue(sourceMap, testPath+".workflow", "workflow (%s) defined in trigger test (%s), but does not exist", test.WorkflowID, test)
+		}
+	}
+	// ---
+
+	// git steps
+	gitStepWarnings, err := validateGitSteps(config, sourceMap)
+	warnings = append(warnings, gitStepWarnings...)
+	if err != nil {
 		return warnings, err
 	}
 	// ---
 
 	// app
 	if err := config.App.Validate(); err != nil {
-		return warnings, err
+		return warnings, newValidationIssue(sourceMap, "app", "%s", err)
 	}
 	// ---
 
 	// pipelines
-	pipelineWarnings, err := validatePipelines(config)
+	pipelineWarnings, err := validatePipelines(config, sourceMap)
 	warnings = append(warnings, pipelineWarnings...)
 	if err != nil {
 		return warnings, err
@@ -480,7 +970,7 @@ func (config *BitriseDataModel) Validate() ([]string, error) {
 	// ---
 
 	// stages
-	stageWarnings, err := validateStages(config)
+	stageWarnings, err := validateStages(config, sourceMap)
 	warnings = append(warnings, stageWarnings...)
 	if err != nil {
 		return warnings, err
@@ -488,7 +978,7 @@ func (config *BitriseDataModel) Validate() ([]string, error) {
 	// ---
 
 	// workflows
-	workflowWarnings, err := validateWorkflows(config)
+	workflowWarnings, err := validateWorkflows(config, sourceMap)
 	warnings = append(warnings, workflowWarnings...)
 	if err != nil {
 		return warnings, err
@@ -498,25 +988,29 @@ func (config *BitriseDataModel) Validate() ([]string, error) {
 	return warnings, nil
 }
 
-func validatePipelines(config *BitriseDataModel) ([]string, error) {
-	pipelineWarnings := make([]string, 0)
+func validatePipelines(config *BitriseDataModel, sourceMap SourceMap) ([]ValidationIssue, error) {
+	pipelineWarnings := make([]ValidationIssue, 0)
 	for ID, pipeline := range config.Pipelines {
+		pipelinePath := "pipelines." + ID
+
 		idWarning, err := validateID(ID, "pipeline")
 		if idWarning != "" {
-			pipelineWarnings = append(pipelineWarnings, idWarning)
+			pipelineWarnings = append(pipelineWarnings, newValidationIssue(sourceMap, pipelinePath, "%s", idWarning))
 		}
 		if err != nil {
-			return pipelineWarnings, err
+			return pipelineWarnings, newValidationIssue(sourceMap, pipelinePath, "%s", err)
 		}
 
 		if len(pipeline.Stages) == 0 {
-			return pipelineWarnings, fmt.Errorf("pipeline (%s) should have at least 1 stage", ID)
+			return pipelineWarnings, newValidationIssue(sourceMap, pipelinePath, "pipeline (%s) should have at least 1 stage", ID)
 		}
 
-		for _, pipelineStage := range pipeline.Stages {
+		for idx, pipelineStage := range pipeline.Stages {
+			stagePath := fmt.Sprintf("%s.stages[%d]", pipelinePath, idx)
+
 			pipelineStageID, err := GetStageIDFromListItemModel(pipelineStage)
 			if err != nil {
-				return pipelineWarnings, err
+				return pipelineWarnings, newValidationIssue(sourceMap, stagePath, "%s", err)
 			}
 			found := false
 			for stageID := range config.Stages {
@@ -526,7 +1020,7 @@ func validatePipelines(config *BitriseDataModel) ([]string, error) {
 				}
 			}
 			if !found {
-				return pipelineWarnings, fmt.Errorf("stage (%s) defined in pipeline (%s), but does not exist", pipelineStageID, ID)
+				return pipelineWarnings, newValidationIssue(sourceMap, stagePath, "stage (%s) defined in pipeline (%s), but does not exist", pipelineStageID, ID)
 			}
 		}
 	}
@@ -534,31 +1028,35 @@ func validatePipelines(config *BitriseDataModel) ([]string, error) {
 	return pipelineWarnings, nil
 }
 
-func validateStages(config *BitriseDataModel) ([]string, error) {
-	stageWarnings := make([]string, 0)
+func validateStages(config *BitriseDataModel, sourceMap SourceMap) ([]ValidationIssue, error) {
+	stageWarnings := make([]ValidationIssue, 0)
 	for ID, stage := range config.Stages {
+		stagePath := "stages." + ID
+
 		idWarning, err := validateID(ID, "stage")
 		if idWarning != "" {
-			stageWarnings = append(stageWarnings, idWarning)
+			stageWarnings = append(stageWarnings, newValidationIssue(sourceMap, stagePath, "%s", idWarning))
 		}
 		if err != nil {
-			return stageWarnings, err
+			return stageWarnings, newValidationIssue(sourceMap, stagePath, "%s", err)
 		}
 
 		if len(stage.Workflows) == 0 {
-			return stageWarnings, fmt.Errorf("stage (%s) should have at least 1 workflow", ID)
+			return stageWarnings, newValidationIssue(sourceMap, stagePath, "stage (%s) should have at least 1 workflow", ID)
 		}
 
-		for _, stageWorkflow := range stage.Workflows {
+		for idx, stageWorkflow := range stage.Workflows {
+			workflowPath := fmt.Sprintf("%s.workflows[%d]", stagePath, idx)
+
 			found := false
 			stageWorkflowID, err := GetWorkflowIDFromListItemModel(stageWorkflow)
 
 			if isUtilityWorkflow(stageWorkflowID) {
-				return stageWarnings, fmt.Errorf("workflow (%s) defined in stage (%s), is a utility workflow", stageWorkflowID, ID)
+				return stageWarnings, newValidationIssue(sourceMap, workflowPath, "workflow (%s) defined in stage (%s), is a utility workflow", stageWorkflowID, ID)
 			}
 
 			if err != nil {
-				return stageWarnings, err
+				return stageWarnings, newValidationIssue(sourceMap, workflowPath, "%s", err)
 			}
 			for workflowID := range config.Workflows {
 				if workflowID == stageWorkflowID {
@@ -567,7 +1065,7 @@ func validateStages(config *BitriseDataModel) ([]string, error) {
 				}
 			}
 			if !found {
-				return stageWarnings, fmt.Errorf("workflow (%s) defined in stage (%s), but does not exist", stageWorkflowID, ID)
+				return stageWarnings, newValidationIssue(sourceMap, workflowPath, "workflow (%s) defined in stage (%s), but does not exist", stageWorkflowID, ID)
 			}
 		}
 	}
@@ -579,25 +1077,32 @@ func isUtilityWorkflow(workflowID string) bool {
 	return strings.HasPrefix(workflowID, "_")
 }
 
-func validateWorkflows(config *BitriseDataModel) ([]string, error) {
-	workflowWarnings := make([]string, 0)
+func validateWorkflows(config *BitriseDataModel, sourceMap SourceMap) ([]ValidationIssue, error) {
+	workflowWarnings := make([]ValidationIssue, 0)
 	for ID, workflow := range config.Workflows {
+		workflowPath := "workflows." + ID
+
 		idWarning, err := validateID(ID, "workflow")
 		if idWarning != "" {
-			workflowWarnings = append(workflowWarnings, idWarning)
+			workflowWarnings = append(workflowWarnings, newValidationIssue(sourceMap, workflowPath, "%s", idWarning))
 		}
 		if err != nil {
-			return workflowWarnings, err
+			return workflowWarnings, newValidationIssue(sourceMap, workflowPath, "%s", err)
 		}
 
-		warns, err := workflow.Validate()
+		warns, err := workflow.validate(workflowPath, sourceMap)
 		workflowWarnings = append(workflowWarnings, warns...)
 		if err != nil {
-			return workflowWarnings, fmt.Errorf("validation error in workflow: %s: %s", ID, err)
+			issue, ok := err.(ValidationIssue)
+			if !ok {
+				issue = newValidationIssue(sourceMap, workflowPath, "%s", err)
+			}
+			issue.Message = fmt.Sprintf("validation error in workflow: %s: %s", ID, issue.Message)
+			return workflowWarnings, issue
 		}
 
 		if err := checkWorkflowReferenceCycle(ID, workflow, *config, []string{}); err != nil {
-			return workflowWarnings, err
+			return workflowWarnings, newValidationIssue(sourceMap, workflowPath, "%s", err)
 		}
 	}
 
@@ -1225,6 +1225,101 @@ workflows:
 		require.NoError(t, err)
 		require.Equal(t, 1, len(warnings))
 	}
+
+	t.Log("container")
+	{
+		workflow := WorkflowModel{Container: &ContainerModel{Image: "ubuntu:22.04", Engine: ContainerEnginePodman}}
+		_, err := workflow.Validate()
+		require.NoError(t, err)
+
+		workflow = WorkflowModel{Container: &ContainerModel{Engine: ContainerEngineDocker}}
+		_, err = workflow.Validate()
+		require.EqualError(t, err, "container image not defined")
+
+		workflow = WorkflowModel{Container: &ContainerModel{Image: "ubuntu:22.04", Engine: "lxc"}}
+		_, err = workflow.Validate()
+		require.EqualError(t, err, "invalid container engine (lxc), supported engines: docker, podman")
+	}
+
+	t.Log("services")
+	{
+		workflow := WorkflowModel{Services: map[string]ServiceModel{
+			"postgres": {Image: "postgres:14", Ports: []string{"15432:5432"}, HealthCheck: &ServiceHealthCheckModel{Command: "pg_isready"}},
+		}}
+		_, err := workflow.Validate()
+		require.NoError(t, err)
+
+		workflow = WorkflowModel{Services: map[string]ServiceModel{"my db": {Image: "postgres:14"}}}
+		_, err = workflow.Validate()
+		require.EqualError(t, err, "invalid service name (my db), only letters, digits, '_' and '-' are allowed")
+
+		workflow = WorkflowModel{Services: map[string]ServiceModel{"db": {Image: "postgres:14", Ports: []string{"1:2:3"}}}}
+		_, err = workflow.Validate()
+		require.EqualError(t, err, "invalid port (1:2:3), should be in HOST_PORT:CONTAINER_PORT or PORT format")
+
+		workflow = WorkflowModel{Services: map[string]ServiceModel{"db": {Image: "postgres:14", HealthCheck: &ServiceHealthCheckModel{}}}}
+		_, err = workflow.Validate()
+		require.EqualError(t, err, "health check command not defined")
+	}
+}
+
+func TestParseServicePort(t *testing.T) {
+	hostPort, containerPort, err := ParseServicePort("5432")
+	require.NoError(t, err)
+	require.Equal(t, 5432, hostPort)
+	require.Equal(t, 5432, containerPort)
+
+	hostPort, containerPort, err = ParseServicePort("15432:5432")
+	require.NoError(t, err)
+	require.Equal(t, 15432, hostPort)
+	require.Equal(t, 5432, containerPort)
+
+	for _, port := range []string{"", "db", "0", "70000", "1:", "1:2:3"} {
+		_, _, err := ParseServicePort(port)
+		require.Error(t, err, port)
+	}
+}
+
+func TestValidateGitSteps(t *testing.T) {
+	const stepID = "git::https://github.com/bitrise-steplib/steps-script.git@1.0.0"
+	workflows := map[string]WorkflowModel{"primary": {Steps: []StepListItemModel{{stepID: stepmanModels.StepModel{}}}}}
+
+	t.Log("valid git steps")
+	{
+		config := BitriseDataModel{FormatVersion: "1.4.0", Workflows: workflows, GitSteps: map[string]GitStepModel{
+			stepID: {Commit: "8ac5a22", Tree: "5B1C2E0A9C6F3F6E0D7C3B2A1F0E9D8C7B6A5F4E", VerifyTagSignature: true, Keyring: "./keys.asc"},
+			"_::https://github.com/bitrise-steplib/steps-git-clone.git@master": {Commit: "8ac5a226bcd292bdae47314f12a3d206d0338c64"},
+		}}
+		warnings, err := config.Validate()
+		require.NoError(t, err)
+		require.Equal(t, []string{"git step (_::https://github.com/bitrise-steplib/steps-git-clone.git@master) is not used by any workflow"}, warnings)
+
+		gitStep, found := config.GitStep(StepIDData{SteplibSource: "git", IDorURI: "https://github.com/bitrise-steplib/steps-script.git", Version: "1.0.0"})
+		require.True(t, found)
+		require.Equal(t, "8ac5a22", gitStep.Commit)
+
+		_, found = config.GitStep(StepIDData{SteplibSource: "git", IDorURI: "https://github.com/bitrise-steplib/steps-script.git", Version: "2.0.0"})
+		require.False(t, found)
+	}
+
+	t.Log("invalid git steps")
+	{
+		for gitStepID, gitStep := range map[string]GitStepModel{
+			"script@1":                     {Commit: "8ac5a22"},
+			"path::./steps/script":         {Commit: "8ac5a22"},
+			stepID:                         {},
+			"git::https://host/step.git":   {VerifyTagSignature: true, Keyring: "./keys.asc"},
+			"git::https://host/step.git@1": {Commit: "main"},
+		} {
+			config := BitriseDataModel{FormatVersion: "1.4.0", Workflows: workflows, GitSteps: map[string]GitStepModel{gitStepID: gitStep}}
+			_, err := config.Validate()
+			require.Error(t, err, gitStepID)
+		}
+
+		config := BitriseDataModel{FormatVersion: "1.4.0", Workflows: workflows, GitSteps: map[string]GitStepModel{stepID: {Tree: "abc"}}}
+		_, err := config.Validate()
+		require.EqualError(t, err, "invalid tree hash (abc)")
+	}
 }
 
 // ----------------------------
@@ -0,0 +1,130 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SourcePosition ...
+type SourcePosition struct {
+	File   string `json:"file,omitempty" yaml:"file,omitempty"`
+	Line   int    `json:"line,omitempty" yaml:"line,omitempty"`
+	Column int    `json:"column,omitempty" yaml:"column,omitempty"`
+}
+
+// String ...
+func (position SourcePosition) String() string {
+	if position.Line == 0 {
+		return position.File
+	}
+	if position.File == "" {
+		return fmt.Sprintf("%d:%d", position.Line, position.Column)
+	}
+	return fmt.Sprintf("%s:%d:%d", position.File, position.Line, position.Column)
+}
+
+// SourceMap maps config paths (like: workflows.primary.steps[0]) to their position in the config file.
+type SourceMap map[string]SourcePosition
+
+// NewSourceMapFromYAML parses the given YAML content and collects the position of every mapping key and sequence item.
+func NewSourceMapFromYAML(file string, content []byte) (SourceMap, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(content, &root); err != nil {
+		return nil, err
+	}
+
+	sourceMap := SourceMap{}
+	if root.Kind == yaml.DocumentNode && len(root.Content) > 0 {
+		sourceMap.collect(file, "", root.Content[0])
+	}
+	return sourceMap, nil
+}
+
+func (sourceMap SourceMap) collect(file, path string, node *yaml.Node) {
+	if _, found := sourceMap[path]; !found {
+		sourceMap[path] = SourcePosition{File: file, Line: node.Line, Column: node.Column}
+	}
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			keyNode, valueNode := node.Content[i], node.Content[i+1]
+
+			childPath := keyNode.Value
+			if path != "" {
+				childPath = path + "." + keyNode.Value
+			}
+
+			sourceMap[childPath] = SourcePosition{File: file, Line: keyNode.Line, Column: keyNode.Column}
+			sourceMap.collect(file, childPath, valueNode)
+		}
+	case yaml.SequenceNode:
+		for i, itemNode := range node.Content {
+			sourceMap.collect(file, fmt.Sprintf("%s[%d]", path, i), itemNode)
+		}
+	}
+}
+
+// Position returns the position of the given config path,
+// or the position of its closest parent if the path itself is not part of the config file.
+func (sourceMap SourceMap) Position(path string) SourcePosition {
+	if sourceMap == nil {
+		return SourcePosition{}
+	}
+
+	for {
+		if position, found := sourceMap[path]; found {
+			return position
+		}
+		if path == "" {
+			return SourcePosition{}
+		}
+
+		idx := strings.LastIndexAny(path, ".[")
+		if idx == -1 {
+			path = ""
+		} else {
+			path = path[:idx]
+		}
+	}
+}
+
+// ValidationIssue is a config validation error or warning,
+// with the path and the source position of the related config element.
+type ValidationIssue struct {
+	Message        string `json:"message" yaml:"message"`
+	Path           string `json:"path,omitempty" yaml:"path,omitempty"`
+	SourcePosition `yaml:",inline"`
+}
+
+// Error ...
+func (issue ValidationIssue) Error() string {
+	return issue.Message
+}
+
+// String ...
+func (issue ValidationIssue) String() string {
+	if position := issue.SourcePosition.String(); position != "" {
+		return fmt.Sprintf("%s: %s", position, issue.Message)
+	}
+	return issue.Message
+}
+
+func newValidationIssue(sourceMap SourceMap, path, format string, args ...interface{}) ValidationIssue {
+	return ValidationIssue{
+		Message:        fmt.Sprintf(format, args...),
+		Path:           path,
+		SourcePosition: sourceMap.Position(path),
+	}
+}
+
+// ValidationIssueMessages ...
+func ValidationIssueMessages(issues []ValidationIssue) []string {
+	messages := []string{}
+	for _, issue := range issues {
+		messages = append(messages, issue.Message)
+	}
+	return messages
+}
@@ -0,0 +1,106 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+func TestSourceMapPosition(t *testing.T) {
+	configStr := `format_version: "11"
+workflows:
+  primary:
+    steps:
+    - script:
+        inputs:
+        - content: echo
+`
+	sourceMap, err := NewSourceMapFromYAML("bitrise.yml", []byte(configStr))
+	require.NoError(t, err)
+
+	t.Log("mapping key")
+	{
+		require.Equal(t, SourcePosition{File: "bitrise.yml", Line: 3, Column: 3}, sourceMap.Position("workflows.primary"))
+	}
+
+	t.Log("sequence item")
+	{
+		require.Equal(t, SourcePosition{File: "bitrise.yml", Line: 5, Column: 7}, sourceMap.Position("workflows.primary.steps[0]"))
+		require.Equal(t, SourcePosition{File: "bitrise.yml", Line: 7, Column: 11}, sourceMap.Position("workflows.primary.steps[0].script.inputs[0]"))
+	}
+
+	t.Log("missing path - closest parent")
+	{
+		require.Equal(t, SourcePosition{File: "bitrise.yml", Line: 4, Column: 5}, sourceMap.Position("workflows.primary.steps[1]"))
+		require.Equal(t, SourcePosition{File: "bitrise.yml", Line: 1, Column: 1}, sourceMap.Position("app"))
+	}
+
+	t.Log("nil source map")
+	{
+		require.Equal(t, SourcePosition{}, SourceMap(nil).Position("workflows.primary"))
+	}
+}
+
+func TestValidateWithSourceMap(t *testing.T) {
+	t.Log("warning and error positions")
+	{
+		configStr := `format_version: "11"
+trigger_map:
+- push_branch: master
+  workflow: _deploy
+pipelines:
+  pipeline:
+    stages:
+    - missing: {}
+stages:
+  stage:
+    workflows:
+    - primary: {}
+workflows:
+  _deploy:
+  primary:
+`
+		var config BitriseDataModel
+		require.NoError(t, yaml.Unmarshal([]byte(configStr), &config))
+		require.NoError(t, config.Normalize())
+
+		sourceMap, err := NewSourceMapFromYAML("bitrise.yml", []byte(configStr))
+		require.NoError(t, err)
+
+		warnings, err := config.ValidateWithSourceMap(sourceMap)
+		require.Equal(t, []ValidationIssue{
+			{
+				Message:        "workflow (_deploy) defined in trigger item (push_branch: master -> workflow: _deploy), but utility workflows can't be triggered directly",
+				Path:           "trigger_map[0].workflow",
+				SourcePosition: SourcePosition{File: "bitrise.yml", Line: 4, Column: 3},
+			},
+		}, warnings)
+		require.Equal(t, ValidationIssue{
+			Message:        "stage (missing) defined in pipeline (pipeline), but does not exist",
+			Path:           "pipelines.pipeline.stages[0]",
+			SourcePosition: SourcePosition{File: "bitrise.yml", Line: 8, Column: 7},
+		}, err)
+		require.Equal(t, "bitrise.yml:8:7: stage (missing) defined in pipeline (pipeline), but does not exist", err.(ValidationIssue).String())
+	}
+
+	t.Log("workflow validation error")
+	{
+		configStr := `format_version: "11"
+workflows:
+  primary:
+    steps:
+    - https://github.com/bitrise-io/bitrise-steplib.git::script@1.2.3.4: {}
+`
+		var config BitriseDataModel
+		require.NoError(t, yaml.Unmarshal([]byte(configStr), &config))
+		require.NoError(t, config.Normalize())
+
+		sourceMap, err := NewSourceMapFromYAML("", []byte(configStr))
+		require.NoError(t, err)
+
+		_, err = config.ValidateWithSourceMap(sourceMap)
+		require.EqualError(t, err, "validation error in workflow: primary: invalid version format (1.2.3.4) specified for step ID: https://github.com/bitrise-io/bitrise-steplib.git::script@1.2.3.4")
+		require.Equal(t, "5:7: validation error in workflow: primary: invalid version format (1.2.3.4) specified for step ID: https://github.com/bitrise-io/bitrise-steplib.git::script@1.2.3.4", err.(ValidationIssue).String())
+	}
+}
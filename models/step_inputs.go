@@ -0,0 +1,226 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	envmanModels "github.com/bitrise-io/envman/models"
+	"github.com/bitrise-io/go-utils/parseutil"
+	stepmanModels "github.com/bitrise-io/stepman/models"
+)
+
+// StepInputType ...
+type StepInputType string
+
+const (
+	// StepInputTypeString ...
+	StepInputTypeString StepInputType = "string"
+	// StepInputTypeInt ...
+	StepInputTypeInt StepInputType = "int"
+	// StepInputTypeBool ...
+	StepInputTypeBool StepInputType = "bool"
+	// StepInputTypeEnum ...
+	StepInputTypeEnum StepInputType = "enum"
+	// StepInputTypePath ...
+	StepInputTypePath StepInputType = "path"
+	// StepInputTypeJSON ...
+	StepInputTypeJSON StepInputType = "json"
+)
+
+// StepInputTypeMetaKey is the opts.meta key of a step.yml input, which declares the input's type.
+// The input options model does not have a dedicated field for it, and unknown option keys are dropped on normalize.
+const StepInputTypeMetaKey = "type"
+
+// StepInputLocation ...
+type StepInputLocation struct {
+	WorkflowID string
+	StepIdx    int
+	StepID     string
+	InputKey   string
+}
+
+// String ...
+func (location StepInputLocation) String() string {
+	return fmt.Sprintf("workflows.%s.steps[%d] (%s) inputs.%s", location.WorkflowID, location.StepIdx, location.StepID, location.InputKey)
+}
+
+// StepInputError ...
+type StepInputError struct {
+	Location StepInputLocation
+	Message  string
+}
+
+// Error ...
+func (e StepInputError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Location, e.Message)
+}
+
+// StepInputErrors ...
+type StepInputErrors []StepInputError
+
+// Error ...
+func (errs StepInputErrors) Error() string {
+	lines := []string{"invalid step inputs:"}
+	for _, err := range errs {
+		lines = append(lines, "- "+err.Error())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// GetStepInputType returns the declared type of a step.yml input, StepInputTypeString if not declared.
+func GetStepInputType(options envmanModels.EnvironmentItemOptionsModel) (StepInputType, error) {
+	value, found := options.Meta[StepInputTypeMetaKey]
+	if !found {
+		if len(options.ValueOptions) > 0 {
+			return StepInputTypeEnum, nil
+		}
+		return StepInputTypeString, nil
+	}
+
+	inputType := StepInputType(parseutil.CastToString(value))
+	switch inputType {
+	case StepInputTypeString, StepInputTypeInt, StepInputTypeBool, StepInputTypeEnum, StepInputTypePath, StepInputTypeJSON:
+		return inputType, nil
+	}
+	return "", fmt.Errorf("unknown input type (%s), supported types: %s, %s, %s, %s, %s, %s", inputType,
+		StepInputTypeString, StepInputTypeInt, StepInputTypeBool, StepInputTypeEnum, StepInputTypePath, StepInputTypeJSON)
+}
+
+func isStepInputValueResolvedAtRuntime(value string, options envmanModels.EnvironmentItemOptionsModel) bool {
+	if options.IsTemplate != nil && *options.IsTemplate {
+		return true
+	}
+	isExpand := envmanModels.DefaultIsExpand
+	if options.IsExpand != nil {
+		isExpand = *options.IsExpand
+	}
+	return isExpand && strings.Contains(value, "$")
+}
+
+func validateStepInputValue(inputType StepInputType, value string, valueOptions []string) error {
+	if len(valueOptions) > 0 {
+		found := false
+		for _, option := range valueOptions {
+			if option == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("value (%s) is not one of the value_options: %s", value, strings.Join(valueOptions, ", "))
+		}
+	}
+
+	switch inputType {
+	case StepInputTypeInt:
+		if _, err := strconv.Atoi(value); err != nil {
+			return fmt.Errorf("value (%s) is not an int", value)
+		}
+	case StepInputTypeBool:
+		switch strings.ToLower(value) {
+		case "true", "false", "yes", "no":
+		default:
+			return fmt.Errorf("value (%s) is not a bool, accepted values: true, false, yes, no", value)
+		}
+	case StepInputTypeEnum:
+		if len(valueOptions) == 0 {
+			return fmt.Errorf("enum input without value_options")
+		}
+	case StepInputTypePath:
+		if strings.ContainsAny(value, "\n\x00") {
+			return fmt.Errorf("value (%s) is not a valid path", value)
+		}
+	case StepInputTypeJSON:
+		if !json.Valid([]byte(value)) {
+			return fmt.Errorf("value (%s) is not valid JSON", value)
+		}
+	}
+
+	return nil
+}
+
+// ValidateStepInputs checks the inputs of a workflow step against the step definition (step.yml):
+// unknown input keys, is_required, is_dont_change_value, value_options and the declared input type.
+// Values referencing env vars or templates are resolved at runtime, these are only checked for is_required.
+func ValidateStepInputs(workflowID string, stepIdx int, stepID string, specStep, workflowStep stepmanModels.StepModel) ([]string, error) {
+	var warnings []string
+	var errs StepInputErrors
+
+	location := func(key string) StepInputLocation {
+		return StepInputLocation{WorkflowID: workflowID, StepIdx: stepIdx, StepID: stepID, InputKey: key}
+	}
+
+	for _, input := range workflowStep.Inputs {
+		key, _, err := input.GetKeyValuePair()
+		if err != nil {
+			return warnings, err
+		}
+		if _, found := getInputByKey(specStep, key); !found {
+			warnings = append(warnings, fmt.Sprintf("%s: input is not defined by the step", location(key)))
+		}
+	}
+
+	for _, specInput := range specStep.Inputs {
+		key, defaultValue, err := specInput.GetKeyValuePair()
+		if err != nil {
+			return warnings, err
+		}
+
+		options, err := specInput.GetOptions()
+		if err != nil {
+			return warnings, err
+		}
+
+		inputType, err := GetStepInputType(options)
+		if err != nil {
+			errs = append(errs, StepInputError{Location: location(key), Message: err.Error()})
+			continue
+		}
+
+		value := defaultValue
+		if workflowInput, found := getInputByKey(workflowStep, key); found {
+			_, workflowValue, err := workflowInput.GetKeyValuePair()
+			if err != nil {
+				return warnings, err
+			}
+			value = workflowValue
+
+			if options.IsDontChangeValue != nil && *options.IsDontChangeValue && value != defaultValue {
+				warnings = append(warnings, fmt.Sprintf("%s: value changed from the default (%s), but the input is marked as is_dont_change_value", location(key), defaultValue))
+			}
+
+			workflowOptions, err := workflowInput.GetOptions()
+			if err != nil {
+				return warnings, err
+			}
+			if workflowOptions.IsTemplate != nil {
+				options.IsTemplate = workflowOptions.IsTemplate
+			}
+			if workflowOptions.IsExpand != nil {
+				options.IsExpand = workflowOptions.IsExpand
+			}
+		}
+
+		if value == "" {
+			if options.IsRequired != nil && *options.IsRequired {
+				errs = append(errs, StepInputError{Location: location(key), Message: "required input is empty"})
+			}
+			continue
+		}
+
+		if isStepInputValueResolvedAtRuntime(value, options) {
+			continue
+		}
+
+		if err := validateStepInputValue(inputType, value, options.ValueOptions); err != nil {
+			errs = append(errs, StepInputError{Location: location(key), Message: err.Error()})
+		}
+	}
+
+	if len(errs) > 0 {
+		return warnings, errs
+	}
+	return warnings, nil
+}
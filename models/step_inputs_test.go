@@ -0,0 +1,142 @@
+package models
+
+import (
+	"testing"
+
+	envmanModels "github.com/bitrise-io/envman/models"
+	"github.com/bitrise-io/go-utils/pointers"
+	stepmanModels "github.com/bitrise-io/stepman/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateStepInputs(t *testing.T) {
+	specStep := stepmanModels.StepModel{
+		Inputs: []envmanModels.EnvironmentItemModel{
+			envmanModels.EnvironmentItemModel{
+				"project_path": "",
+				envmanModels.OptionsKey: envmanModels.EnvironmentItemOptionsModel{
+					IsRequired: pointers.NewBoolPtr(true),
+				},
+			},
+			envmanModels.EnvironmentItemModel{
+				"retry_count": "1",
+				envmanModels.OptionsKey: envmanModels.EnvironmentItemOptionsModel{
+					Meta: map[string]interface{}{"type": "int"},
+				},
+			},
+			envmanModels.EnvironmentItemModel{
+				"verbose": "no",
+				envmanModels.OptionsKey: envmanModels.EnvironmentItemOptionsModel{
+					Meta: map[string]interface{}{"type": "bool"},
+				},
+			},
+			envmanModels.EnvironmentItemModel{
+				"configuration": "debug",
+				envmanModels.OptionsKey: envmanModels.EnvironmentItemOptionsModel{
+					ValueOptions: []string{"debug", "release"},
+				},
+			},
+			envmanModels.EnvironmentItemModel{
+				"extra": "{}",
+				envmanModels.OptionsKey: envmanModels.EnvironmentItemOptionsModel{
+					Meta: map[string]interface{}{"type": "json"},
+				},
+			},
+			envmanModels.EnvironmentItemModel{
+				"ipa_path": "$BITRISE_IPA_PATH",
+				envmanModels.OptionsKey: envmanModels.EnvironmentItemOptionsModel{
+					IsDontChangeValue: pointers.NewBoolPtr(true),
+				},
+			},
+		},
+	}
+
+	t.Log("valid inputs")
+	{
+		workflowStep := stepmanModels.StepModel{
+			Inputs: []envmanModels.EnvironmentItemModel{
+				envmanModels.EnvironmentItemModel{"project_path": "./app"},
+				envmanModels.EnvironmentItemModel{"retry_count": "3"},
+				envmanModels.EnvironmentItemModel{"verbose": "true"},
+				envmanModels.EnvironmentItemModel{"configuration": "release"},
+			},
+		}
+
+		warnings, err := ValidateStepInputs("primary", 0, "xcode-archive", specStep, workflowStep)
+		require.NoError(t, err)
+		require.Equal(t, 0, len(warnings))
+	}
+
+	t.Log("values referencing env vars are resolved at runtime")
+	{
+		workflowStep := stepmanModels.StepModel{
+			Inputs: []envmanModels.EnvironmentItemModel{
+				envmanModels.EnvironmentItemModel{"project_path": "$PROJECT_PATH"},
+				envmanModels.EnvironmentItemModel{"retry_count": "$RETRY_COUNT"},
+			},
+		}
+
+		warnings, err := ValidateStepInputs("primary", 0, "xcode-archive", specStep, workflowStep)
+		require.NoError(t, err)
+		require.Equal(t, 0, len(warnings))
+	}
+
+	t.Log("invalid inputs")
+	{
+		workflowStep := stepmanModels.StepModel{
+			Inputs: []envmanModels.EnvironmentItemModel{
+				envmanModels.EnvironmentItemModel{"retry_count": "three"},
+				envmanModels.EnvironmentItemModel{"verbose": "maybe"},
+				envmanModels.EnvironmentItemModel{"configuration": "profile"},
+				envmanModels.EnvironmentItemModel{"extra": "{"},
+			},
+		}
+
+		warnings, err := ValidateStepInputs("primary", 1, "xcode-archive", specStep, workflowStep)
+		require.Equal(t, 0, len(warnings))
+
+		inputErrs, ok := err.(StepInputErrors)
+		require.True(t, ok)
+		require.Equal(t, 5, len(inputErrs))
+		require.Equal(t, "workflows.primary.steps[1] (xcode-archive) inputs.project_path: required input is empty", inputErrs[0].Error())
+		require.Equal(t, "workflows.primary.steps[1] (xcode-archive) inputs.retry_count: value (three) is not an int", inputErrs[1].Error())
+		require.Equal(t, "workflows.primary.steps[1] (xcode-archive) inputs.verbose: value (maybe) is not a bool, accepted values: true, false, yes, no", inputErrs[2].Error())
+		require.Equal(t, "workflows.primary.steps[1] (xcode-archive) inputs.configuration: value (profile) is not one of the value_options: debug, release", inputErrs[3].Error())
+		require.Equal(t, "workflows.primary.steps[1] (xcode-archive) inputs.extra: value ({) is not valid JSON", inputErrs[4].Error())
+	}
+
+	t.Log("unknown input and changed is_dont_change_value input - warning")
+	{
+		workflowStep := stepmanModels.StepModel{
+			Inputs: []envmanModels.EnvironmentItemModel{
+				envmanModels.EnvironmentItemModel{"project_path": "./app"},
+				envmanModels.EnvironmentItemModel{"unknown": "value"},
+				envmanModels.EnvironmentItemModel{"ipa_path": "./app.ipa"},
+			},
+		}
+
+		warnings, err := ValidateStepInputs("primary", 0, "xcode-archive", specStep, workflowStep)
+		require.NoError(t, err)
+		require.Equal(t, []string{
+			"workflows.primary.steps[0] (xcode-archive) inputs.unknown: input is not defined by the step",
+			"workflows.primary.steps[0] (xcode-archive) inputs.ipa_path: value changed from the default ($BITRISE_IPA_PATH), but the input is marked as is_dont_change_value",
+		}, warnings)
+	}
+
+	t.Log("unknown input type")
+	{
+		spec := stepmanModels.StepModel{
+			Inputs: []envmanModels.EnvironmentItemModel{
+				envmanModels.EnvironmentItemModel{
+					"timeout": "10",
+					envmanModels.OptionsKey: envmanModels.EnvironmentItemOptionsModel{
+						Meta: map[string]interface{}{"type": "duration"},
+					},
+				},
+			},
+		}
+
+		_, err := ValidateStepInputs("primary", 0, "script", spec, stepmanModels.StepModel{})
+		require.EqualError(t, err, "invalid step inputs:\n- workflows.primary.steps[0] (script) inputs.timeout: unknown input type (duration), supported types: string, int, bool, enum, path, json")
+	}
+}
@@ -0,0 +1,138 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/bitrise-io/go-utils/pointers"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTriggerMapItemModelMatchRegex(t *testing.T) {
+	t.Log("push_branch_regex")
+	{
+		item := TriggerMapItemModel{PushBranchRegex: "^release/[0-9]+\\.[0-9]+$", WorkflowID: "release"}
+
+		match, err := item.Match(TriggerEventModel{PushBranch: "release/1.2"})
+		require.NoError(t, err)
+		require.True(t, match)
+
+		match, err = item.Match(TriggerEventModel{PushBranch: "release/next"})
+		require.NoError(t, err)
+		require.False(t, match)
+
+		match, err = item.Match(TriggerEventModel{Tag: "release/1.2"})
+		require.NoError(t, err)
+		require.False(t, match)
+	}
+
+	t.Log("tag_regex")
+	{
+		item := TriggerMapItemModel{TagRegex: "^v[0-9]+\\.[0-9]+\\.[0-9]+$", WorkflowID: "release"}
+
+		match, err := item.Match(TriggerEventModel{Tag: "v1.0.0"})
+		require.NoError(t, err)
+		require.True(t, match)
+
+		match, err = item.Match(TriggerEventModel{Tag: "v1.0.0-beta"})
+		require.NoError(t, err)
+		require.False(t, match)
+	}
+}
+
+func TestTriggerMapItemModelMatchCommitMessageAndPR(t *testing.T) {
+	t.Log("commit_message")
+	{
+		item := TriggerMapItemModel{
+			PushBranch:    "*",
+			CommitMessage: &CommitMessageFilterModel{Include: []string{"*[deploy]*"}, Exclude: []string{"*[skip ci]*"}},
+			WorkflowID:    "deploy",
+		}
+
+		match, err := item.Match(TriggerEventModel{PushBranch: "master", CommitMessage: "Fix login [deploy]"})
+		require.NoError(t, err)
+		require.True(t, match)
+
+		match, err = item.Match(TriggerEventModel{PushBranch: "master", CommitMessage: "Fix login [deploy] [skip ci]"})
+		require.NoError(t, err)
+		require.False(t, match)
+
+		match, err = item.Match(TriggerEventModel{PushBranch: "master", CommitMessage: "Fix login"})
+		require.NoError(t, err)
+		require.False(t, match)
+
+		// unknown commit message
+		match, err = item.Match(TriggerEventModel{PushBranch: "master"})
+		require.NoError(t, err)
+		require.True(t, match)
+	}
+
+	t.Log("pull_request_label")
+	{
+		item := TriggerMapItemModel{PullRequestTargetBranch: "master", PullRequestLabel: "ci:*", WorkflowID: "pr"}
+
+		match, err := item.Match(TriggerEventModel{PRTargetBranch: "master", PRLabels: []string{"bug", "ci:full"}})
+		require.NoError(t, err)
+		require.True(t, match)
+
+		match, err = item.Match(TriggerEventModel{PRTargetBranch: "master", PRLabels: []string{}})
+		require.NoError(t, err)
+		require.False(t, match)
+
+		// unknown labels
+		match, err = item.Match(TriggerEventModel{PRTargetBranch: "master"})
+		require.NoError(t, err)
+		require.True(t, match)
+	}
+
+	t.Log("draft_pull_request_enabled")
+	{
+		item := TriggerMapItemModel{PullRequestTargetBranch: "master", DraftPullRequestEnabled: pointers.NewBoolPtr(false), WorkflowID: "pr"}
+
+		match, err := item.Match(TriggerEventModel{PRTargetBranch: "master", IsDraftPR: true})
+		require.NoError(t, err)
+		require.False(t, match)
+
+		match, err = item.Match(TriggerEventModel{PRTargetBranch: "master"})
+		require.NoError(t, err)
+		require.True(t, match)
+	}
+}
+
+func TestTriggerMapItemModelValidateConditions(t *testing.T) {
+	t.Log("push_branch and push_branch_regex")
+	{
+		item := TriggerMapItemModel{PushBranch: "master", PushBranchRegex: "^master$", WorkflowID: "primary"}
+		require.EqualError(t, item.Validate(), "trigger map item (push_branch: master push_branch_regex: ^master$ -> workflow: primary) validate failed, error: push_branch and push_branch_regex both defined")
+	}
+
+	t.Log("invalid tag_regex")
+	{
+		item := TriggerMapItemModel{TagRegex: "v(", WorkflowID: "primary"}
+		require.EqualError(t, item.Validate(), "trigger map item (tag_regex: v( -> workflow: primary) validate failed, error: invalid tag_regex: error parsing regexp: missing closing ): `v(`")
+	}
+
+	t.Log("pull request conditions on push item")
+	{
+		item := TriggerMapItemModel{PushBranch: "master", PullRequestLabel: "ci", WorkflowID: "primary"}
+		require.EqualError(t, item.Validate(), "trigger map item (push_branch: master pull_request_label: ci -> workflow: primary) validate failed, error: pull_request_label and draft_pull_request_enabled are only supported on pull request trigger items")
+	}
+
+	t.Log("invalid commit_message filter")
+	{
+		item := TriggerMapItemModel{PushBranch: "master", CommitMessage: &CommitMessageFilterModel{Include: []string{""}}, WorkflowID: "primary"}
+		require.EqualError(t, item.Validate(), "trigger map item (push_branch: master commit_message: include: [] -> workflow: primary) validate failed, error: invalid commit_message filter: empty pattern defined")
+	}
+
+	t.Log("duplicated regex items")
+	{
+		triggerMap := TriggerMapModel{
+			{PushBranchRegex: "^master$", WorkflowID: "primary"},
+			{PushBranch: "master", WorkflowID: "primary"},
+			{PushBranchRegex: "^master$", CommitMessage: &CommitMessageFilterModel{Include: []string{"*[deploy]*"}}, WorkflowID: "deploy"},
+		}
+		require.NoError(t, checkDuplicatedTriggerMapItems(triggerMap))
+
+		triggerMap = append(triggerMap, TriggerMapItemModel{PushBranchRegex: "^master$", WorkflowID: "secondary"})
+		require.EqualError(t, checkDuplicatedTriggerMapItems(triggerMap), "duplicated trigger item found (push_branch_regex: ^master$)")
+	}
+}
@@ -0,0 +1,298 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/ryanuber/go-glob"
+)
+
+// TriggerConditionExplanation describes how a condition of a trigger map item was evaluated against an event.
+type TriggerConditionExplanation struct {
+	Field   string `json:"field" yaml:"field"`
+	Pattern string `json:"pattern" yaml:"pattern"`
+	Value   string `json:"value" yaml:"value"`
+	Match   bool   `json:"match" yaml:"match"`
+	// Skipped is true if the related event property is unknown, so the condition was not evaluated.
+	Skipped bool `json:"skipped,omitempty" yaml:"skipped,omitempty"`
+}
+
+// TriggerItemExplanation describes how a trigger map item was matched against an event.
+type TriggerItemExplanation struct {
+	Index      int                           `json:"index" yaml:"index"`
+	Item       string                        `json:"item" yaml:"item"`
+	EventType  TriggerEventType              `json:"event_type" yaml:"event_type"`
+	PipelineID string                        `json:"pipeline,omitempty" yaml:"pipeline,omitempty"`
+	WorkflowID string                        `json:"workflow,omitempty" yaml:"workflow,omitempty"`
+	Match      bool                          `json:"match" yaml:"match"`
+	FirstMatch bool                          `json:"first_match,omitempty" yaml:"first_match,omitempty"`
+	Conditions []TriggerConditionExplanation `json:"conditions" yaml:"conditions"`
+}
+
+// TriggerMapExplanation describes how the trigger map was matched against an event.
+type TriggerMapExplanation struct {
+	Event     string                   `json:"event" yaml:"event"`
+	EventType TriggerEventType         `json:"event_type" yaml:"event_type"`
+	Items     []TriggerItemExplanation `json:"items" yaml:"items"`
+	Warnings  []string                 `json:"warnings,omitempty" yaml:"warnings,omitempty"`
+}
+
+// FirstMatch returns the first matching item's explanation, or nil if none of the items matched.
+func (explanation TriggerMapExplanation) FirstMatch() *TriggerItemExplanation {
+	for idx := range explanation.Items {
+		if explanation.Items[idx].FirstMatch {
+			return &explanation.Items[idx]
+		}
+	}
+	return nil
+}
+
+// String ...
+func (event TriggerEventModel) String() string {
+	var parts []string
+	for _, property := range []struct{ key, value string }{
+		{"push-branch", event.PushBranch},
+		{"pr-source-branch", event.PRSourceBranch},
+		{"pr-target-branch", event.PRTargetBranch},
+		{"tag", event.Tag},
+		{"commit-message", event.CommitMessage},
+	} {
+		if property.value != "" {
+			parts = append(parts, fmt.Sprintf("%s: %s", property.key, property.value))
+		}
+	}
+	if event.ChangedFiles != nil {
+		parts = append(parts, fmt.Sprintf("changed-files: [%s]", strings.Join(event.ChangedFiles, ", ")))
+	}
+	if event.PRLabels != nil {
+		parts = append(parts, fmt.Sprintf("pr-labels: [%s]", strings.Join(event.PRLabels, ", ")))
+	}
+	if event.IsDraftPR {
+		parts = append(parts, "pr-draft: true")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Explain matches every item of the trigger map against the event, and describes the evaluation of the items' conditions.
+// The returned warnings list the items, which are shadowed by an earlier item,
+// and the conditions of the first match, which are not evaluated as the related event properties are unknown.
+func (triggerMap TriggerMapModel) Explain(event TriggerEventModel) (TriggerMapExplanation, error) {
+	eventType, err := triggerEventType(event.PushBranch, event.PRSourceBranch, event.PRTargetBranch, event.Tag)
+	if err != nil {
+		return TriggerMapExplanation{}, err
+	}
+
+	explanation := TriggerMapExplanation{
+		Event:     event.String(),
+		EventType: eventType,
+		Items:     []TriggerItemExplanation{},
+		Warnings:  triggerMap.shadowedItemWarnings(),
+	}
+
+	hasMatch := false
+	for idx, item := range triggerMap {
+		match, err := item.Match(event)
+		if err != nil {
+			return TriggerMapExplanation{}, err
+		}
+
+		itemExplanation := TriggerItemExplanation{
+			Index:      idx,
+			Item:       item.String(false),
+			PipelineID: item.PipelineID,
+			WorkflowID: item.WorkflowID,
+			Match:      match,
+			FirstMatch: match && !hasMatch,
+			Conditions: []TriggerConditionExplanation{},
+		}
+		hasMatch = hasMatch || match
+
+		for _, migratedItem := range migratedTriggerItems(item) {
+			itemEventType, err := migratedItem.eventType()
+			if err != nil {
+				return TriggerMapExplanation{}, err
+			}
+
+			itemExplanation.EventType = itemEventType
+			if itemEventType == eventType {
+				itemExplanation.Conditions = migratedItem.explainConditions(event)
+				break
+			}
+		}
+		if itemExplanation.EventType != eventType {
+			itemExplanation.Conditions = append(itemExplanation.Conditions, TriggerConditionExplanation{
+				Field:   "event_type",
+				Pattern: string(itemExplanation.EventType),
+				Value:   string(eventType),
+			})
+		}
+
+		explanation.Items = append(explanation.Items, itemExplanation)
+	}
+
+	if firstMatch := explanation.FirstMatch(); firstMatch != nil {
+		if warning := triggerMap[firstMatch.Index].UnevaluatedConditionsWarning(firstMatch.Index, event); warning != "" {
+			explanation.Warnings = append(explanation.Warnings, warning)
+		}
+	}
+
+	return explanation, nil
+}
+
+func migratedTriggerItems(triggerItem TriggerMapItemModel) []TriggerMapItemModel {
+	if triggerItem.Pattern != "" {
+		return migrateDeprecatedTriggerItem(triggerItem)
+	}
+	return []TriggerMapItemModel{triggerItem}
+}
+
+// explainConditions describes the evaluation of the item's conditions, the event is expected to have the item's event type.
+func (triggerItem TriggerMapItemModel) explainConditions(event TriggerEventModel) []TriggerConditionExplanation {
+	var conditions []TriggerConditionExplanation
+	addGlob := func(field, pattern, value string) {
+		if pattern != "" {
+			conditions = append(conditions, TriggerConditionExplanation{Field: field, Pattern: pattern, Value: value, Match: glob.Glob(pattern, value)})
+		}
+	}
+	addRegex := func(field, pattern, value string) {
+		if pattern != "" {
+			match, err := regexp.MatchString(pattern, value)
+			conditions = append(conditions, TriggerConditionExplanation{Field: field, Pattern: pattern, Value: value, Match: err == nil && match})
+		}
+	}
+
+	addGlob("push_branch", triggerItem.PushBranch, event.PushBranch)
+	addRegex("push_branch_regex", triggerItem.PushBranchRegex, event.PushBranch)
+	addGlob("pull_request_source_branch", triggerItem.PullRequestSourceBranch, event.PRSourceBranch)
+	addGlob("pull_request_target_branch", triggerItem.PullRequestTargetBranch, event.PRTargetBranch)
+	addGlob("tag", triggerItem.Tag, event.Tag)
+	addRegex("tag_regex", triggerItem.TagRegex, event.Tag)
+
+	if triggerItem.ChangedFiles != nil {
+		condition := TriggerConditionExplanation{Field: "changed_files", Pattern: triggerItem.ChangedFiles.String(), Skipped: event.ChangedFiles == nil}
+		if !condition.Skipped {
+			condition.Value = strings.Join(event.ChangedFiles, ", ")
+			condition.Match = triggerItem.ChangedFiles.Match(event.ChangedFiles)
+		}
+		conditions = append(conditions, condition)
+	}
+
+	if triggerItem.CommitMessage != nil {
+		condition := TriggerConditionExplanation{Field: "commit_message", Pattern: triggerItem.CommitMessage.String(), Skipped: event.CommitMessage == ""}
+		if !condition.Skipped {
+			condition.Value = event.CommitMessage
+			condition.Match = triggerItem.CommitMessage.Match(event.CommitMessage)
+		}
+		conditions = append(conditions, condition)
+	}
+
+	if triggerItem.PullRequestLabel != "" {
+		condition := TriggerConditionExplanation{Field: "pull_request_label", Pattern: triggerItem.PullRequestLabel, Skipped: event.PRLabels == nil}
+		if !condition.Skipped {
+			condition.Value = strings.Join(event.PRLabels, ", ")
+			for _, label := range event.PRLabels {
+				if glob.Glob(triggerItem.PullRequestLabel, label) {
+					condition.Match = true
+					break
+				}
+			}
+		}
+		conditions = append(conditions, condition)
+	}
+
+	if triggerItem.DraftPullRequestEnabled != nil {
+		conditions = append(conditions, TriggerConditionExplanation{
+			Field:   "draft_pull_request_enabled",
+			Pattern: fmt.Sprintf("%v", *triggerItem.DraftPullRequestEnabled),
+			Value:   fmt.Sprintf("draft: %v", event.IsDraftPR),
+			Match:   *triggerItem.DraftPullRequestEnabled || !event.IsDraftPR,
+		})
+	}
+
+	return conditions
+}
+
+// shadowedItemWarnings returns a warning for every trigger map item, which can never be the first match,
+// as every event it matches is already matched by an earlier (broader) item.
+func (triggerMap TriggerMapModel) shadowedItemWarnings() []string {
+	var warnings []string
+	for idx, item := range triggerMap {
+		for earlierIdx := 0; earlierIdx < idx; earlierIdx++ {
+			earlierItem := triggerMap[earlierIdx]
+			if earlierItem.covers(item) {
+				warnings = append(warnings, fmt.Sprintf("trigger_map[%d] (%s) is shadowed by trigger_map[%d] (%s), it never gets triggered",
+					idx, item.String(true), earlierIdx, earlierItem.String(true)))
+				break
+			}
+		}
+	}
+	return warnings
+}
+
+// covers returns true if every event matching the other item, matches the item as well.
+// The check is conservative: false is returned if it can not be decided.
+func (triggerItem TriggerMapItemModel) covers(other TriggerMapItemModel) bool {
+	for _, otherMigratedItem := range migratedTriggerItems(other) {
+		covered := false
+		for _, migratedItem := range migratedTriggerItems(triggerItem) {
+			if migratedItem.coversMigrated(otherMigratedItem) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			return false
+		}
+	}
+	return true
+}
+
+func (triggerItem TriggerMapItemModel) coversMigrated(other TriggerMapItemModel) bool {
+	eventType, err := triggerItem.eventType()
+	if err != nil {
+		return false
+	}
+	otherEventType, err := other.eventType()
+	if err != nil || eventType != otherEventType {
+		return false
+	}
+
+	if !triggerItem.hasNoConditions() && !triggerItem.hasSameConditions(other) {
+		return false
+	}
+
+	switch eventType {
+	case TriggerEventTypeCodePush:
+		return coversPattern(triggerItem.PushBranch, triggerItem.PushBranchRegex, other.PushBranch, other.PushBranchRegex)
+	case TriggerEventTypePullRequest:
+		return coversPattern(triggerItem.PullRequestSourceBranch, "", other.PullRequestSourceBranch, "") &&
+			coversPattern(triggerItem.PullRequestTargetBranch, "", other.PullRequestTargetBranch, "")
+	case TriggerEventTypeTag:
+		return coversPattern(triggerItem.Tag, triggerItem.TagRegex, other.Tag, other.TagRegex)
+	}
+	return false
+}
+
+// hasNoConditions returns true if the item has no other conditions than the branch and tag related ones.
+func (triggerItem TriggerMapItemModel) hasNoConditions() bool {
+	return triggerItem.ChangedFiles == nil && triggerItem.CommitMessage == nil &&
+		triggerItem.PullRequestLabel == "" && triggerItem.DraftPullRequestEnabled == nil
+}
+
+// coversPattern returns true if every value matching the narrow glob (or regex) pattern matches the broad one.
+// An empty pattern (without regex) matches every value.
+func coversPattern(broadGlob, broadRegex, narrowGlob, narrowRegex string) bool {
+	if broadRegex != "" {
+		return broadRegex == narrowRegex
+	}
+	if broadGlob == "" || broadGlob == "*" {
+		return true
+	}
+	if narrowRegex != "" || narrowGlob == "" {
+		return false
+	}
+	// the narrow pattern's wildcards are matched as literal characters by the broad pattern:
+	// feature/* covers feature/login and feature/a*, but not *
+	return glob.Glob(broadGlob, narrowGlob)
+}
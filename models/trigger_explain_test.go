@@ -0,0 +1,99 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTriggerMapExplain(t *testing.T) {
+	triggerMap := TriggerMapModel{
+		{PushBranch: "feature/*", WorkflowID: "primary"},
+		{PushBranch: "master", ChangedFiles: &ChangedFilesFilterModel{Include: []string{"ios/*"}}, WorkflowID: "ios"},
+		{PushBranch: "master", WorkflowID: "primary"},
+		{Tag: "*", PipelineID: "release"},
+	}
+
+	t.Log("first hit and the conditions")
+	{
+		explanation, err := triggerMap.Explain(TriggerEventModel{PushBranch: "master", ChangedFiles: []string{"README.md"}})
+		require.NoError(t, err)
+		require.Equal(t, "push-branch: master, changed-files: [README.md]", explanation.Event)
+		require.Equal(t, TriggerEventTypeCodePush, explanation.EventType)
+		require.Equal(t, 0, len(explanation.Warnings))
+		require.Equal(t, []TriggerItemExplanation{
+			{
+				Index: 0, Item: "push_branch: feature/*", EventType: TriggerEventTypeCodePush, WorkflowID: "primary",
+				Conditions: []TriggerConditionExplanation{{Field: "push_branch", Pattern: "feature/*", Value: "master"}},
+			},
+			{
+				Index: 1, Item: "push_branch: master changed_files: include: [ios/*]", EventType: TriggerEventTypeCodePush, WorkflowID: "ios",
+				Conditions: []TriggerConditionExplanation{
+					{Field: "push_branch", Pattern: "master", Value: "master", Match: true},
+					{Field: "changed_files", Pattern: "include: [ios/*]", Value: "README.md"},
+				},
+			},
+			{
+				Index: 2, Item: "push_branch: master", EventType: TriggerEventTypeCodePush, WorkflowID: "primary", Match: true, FirstMatch: true,
+				Conditions: []TriggerConditionExplanation{{Field: "push_branch", Pattern: "master", Value: "master", Match: true}},
+			},
+			{
+				Index: 3, Item: "tag: *", EventType: TriggerEventTypeTag, PipelineID: "release",
+				Conditions: []TriggerConditionExplanation{{Field: "event_type", Pattern: "tag", Value: "code-push"}},
+			},
+		}, explanation.Items)
+		require.Equal(t, 2, explanation.FirstMatch().Index)
+	}
+
+	t.Log("first hit without evaluating the changed files")
+	{
+		explanation, err := triggerMap.Explain(TriggerEventModel{PushBranch: "master"})
+		require.NoError(t, err)
+		require.Equal(t, 1, explanation.FirstMatch().Index)
+		require.Equal(t, TriggerConditionExplanation{Field: "changed_files", Pattern: "include: [ios/*]", Skipped: true}, explanation.Items[1].Conditions[1])
+		require.Equal(t, []string{"trigger_map[1] (push_branch: master changed_files: include: [ios/*] -> workflow: ios) matches without evaluating its changed_files condition(s), as the related event properties are unknown"}, explanation.Warnings)
+	}
+
+	t.Log("no match")
+	{
+		explanation, err := triggerMap.Explain(TriggerEventModel{PRSourceBranch: "develop"})
+		require.NoError(t, err)
+		require.Nil(t, explanation.FirstMatch())
+	}
+
+	t.Log("invalid event")
+	{
+		_, err := triggerMap.Explain(TriggerEventModel{})
+		require.Error(t, err)
+	}
+}
+
+func TestTriggerMapShadowedItemWarnings(t *testing.T) {
+	triggerMap := TriggerMapModel{
+		{PushBranch: "feature/*", WorkflowID: "primary"},
+		{PushBranch: "feature/login", WorkflowID: "secondary"},
+		{PushBranch: "master", CommitMessage: &CommitMessageFilterModel{Include: []string{"*[deploy]*"}}, WorkflowID: "deploy"},
+		{PushBranch: "master", WorkflowID: "primary"},
+		{PullRequestTargetBranch: "master", WorkflowID: "pr"},
+		{PullRequestSourceBranch: "feature/*", PullRequestTargetBranch: "master", WorkflowID: "feature-pr"},
+		{PullRequestSourceBranch: "*", WorkflowID: "any-pr"},
+		{TagRegex: "^v[0-9]+$", WorkflowID: "release"},
+		{Tag: "v1", WorkflowID: "release"},
+		{Pattern: "*", WorkflowID: "primary"},
+	}
+
+	require.Equal(t, []string{
+		"trigger_map[1] (push_branch: feature/login -> workflow: secondary) is shadowed by trigger_map[0] (push_branch: feature/* -> workflow: primary), it never gets triggered",
+		"trigger_map[5] (pull_request_source_branch: feature/* && pull_request_target_branch: master -> workflow: feature-pr) is shadowed by trigger_map[4] (pull_request_target_branch: master -> workflow: pr), it never gets triggered",
+	}, triggerMap.shadowedItemWarnings())
+
+	t.Log("deprecated items")
+	{
+		triggerMap := TriggerMapModel{
+			{Pattern: "*", IsPullRequestAllowed: true, WorkflowID: "primary"},
+			{PushBranch: "master", WorkflowID: "primary"},
+			{PullRequestSourceBranch: "feature", WorkflowID: "primary"},
+		}
+		require.Equal(t, 2, len(triggerMap.shadowedItemWarnings()))
+	}
+}
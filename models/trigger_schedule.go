@@ -0,0 +1,185 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CronSchedule is a parsed cron expression with the standard five fields (minute, hour, day of month, month, day of week),
+// it is evaluated in UTC.
+type CronSchedule struct {
+	minutes     uint64
+	hours       uint64
+	daysOfMonth uint64
+	months      uint64
+	daysOfWeek  uint64
+	// if both the day of month and the day of week fields are restricted, a day matches if either of them matches
+	daysOfMonthRestricted bool
+	daysOfWeekRestricted  bool
+}
+
+type cronField struct {
+	name  string
+	min   int
+	max   int
+	names map[string]int
+}
+
+var (
+	cronMinuteField     = cronField{name: "minute", min: 0, max: 59}
+	cronHourField       = cronField{name: "hour", min: 0, max: 23}
+	cronDayOfMonthField = cronField{name: "day of month", min: 1, max: 31}
+	cronMonthField      = cronField{name: "month", min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	// 7 is an alias of sunday
+	cronDayOfWeekField = cronField{name: "day of week", min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// ParseCronSchedule parses a cron expression: five space separated fields or one of the
+// @yearly, @annually, @monthly, @weekly, @daily, @midnight and @hourly macros.
+// A field is a comma separated list of values (*, 5, 1-5, */15, 1-30/2), months and days of week can be referred by name (jan, mon).
+func ParseCronSchedule(expression string) (CronSchedule, error) {
+	expression = strings.TrimSpace(expression)
+	if macro, ok := cronMacros[strings.ToLower(expression)]; ok {
+		expression = macro
+	}
+
+	fields := strings.Fields(expression)
+	if len(fields) != 5 {
+		return CronSchedule{}, fmt.Errorf("cron expression (%s) should have 5 fields (minute hour day-of-month month day-of-week), got: %d", expression, len(fields))
+	}
+
+	var schedule CronSchedule
+	var err error
+	if schedule.minutes, err = cronMinuteField.parse(fields[0]); err != nil {
+		return CronSchedule{}, err
+	}
+	if schedule.hours, err = cronHourField.parse(fields[1]); err != nil {
+		return CronSchedule{}, err
+	}
+	if schedule.daysOfMonth, err = cronDayOfMonthField.parse(fields[2]); err != nil {
+		return CronSchedule{}, err
+	}
+	if schedule.months, err = cronMonthField.parse(fields[3]); err != nil {
+		return CronSchedule{}, err
+	}
+	if schedule.daysOfWeek, err = cronDayOfWeekField.parse(fields[4]); err != nil {
+		return CronSchedule{}, err
+	}
+	if schedule.daysOfWeek&(1<<7) != 0 {
+		schedule.daysOfWeek |= 1
+	}
+	schedule.daysOfMonthRestricted = !strings.HasPrefix(fields[2], "*")
+	schedule.daysOfWeekRestricted = !strings.HasPrefix(fields[4], "*")
+
+	return schedule, nil
+}
+
+func (field cronField) parse(expression string) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(expression, ",") {
+		rangeExpression, step := part, 1
+		if idx := strings.Index(part, "/"); idx != -1 {
+			rangeExpression = part[:idx]
+			value, err := strconv.Atoi(part[idx+1:])
+			if err != nil || value < 1 {
+				return 0, fmt.Errorf("invalid %s step (%s) in: %s", field.name, part[idx+1:], expression)
+			}
+			step = value
+		}
+
+		start, end := field.min, field.max
+		if rangeExpression != "*" {
+			bounds := strings.SplitN(rangeExpression, "-", 2)
+
+			var err error
+			if start, err = field.value(bounds[0]); err != nil {
+				return 0, err
+			}
+			end = start
+			if len(bounds) == 2 {
+				if end, err = field.value(bounds[1]); err != nil {
+					return 0, err
+				}
+			} else if step > 1 {
+				// 5/15 means every 15th value starting from 5
+				end = field.max
+			}
+			if start > end {
+				return 0, fmt.Errorf("invalid %s range (%s) in: %s", field.name, rangeExpression, expression)
+			}
+		}
+
+		for value := start; value <= end; value += step {
+			bits |= 1 << uint(value)
+		}
+	}
+	return bits, nil
+}
+
+func (field cronField) value(expression string) (int, error) {
+	if value, ok := field.names[strings.ToLower(expression)]; ok {
+		return value, nil
+	}
+
+	value, err := strconv.Atoi(expression)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s value: %s", field.name, expression)
+	}
+	if value < field.min || value > field.max {
+		return 0, fmt.Errorf("%s value (%d) out of range (%d-%d)", field.name, value, field.min, field.max)
+	}
+	return value, nil
+}
+
+// Match returns true if the schedule fires in the minute of the given time.
+func (schedule CronSchedule) Match(t time.Time) bool {
+	t = t.UTC()
+	if schedule.minutes&(1<<uint(t.Minute())) == 0 ||
+		schedule.hours&(1<<uint(t.Hour())) == 0 ||
+		schedule.months&(1<<uint(t.Month())) == 0 {
+		return false
+	}
+
+	dayOfMonthMatch := schedule.daysOfMonth&(1<<uint(t.Day())) != 0
+	dayOfWeekMatch := schedule.daysOfWeek&(1<<uint(t.Weekday())) != 0
+	if schedule.daysOfMonthRestricted && schedule.daysOfWeekRestricted {
+		return dayOfMonthMatch || dayOfWeekMatch
+	}
+	return dayOfMonthMatch && dayOfWeekMatch
+}
+
+// ScheduledItems returns the schedule trigger map items, which fire in the minute of the given time.
+func (triggerMap TriggerMapModel) ScheduledItems(at time.Time) ([]TriggerMapItemModel, error) {
+	items := []TriggerMapItemModel{}
+	for _, item := range triggerMap {
+		if item.Schedule == "" {
+			continue
+		}
+
+		schedule, err := ParseCronSchedule(item.Schedule)
+		if err != nil {
+			return nil, fmt.Errorf("trigger map item (%s) has invalid schedule: %s", item.String(true), err)
+		}
+		if schedule.Match(at) {
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
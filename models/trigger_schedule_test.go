@@ -0,0 +1,167 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func parseTime(t *testing.T, value string) time.Time {
+	at, err := time.Parse(time.RFC3339, value)
+	require.NoError(t, err)
+	return at
+}
+
+func TestParseCronSchedule(t *testing.T) {
+	t.Log("valid expressions")
+	{
+		for _, expression := range []string{
+			"0 2 * * *",
+			"*/15 * * * *",
+			"0 9-17/2 * * mon-fri",
+			"30 4 1,15 * *",
+			"0 0 * jan,jul sun",
+			"0 0 * * 7",
+			"5/10 * * * *",
+			"@daily",
+			"@Hourly",
+		} {
+			_, err := ParseCronSchedule(expression)
+			require.NoError(t, err, expression)
+		}
+	}
+
+	t.Log("invalid expressions")
+	{
+		for expression, expectedErr := range map[string]string{
+			"0 2 * *":        "cron expression (0 2 * *) should have 5 fields (minute hour day-of-month month day-of-week), got: 4",
+			"60 * * * *":     "minute value (60) out of range (0-59)",
+			"0 24 * * *":     "hour value (24) out of range (0-23)",
+			"0 0 0 * *":      "day of month value (0) out of range (1-31)",
+			"0 0 * 13 *":     "month value (13) out of range (1-12)",
+			"0 0 * * 8":      "day of week value (8) out of range (0-7)",
+			"0 0 * * funday": "invalid day of week value: funday",
+			"*/0 * * * *":    "invalid minute step (0) in: */0",
+			"0 5-1 * * *":    "invalid hour range (5-1) in: 5-1",
+			"@sometimes":     "cron expression (@sometimes) should have 5 fields (minute hour day-of-month month day-of-week), got: 1",
+		} {
+			_, err := ParseCronSchedule(expression)
+			require.EqualError(t, err, expectedErr, expression)
+		}
+	}
+}
+
+func TestCronScheduleMatch(t *testing.T) {
+	t.Log("nightly")
+	{
+		schedule, err := ParseCronSchedule("0 2 * * *")
+		require.NoError(t, err)
+
+		require.True(t, schedule.Match(parseTime(t, "2021-03-10T02:00:00Z")))
+		require.True(t, schedule.Match(parseTime(t, "2021-03-10T02:00:59Z")))
+		require.False(t, schedule.Match(parseTime(t, "2021-03-10T02:01:00Z")))
+		// evaluated in UTC
+		require.True(t, schedule.Match(parseTime(t, "2021-03-10T03:00:00+01:00")))
+		require.False(t, schedule.Match(parseTime(t, "2021-03-10T02:00:00+01:00")))
+	}
+
+	t.Log("steps and ranges")
+	{
+		schedule, err := ParseCronSchedule("*/15 9-17 * * mon-fri")
+		require.NoError(t, err)
+
+		// 2021-03-10 is a wednesday
+		require.True(t, schedule.Match(parseTime(t, "2021-03-10T09:45:00Z")))
+		require.False(t, schedule.Match(parseTime(t, "2021-03-10T09:50:00Z")))
+		require.False(t, schedule.Match(parseTime(t, "2021-03-10T18:00:00Z")))
+		require.False(t, schedule.Match(parseTime(t, "2021-03-13T09:45:00Z")))
+	}
+
+	t.Log("sunday as 7")
+	{
+		schedule, err := ParseCronSchedule("0 0 * * 7")
+		require.NoError(t, err)
+
+		require.True(t, schedule.Match(parseTime(t, "2021-03-14T00:00:00Z")))
+		require.False(t, schedule.Match(parseTime(t, "2021-03-15T00:00:00Z")))
+	}
+
+	t.Log("restricted day of month and day of week")
+	{
+		// either the 1st of the month or a monday
+		schedule, err := ParseCronSchedule("0 0 1 * mon")
+		require.NoError(t, err)
+
+		require.True(t, schedule.Match(parseTime(t, "2021-03-01T00:00:00Z")))
+		require.True(t, schedule.Match(parseTime(t, "2021-03-08T00:00:00Z")))
+		require.False(t, schedule.Match(parseTime(t, "2021-03-09T00:00:00Z")))
+	}
+}
+
+func TestTriggerMapModelScheduledItems(t *testing.T) {
+	triggerMap := TriggerMapModel{
+		TriggerMapItemModel{PushBranch: "*", WorkflowID: "primary"},
+		TriggerMapItemModel{Schedule: "0 2 * * *", WorkflowID: "nightly"},
+		TriggerMapItemModel{Schedule: "0 2 * * *", PipelineID: "nightly-tests"},
+		TriggerMapItemModel{Schedule: "@weekly", WorkflowID: "weekly"},
+	}
+	require.NoError(t, triggerMap.Validate())
+	require.NoError(t, checkDuplicatedTriggerMapItems(triggerMap))
+
+	t.Log("multiple items fire")
+	{
+		items, err := triggerMap.ScheduledItems(parseTime(t, "2021-03-10T02:00:00Z"))
+		require.NoError(t, err)
+		require.Equal(t, []TriggerMapItemModel{triggerMap[1], triggerMap[2]}, items)
+	}
+
+	t.Log("no item fires")
+	{
+		items, err := triggerMap.ScheduledItems(parseTime(t, "2021-03-10T03:00:00Z"))
+		require.NoError(t, err)
+		require.Equal(t, []TriggerMapItemModel{}, items)
+	}
+
+	t.Log("schedule items do not match git events")
+	{
+		item, err := triggerMap[1:].FirstMatch(TriggerEventModel{PushBranch: "master"})
+		require.NoError(t, err)
+		require.Nil(t, item)
+	}
+}
+
+func TestTriggerMapItemModelValidateSchedule(t *testing.T) {
+	t.Log("invalid cron expression")
+	{
+		item := TriggerMapItemModel{Schedule: "0 25 * * *", WorkflowID: "nightly"}
+		require.EqualError(t, item.Validate(), "trigger map item (schedule: 0 25 * * * -> workflow: nightly) validate failed, error: invalid schedule: hour value (25) out of range (0-23)")
+	}
+
+	t.Log("schedule mixed with branch conditions")
+	{
+		item := TriggerMapItemModel{Schedule: "0 2 * * *", PushBranch: "master", WorkflowID: "nightly"}
+		require.EqualError(t, item.Validate(), "trigger map item (push_branch: master schedule: 0 2 * * * -> workflow: nightly) validate failed, error: schedule (0 2 * * *) selects scheduled trigger event, but branch or tag conditions also provided")
+	}
+
+	t.Log("schedule with event conditions")
+	{
+		item := TriggerMapItemModel{Schedule: "0 2 * * *", CommitMessage: &CommitMessageFilterModel{Include: []string{"*"}}, WorkflowID: "nightly"}
+		require.EqualError(t, item.Validate(), "trigger map item (schedule: 0 2 * * * commit_message: include: [*] -> workflow: nightly) validate failed, error: changed_files and commit_message are not supported on schedule trigger items")
+	}
+
+	t.Log("schedule with deprecated pattern")
+	{
+		item := TriggerMapItemModel{Schedule: "0 2 * * *", Pattern: "*", WorkflowID: "nightly"}
+		require.Error(t, item.Validate())
+	}
+
+	t.Log("duplicated schedule")
+	{
+		triggerMap := TriggerMapModel{
+			TriggerMapItemModel{Schedule: "0 2 * * *", WorkflowID: "nightly"},
+			TriggerMapItemModel{Schedule: "0 2 * * *", WorkflowID: "nightly"},
+		}
+		require.EqualError(t, checkDuplicatedTriggerMapItems(triggerMap), "duplicated trigger item found (schedule: 0 2 * * * -> workflow: nightly)")
+	}
+}
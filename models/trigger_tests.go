@@ -0,0 +1,96 @@
+package models
+
+import (
+	"fmt"
+)
+
+// TriggerTestResult is the result of a trigger test (TriggerTestModel).
+type TriggerTestResult struct {
+	Index            int    `json:"index" yaml:"index"`
+	Title            string `json:"title,omitempty" yaml:"title,omitempty"`
+	Event            string `json:"event" yaml:"event"`
+	ExpectedPipeline string `json:"expected_pipeline,omitempty" yaml:"expected_pipeline,omitempty"`
+	ExpectedWorkflow string `json:"expected_workflow,omitempty" yaml:"expected_workflow,omitempty"`
+	ActualPipeline   string `json:"actual_pipeline,omitempty" yaml:"actual_pipeline,omitempty"`
+	ActualWorkflow   string `json:"actual_workflow,omitempty" yaml:"actual_workflow,omitempty"`
+	Passed           bool   `json:"passed" yaml:"passed"`
+	Error            string `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// Event ...
+func (test TriggerTestModel) Event() TriggerEventModel {
+	event := TriggerEventModel{
+		PushBranch:     test.PushBranch,
+		PRSourceBranch: test.PullRequestSourceBranch,
+		PRTargetBranch: test.PullRequestTargetBranch,
+		Tag:            test.Tag,
+		ChangedFiles:   test.ChangedFiles,
+		CommitMessage:  test.CommitMessage,
+		PRLabels:       test.PullRequestLabels,
+		IsDraftPR:      test.DraftPullRequest,
+	}
+	return event
+}
+
+// String ...
+func (test TriggerTestModel) String() string {
+	if test.Title != "" {
+		return test.Title
+	}
+	return test.Event().String()
+}
+
+// Validate ...
+func (test TriggerTestModel) Validate() error {
+	if test.PipelineID != "" && test.WorkflowID != "" {
+		return fmt.Errorf("invalid trigger test (%s), error: pipeline & workflow both defined", test)
+	}
+	if _, err := triggerEventType(test.PushBranch, test.PullRequestSourceBranch, test.PullRequestTargetBranch, test.Tag); err != nil {
+		return fmt.Errorf("invalid trigger test (%s), error: %s", test, err)
+	}
+	return nil
+}
+
+// FirstMatch returns the first trigger map item matching the event, or nil if none of the items match.
+func (triggerMap TriggerMapModel) FirstMatch(event TriggerEventModel) (*TriggerMapItemModel, error) {
+	for _, item := range triggerMap {
+		match, err := item.Match(event)
+		if err != nil {
+			return nil, err
+		}
+		if match {
+			return &item, nil
+		}
+	}
+	return nil, nil
+}
+
+// RunTests matches the events of the trigger tests against the trigger map,
+// a test passes if the first matching item triggers the expected pipeline or workflow.
+func (triggerMap TriggerMapModel) RunTests(tests []TriggerTestModel) []TriggerTestResult {
+	results := []TriggerTestResult{}
+	for idx, test := range tests {
+		event := test.Event()
+		result := TriggerTestResult{
+			Index:            idx,
+			Title:            test.Title,
+			Event:            event.String(),
+			ExpectedPipeline: test.PipelineID,
+			ExpectedWorkflow: test.WorkflowID,
+		}
+
+		item, err := triggerMap.FirstMatch(event)
+		if err != nil {
+			result.Error = err.Error()
+		} else {
+			if item != nil {
+				result.ActualPipeline = item.PipelineID
+				result.ActualWorkflow = item.WorkflowID
+			}
+			result.Passed = result.ActualPipeline == result.ExpectedPipeline && result.ActualWorkflow == result.ExpectedWorkflow
+		}
+
+		results = append(results, result)
+	}
+	return results
+}
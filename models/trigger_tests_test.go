@@ -0,0 +1,67 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTriggerMapRunTests(t *testing.T) {
+	triggerMap := TriggerMapModel{
+		{PushBranch: "master", ChangedFiles: &ChangedFilesFilterModel{Include: []string{"ios/*"}}, WorkflowID: "ios"},
+		{PushBranch: "*", WorkflowID: "primary"},
+		{Tag: "*", PipelineID: "release"},
+	}
+
+	results := triggerMap.RunTests([]TriggerTestModel{
+		{Title: "ios change", PushBranch: "master", ChangedFiles: []string{"ios/Podfile"}, WorkflowID: "ios"},
+		{PushBranch: "master", ChangedFiles: []string{"README.md"}, WorkflowID: "ios"},
+		{Tag: "1.0.0", PipelineID: "release"},
+		{PullRequestSourceBranch: "develop"},
+		{},
+	})
+
+	require.Equal(t, []TriggerTestResult{
+		{Index: 0, Title: "ios change", Event: "push-branch: master, changed-files: [ios/Podfile]", ExpectedWorkflow: "ios", ActualWorkflow: "ios", Passed: true},
+		{Index: 1, Event: "push-branch: master, changed-files: [README.md]", ExpectedWorkflow: "ios", ActualWorkflow: "primary"},
+		{Index: 2, Event: "tag: 1.0.0", ExpectedPipeline: "release", ActualPipeline: "release", Passed: true},
+		{Index: 3, Event: "pr-source-branch: develop", Passed: true},
+		{Index: 4, Error: "failed to determin trigger event from params: push-branch: , pr-source-branch: , pr-target-branch: , tag: "},
+	}, results)
+}
+
+func TestValidateConfigTriggerTests(t *testing.T) {
+	t.Log("valid trigger tests")
+	{
+		config := BitriseDataModel{
+			FormatVersion: "12",
+			TriggerMap:    TriggerMapModel{{PushBranch: "*", WorkflowID: "primary"}},
+			TriggerTests:  []TriggerTestModel{{PushBranch: "master", WorkflowID: "primary"}, {Tag: "1.0.0"}},
+			Workflows:     map[string]WorkflowModel{"primary": {}},
+		}
+		_, err := config.Validate()
+		require.NoError(t, err)
+	}
+
+	t.Log("missing event")
+	{
+		config := BitriseDataModel{
+			FormatVersion: "12",
+			TriggerTests:  []TriggerTestModel{{Title: "empty", WorkflowID: "primary"}},
+			Workflows:     map[string]WorkflowModel{"primary": {}},
+		}
+		_, err := config.Validate()
+		require.EqualError(t, err, "invalid trigger test (empty), error: failed to determin trigger event from params: push-branch: , pr-source-branch: , pr-target-branch: , tag: ")
+	}
+
+	t.Log("workflow does not exist")
+	{
+		config := BitriseDataModel{
+			FormatVersion: "12",
+			TriggerTests:  []TriggerTestModel{{PushBranch: "master", WorkflowID: "secondary"}},
+			Workflows:     map[string]WorkflowModel{"primary": {}},
+		}
+		_, err := config.Validate()
+		require.EqualError(t, err, "workflow (secondary) defined in trigger test (push-branch: master), but does not exist")
+	}
+}
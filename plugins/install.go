@@ -14,6 +14,7 @@ import (
 	"github.com/bitrise-io/go-utils/progress"
 	"github.com/bitrise-io/go-utils/sliceutil"
 	ver "github.com/hashicorp/go-version"
+	"github.com/tothszabi/bitrise-test/configs"
 	"github.com/tothszabi/bitrise-test/log"
 )
 
@@ -56,6 +57,10 @@ func downloadPluginBin(sourceURL, destinationPth string) error {
 	}
 
 	// Download remote binary
+	if err := configs.CheckNetworkAccess(fmt.Sprintf("downloading plugin binary (%s)", sourceURL)); err != nil {
+		return err
+	}
+
 	out, err := os.Create(destinationPth)
 	defer func() {
 		if err := out.Close(); err != nil {
@@ -239,6 +244,10 @@ func InstallPlugin(pluginSourceURI, versionTag string) (Plugin, string, error) {
 	pluginDir := ""
 
 	if !isLocalURL(pluginSourceURI) {
+		if err := configs.CheckNetworkAccess(fmt.Sprintf("installing plugin (%s)", pluginSourceURI)); err != nil {
+			return Plugin{}, "", err
+		}
+
 		pluginSrcTmpDir, err := pathutil.NormalizedOSTempDirPath("plugin-src-tmp")
 		if err != nil {
 			return Plugin{}, "", fmt.Errorf("failed to create plugin src temp directory, error: %s", err)
@@ -83,7 +83,7 @@ func PrintPluginUpdateInfos(newVersion string, plugin Plugin) {
 }
 
 func runPlugin(plugin Plugin, args []string, envs PluginConfig, input []byte) error {
-	if !configs.IsCIMode && configs.CheckIsPluginUpdateCheckRequired(plugin.Name) {
+	if !configs.IsCIMode && !configs.IsOfflineMode && configs.CheckIsPluginUpdateCheckRequired(plugin.Name) {
 		// Check for new version
 		log.Infof("Checking for plugin (%s) new version...", plugin.Name)
 
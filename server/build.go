@@ -0,0 +1,41 @@
+package server
+
+import (
+	"time"
+
+	"github.com/tothszabi/bitrise-test/webhook"
+)
+
+// BuildStatus ...
+type BuildStatus string
+
+const (
+	// BuildStatusQueued ...
+	BuildStatusQueued BuildStatus = "queued"
+	// BuildStatusRunning ...
+	BuildStatusRunning BuildStatus = "running"
+	// BuildStatusSucceeded ...
+	BuildStatusSucceeded BuildStatus = "succeeded"
+	// BuildStatusFailed ...
+	BuildStatusFailed BuildStatus = "failed"
+)
+
+// Build is a queued run of the workflow triggered by a webhook.
+type Build struct {
+	ID         int              `json:"id"`
+	Provider   webhook.Provider `json:"provider"`
+	Event      string           `json:"event"`
+	WorkflowID string           `json:"workflow"`
+	CommitHash string           `json:"commit_hash,omitempty"`
+	Status     BuildStatus      `json:"status"`
+	Error      string           `json:"error,omitempty"`
+	QueuedAt   time.Time        `json:"queued_at"`
+	StartedAt  *time.Time       `json:"started_at,omitempty"`
+	FinishedAt *time.Time       `json:"finished_at,omitempty"`
+
+	webhookEvent webhook.Event
+	payload      []byte
+	// signed is true if the webhook's signature was verified with the shared secret.
+	signed  bool
+	logPath string
+}
@@ -0,0 +1,104 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/command"
+)
+
+// Runner runs a queued build, the output of the build is written to the log writer.
+type Runner func(build Build, log io.Writer) error
+
+var commitHashRegexp = regexp.MustCompile(`^[0-9a-fA-F]{7,64}$`)
+
+// NewTriggerRunner returns a Runner, which checks out the commit of the build in the working copy,
+// then runs `bitrise trigger` with the webhook payload of the build.
+// Pull requests from forks are only built if buildForkPRs is set, as they run the fork author's code on this machine.
+func NewTriggerRunner(bitriseBinaryPath, workDir, configPath string, buildForkPRs bool) Runner {
+	return func(build Build, log io.Writer) error {
+		run := func(name string, args ...string) error {
+			fmt.Fprintf(log, "$ %s\n", command.New(name, args...).PrintableCommandArgs())
+			cmd := command.New(name, args...).SetDir(workDir).SetStdout(log).SetStderr(log)
+			return cmd.Run()
+		}
+
+		if build.CommitHash != "" {
+			if !commitHashRegexp.MatchString(build.CommitHash) {
+				return fmt.Errorf("invalid commit hash: %s", build.CommitHash)
+			}
+
+			// the pull request repository of the payload is fetched only on opt-in and only if the payload is authentic,
+			// otherwise anyone could run an arbitrary repository's commit
+			event := build.webhookEvent
+			isFork := event.PullRequestRepositoryURL != "" && event.PullRequestRepositoryURL != event.RepositoryURL && event.PRSourceBranch != ""
+			if isFork {
+				if !buildForkPRs {
+					return fmt.Errorf("pull request from a fork (%s) is not built, pull requests from forks are only built with --build-fork-prs", event.PullRequestRepositoryURL)
+				}
+				if !build.signed {
+					return fmt.Errorf("pull request from a fork (%s) is only built for signed webhooks, define a webhook secret", event.PullRequestRepositoryURL)
+				}
+				if err := validateFetchArguments(event.PullRequestRepositoryURL, event.PRSourceBranch); err != nil {
+					return err
+				}
+			}
+
+			if err := run("git", "fetch", "--tags", "--force", "origin"); err != nil {
+				return fmt.Errorf("failed to fetch the repository: %s", err)
+			}
+
+			if isFork {
+				if err := run("git", "fetch", "--", event.PullRequestRepositoryURL, "refs/heads/"+event.PRSourceBranch); err != nil {
+					return fmt.Errorf("failed to fetch the pull request repository: %s", err)
+				}
+			}
+
+			if err := run("git", "checkout", "--force", "--detach", build.CommitHash, "--"); err != nil {
+				return fmt.Errorf("failed to checkout the commit (%s): %s", build.CommitHash, err)
+			}
+		}
+
+		payloadFile, err := ioutil.TempFile("", "webhook-payload-*.json")
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err := os.Remove(payloadFile.Name()); err != nil {
+				fmt.Fprintf(log, "Failed to remove the payload file: %s\n", err)
+			}
+		}()
+		if _, err := payloadFile.Write(build.payload); err != nil {
+			return err
+		}
+		if err := payloadFile.Close(); err != nil {
+			return err
+		}
+
+		args := []string{"trigger", "--event-payload", payloadFile.Name(), "--provider", string(build.Provider)}
+		if configPath != "" {
+			if !filepath.IsAbs(configPath) {
+				configPath = filepath.Join(workDir, configPath)
+			}
+			args = append(args, "--config", configPath)
+		}
+		return run(bitriseBinaryPath, args...)
+	}
+}
+
+// validateFetchArguments checks that the pull request repository URL and branch of the payload
+// can not be interpreted as git options or refspecs.
+func validateFetchArguments(repositoryURL, branch string) error {
+	if repositoryURL == "" || strings.HasPrefix(repositoryURL, "-") {
+		return fmt.Errorf("invalid pull request repository URL: %s", repositoryURL)
+	}
+	if branch == "" || strings.HasPrefix(branch, "-") || strings.ContainsAny(branch, ": \t\n~^?*[\\") || strings.Contains(branch, "..") {
+		return fmt.Errorf("invalid pull request branch: %s", branch)
+	}
+	return nil
+}
@@ -0,0 +1,327 @@
+// Package server implements a webhook listener, which runs the workflows selected by the trigger map
+// of the webhooks' git events in a local working copy.
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tothszabi/bitrise-test/bitrise"
+	"github.com/tothszabi/bitrise-test/log"
+	"github.com/tothszabi/bitrise-test/webhook"
+)
+
+const (
+	maxPayloadSize = 25 * 1024 * 1024
+	queueSize      = 100
+
+	authorizationHeader = "Authorization"
+	bearerPrefix        = "Bearer "
+)
+
+// Config ...
+type Config struct {
+	// Secret is the shared secret of the webhooks, the requests are not verified if empty.
+	Secret string
+	// ConfigPath is the path of the bitrise config, the workflows are selected by its trigger map.
+	ConfigPath string
+	// LogDir is the directory of the build logs.
+	LogDir string
+	// APIToken is the bearer token of the builds endpoints, the builds endpoints are disabled if empty.
+	APIToken string
+	Runner   Runner
+}
+
+// Server receives webhooks and runs the triggered builds one by one.
+type Server struct {
+	config Config
+
+	mutex  sync.Mutex
+	builds []*Build
+	queue  chan *Build
+}
+
+type webhookResponse struct {
+	Triggered bool   `json:"triggered"`
+	Message   string `json:"message,omitempty"`
+	Build     *Build `json:"build,omitempty"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// New ...
+func New(config Config) *Server {
+	return &Server{
+		config: config,
+		queue:  make(chan *Build, queueSize),
+	}
+}
+
+// Handler returns the HTTP handler of the server:
+//
+//	POST /webhooks/{github|gitlab|bitbucket}
+//	GET  /builds
+//	GET  /builds/{id}
+//	GET  /builds/{id}/log
+//
+// The builds endpoints require the API token (Authorization: Bearer <token>).
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/webhooks/", s.handleWebhook)
+	mux.HandleFunc("/builds", s.requireAPIToken(s.handleBuilds))
+	mux.HandleFunc("/builds/", s.requireAPIToken(s.handleBuild))
+	return mux
+}
+
+// requireAPIToken returns a handler, which serves the request only if it carries the API token,
+// the handler is disabled if no API token is defined.
+func (s *Server) requireAPIToken(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.config.APIToken == "" {
+			writeJSON(w, http.StatusNotFound, errorResponse{Error: "the builds endpoints are disabled, define an API token to enable them"})
+			return
+		}
+
+		token := strings.TrimPrefix(r.Header.Get(authorizationHeader), bearerPrefix)
+		if token == r.Header.Get(authorizationHeader) || subtle.ConstantTimeCompare([]byte(token), []byte(s.config.APIToken)) != 1 {
+			writeJSON(w, http.StatusUnauthorized, errorResponse{Error: "missing or invalid " + authorizationHeader + " header"})
+			return
+		}
+
+		handler(w, r)
+	}
+}
+
+// Run runs the queued builds one by one, until the context is cancelled.
+func (s *Server) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case build := <-s.queue:
+			s.runBuild(build)
+		}
+	}
+}
+
+func (s *Server) runBuild(build *Build) {
+	s.mutex.Lock()
+	startedAt := time.Now()
+	build.Status = BuildStatusRunning
+	build.StartedAt = &startedAt
+	build.logPath = filepath.Join(s.config.LogDir, fmt.Sprintf("%d.log", build.ID))
+	buildCopy := *build
+	s.mutex.Unlock()
+
+	log.Infof("Build #%d (%s) started", build.ID, build.WorkflowID)
+
+	err := func() error {
+		logFile, err := os.Create(buildCopy.logPath)
+		if err != nil {
+			return fmt.Errorf("failed to create the build log: %s", err)
+		}
+		defer func() {
+			if err := logFile.Close(); err != nil {
+				log.Warnf("Failed to close the build log: %s", err)
+			}
+		}()
+
+		return s.config.Runner(buildCopy, logFile)
+	}()
+
+	s.mutex.Lock()
+	finishedAt := time.Now()
+	build.FinishedAt = &finishedAt
+	if err != nil {
+		build.Status = BuildStatusFailed
+		build.Error = err.Error()
+	} else {
+		build.Status = BuildStatusSucceeded
+	}
+	s.mutex.Unlock()
+
+	if err != nil {
+		log.Errorf("Build #%d failed: %s", build.ID, err)
+	} else {
+		log.Donef("Build #%d succeeded", build.ID)
+	}
+}
+
+func (s *Server) handleWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse{Error: "method not allowed"})
+		return
+	}
+
+	provider, err := webhook.ParseProvider(strings.TrimPrefix(r.URL.Path, "/webhooks/"))
+	if err != nil {
+		writeJSON(w, http.StatusNotFound, errorResponse{Error: err.Error()})
+		return
+	}
+
+	payload, err := ioutil.ReadAll(io.LimitReader(r.Body, maxPayloadSize))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: fmt.Sprintf("failed to read the payload: %s", err)})
+		return
+	}
+
+	signed := false
+	if s.config.Secret != "" {
+		if err := verifySignature(provider, s.config.Secret, r.Header, payload); err != nil {
+			writeJSON(w, http.StatusUnauthorized, errorResponse{Error: err.Error()})
+			return
+		}
+		signed = true
+	}
+
+	eventType := ""
+	switch provider {
+	case webhook.ProviderGitHub:
+		eventType = r.Header.Get("X-GitHub-Event")
+		if eventType == "ping" {
+			writeJSON(w, http.StatusOK, webhookResponse{Message: "pong"})
+			return
+		}
+	case webhook.ProviderBitbucket:
+		eventType = r.Header.Get("X-Event-Key")
+	}
+
+	event, err := webhook.Parse(provider, eventType, payload)
+	if err != nil {
+		writeJSON(w, http.StatusOK, webhookResponse{Message: err.Error()})
+		return
+	}
+
+	config, _, err := bitrise.ReadBitriseConfig(s.config.ConfigPath)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: fmt.Sprintf("failed to read the config (%s): %s", s.config.ConfigPath, err)})
+		return
+	}
+
+	triggerEvent := event.TriggerEvent()
+	item, err := config.TriggerMap.FirstMatch(triggerEvent)
+	if err != nil {
+		writeJSON(w, http.StatusOK, webhookResponse{Message: err.Error()})
+		return
+	}
+	if item == nil {
+		writeJSON(w, http.StatusOK, webhookResponse{Message: fmt.Sprintf("no matching workflow found for the event (%s)", triggerEvent)})
+		return
+	}
+	if item.PipelineID != "" {
+		writeJSON(w, http.StatusOK, webhookResponse{Message: fmt.Sprintf("the event (%s) triggers a pipeline (%s), pipelines can not be run locally", triggerEvent, item.PipelineID)})
+		return
+	}
+
+	build, err := s.enqueue(provider, event, payload, signed, item.WorkflowID)
+	if err != nil {
+		writeJSON(w, http.StatusServiceUnavailable, errorResponse{Error: err.Error()})
+		return
+	}
+
+	log.Infof("Build #%d (%s) queued for the event: %s", build.ID, build.WorkflowID, build.Event)
+	writeJSON(w, http.StatusAccepted, webhookResponse{Triggered: true, Build: &build})
+}
+
+func (s *Server) enqueue(provider webhook.Provider, event webhook.Event, payload []byte, signed bool, workflowID string) (Build, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	build := &Build{
+		ID:           len(s.builds) + 1,
+		Provider:     provider,
+		Event:        event.TriggerEvent().String(),
+		WorkflowID:   workflowID,
+		CommitHash:   event.CommitHash,
+		Status:       BuildStatusQueued,
+		QueuedAt:     time.Now(),
+		webhookEvent: event,
+		payload:      payload,
+		signed:       signed,
+	}
+
+	select {
+	case s.queue <- build:
+	default:
+		return Build{}, fmt.Errorf("the build queue is full")
+	}
+
+	s.builds = append(s.builds, build)
+	return *build, nil
+}
+
+func (s *Server) handleBuilds(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse{Error: "method not allowed"})
+		return
+	}
+
+	s.mutex.Lock()
+	builds := []Build{}
+	for _, build := range s.builds {
+		builds = append(builds, *build)
+	}
+	s.mutex.Unlock()
+
+	writeJSON(w, http.StatusOK, builds)
+}
+
+func (s *Server) handleBuild(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse{Error: "method not allowed"})
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/builds/")
+	isLog := strings.HasSuffix(path, "/log")
+	path = strings.TrimSuffix(path, "/log")
+
+	id, err := strconv.Atoi(path)
+	s.mutex.Lock()
+	if err != nil || id < 1 || id > len(s.builds) {
+		s.mutex.Unlock()
+		writeJSON(w, http.StatusNotFound, errorResponse{Error: fmt.Sprintf("build not found: %s", path)})
+		return
+	}
+	build := *s.builds[id-1]
+	s.mutex.Unlock()
+
+	if !isLog {
+		writeJSON(w, http.StatusOK, build)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if build.logPath == "" {
+		return
+	}
+	content, err := ioutil.ReadFile(build.logPath)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: fmt.Sprintf("failed to read the build log: %s", err)})
+		return
+	}
+	if _, err := w.Write(content); err != nil {
+		log.Warnf("Failed to write the build log response: %s", err)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Warnf("Failed to write the response: %s", err)
+	}
+}
@@ -0,0 +1,337 @@
+package server
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tothszabi/bitrise-test/webhook"
+)
+
+const testAPIToken = "t0ken"
+
+const testConfig = `format_version: "12"
+trigger_map:
+- push_branch: master
+  workflow: primary
+- tag: "*"
+  pipeline: release
+workflows:
+  primary:
+pipelines:
+  release:
+    stages:
+    - build: {}
+stages:
+  build:
+    workflows:
+    - primary: {}
+`
+
+func newTestServer(t *testing.T, secret string, runner Runner) (*Server, *httptest.Server, func()) {
+	dir, err := ioutil.TempDir("", "serve")
+	require.NoError(t, err)
+
+	configPath := filepath.Join(dir, "bitrise.yml")
+	require.NoError(t, ioutil.WriteFile(configPath, []byte(testConfig), 0644))
+
+	srv := New(Config{Secret: secret, ConfigPath: configPath, LogDir: dir, APIToken: testAPIToken, Runner: runner})
+	ctx, cancel := context.WithCancel(context.Background())
+	go srv.Run(ctx)
+	httpServer := httptest.NewServer(srv.Handler())
+
+	return srv, httpServer, func() {
+		httpServer.Close()
+		cancel()
+		require.NoError(t, os.RemoveAll(dir))
+	}
+}
+
+func postWebhook(t *testing.T, url, provider, payload string, headers map[string]string) (int, webhookResponse) {
+	req, err := http.NewRequest(http.MethodPost, url+"/webhooks/"+provider, strings.NewReader(payload))
+	require.NoError(t, err)
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, resp.Body.Close())
+	}()
+
+	var response webhookResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&response))
+	return resp.StatusCode, response
+}
+
+func getWithToken(t *testing.T, url, token string) *http.Response {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	return resp
+}
+
+func getBuild(t *testing.T, url string, id int) Build {
+	resp := getWithToken(t, fmt.Sprintf("%s/builds/%d", url, id), testAPIToken)
+	defer func() {
+		require.NoError(t, resp.Body.Close())
+	}()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var build Build
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&build))
+	return build
+}
+
+func waitForBuild(t *testing.T, url string, id int) Build {
+	for i := 0; i < 100; i++ {
+		build := getBuild(t, url, id)
+		if build.Status == BuildStatusSucceeded || build.Status == BuildStatusFailed {
+			return build
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatalf("build #%d did not finish", id)
+	return Build{}
+}
+
+func TestServerWebhooks(t *testing.T) {
+	var runBuilds []Build
+	runner := func(build Build, log io.Writer) error {
+		runBuilds = append(runBuilds, build)
+		_, err := fmt.Fprintf(log, "running %s", build.WorkflowID)
+		if build.CommitHash == "failing" {
+			return errors.New("exit status 1")
+		}
+		return err
+	}
+
+	_, httpServer, cleanup := newTestServer(t, "", runner)
+	defer cleanup()
+
+	t.Log("triggered push")
+	{
+		statusCode, response := postWebhook(t, httpServer.URL, "github", `{"ref":"refs/heads/master","after":"a1","head_commit":{"message":"Fix"}}`, map[string]string{"X-GitHub-Event": "push"})
+		require.Equal(t, http.StatusAccepted, statusCode)
+		require.True(t, response.Triggered)
+		require.Equal(t, 1, response.Build.ID)
+		require.Equal(t, "primary", response.Build.WorkflowID)
+
+		build := waitForBuild(t, httpServer.URL, 1)
+		require.Equal(t, BuildStatusSucceeded, build.Status)
+		require.Equal(t, "push-branch: master, commit-message: Fix", build.Event)
+		require.Equal(t, "a1", build.CommitHash)
+
+		resp := getWithToken(t, httpServer.URL+"/builds/1/log", testAPIToken)
+		content, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+		require.Equal(t, "running primary", string(content))
+	}
+
+	t.Log("failing build")
+	{
+		statusCode, _ := postWebhook(t, httpServer.URL, "gitlab", `{"object_kind":"push","ref":"refs/heads/master","after":"failing","checkout_sha":"failing"}`, nil)
+		require.Equal(t, http.StatusAccepted, statusCode)
+
+		build := waitForBuild(t, httpServer.URL, 2)
+		require.Equal(t, BuildStatusFailed, build.Status)
+		require.Equal(t, "exit status 1", build.Error)
+	}
+
+	t.Log("not triggered events")
+	{
+		statusCode, response := postWebhook(t, httpServer.URL, "github", `{"ref":"refs/heads/develop","after":"b2"}`, nil)
+		require.Equal(t, http.StatusOK, statusCode)
+		require.False(t, response.Triggered)
+		require.Equal(t, "no matching workflow found for the event (push-branch: develop)", response.Message)
+
+		statusCode, response = postWebhook(t, httpServer.URL, "github", `{"ref":"refs/tags/1.0.0","after":"c3"}`, nil)
+		require.Equal(t, http.StatusOK, statusCode)
+		require.False(t, response.Triggered)
+		require.Equal(t, "the event (tag: 1.0.0) triggers a pipeline (release), pipelines can not be run locally", response.Message)
+
+		statusCode, response = postWebhook(t, httpServer.URL, "github", `{}`, map[string]string{"X-GitHub-Event": "ping"})
+		require.Equal(t, http.StatusOK, statusCode)
+		require.Equal(t, "pong", response.Message)
+	}
+
+	t.Log("unknown provider")
+	{
+		statusCode, _ := postWebhook(t, httpServer.URL, "svn", `{}`, nil)
+		require.Equal(t, http.StatusNotFound, statusCode)
+	}
+
+	t.Log("list builds")
+	{
+		resp := getWithToken(t, httpServer.URL+"/builds", testAPIToken)
+		var builds []Build
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&builds))
+		require.NoError(t, resp.Body.Close())
+		require.Equal(t, 2, len(builds))
+		require.Equal(t, 2, len(runBuilds))
+	}
+
+	t.Log("unknown build")
+	{
+		resp := getWithToken(t, httpServer.URL+"/builds/3", testAPIToken)
+		require.NoError(t, resp.Body.Close())
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	}
+}
+
+func TestServerSignatureVerification(t *testing.T) {
+	runner := func(build Build, log io.Writer) error { return nil }
+	_, httpServer, cleanup := newTestServer(t, "s3cr3t", runner)
+	defer cleanup()
+
+	payload := `{"ref":"refs/heads/master","after":"a1"}`
+
+	t.Log("valid GitHub signature")
+	{
+		signature := "sha256=" + hex.EncodeToString(payloadSignature("s3cr3t", []byte(payload)))
+		statusCode, response := postWebhook(t, httpServer.URL, "github", payload, map[string]string{gitHubSignatureHeader: signature})
+		require.Equal(t, http.StatusAccepted, statusCode)
+		require.True(t, response.Triggered)
+	}
+
+	t.Log("invalid GitHub signature")
+	{
+		signature := "sha256=" + hex.EncodeToString(payloadSignature("other", []byte(payload)))
+		statusCode, _ := postWebhook(t, httpServer.URL, "github", payload, map[string]string{gitHubSignatureHeader: signature})
+		require.Equal(t, http.StatusUnauthorized, statusCode)
+
+		statusCode, _ = postWebhook(t, httpServer.URL, "github", payload, nil)
+		require.Equal(t, http.StatusUnauthorized, statusCode)
+	}
+
+	t.Log("GitLab token")
+	{
+		gitLabPayload := `{"object_kind":"push","ref":"refs/heads/master","after":"a1","checkout_sha":"a1"}`
+		statusCode, _ := postWebhook(t, httpServer.URL, "gitlab", gitLabPayload, map[string]string{gitLabTokenHeader: "s3cr3t"})
+		require.Equal(t, http.StatusAccepted, statusCode)
+
+		statusCode, _ = postWebhook(t, httpServer.URL, "gitlab", gitLabPayload, map[string]string{gitLabTokenHeader: "other"})
+		require.Equal(t, http.StatusUnauthorized, statusCode)
+	}
+}
+
+func TestServerBuildsAuthentication(t *testing.T) {
+	runner := func(build Build, log io.Writer) error { return nil }
+	srv, httpServer, cleanup := newTestServer(t, "", runner)
+	defer cleanup()
+
+	t.Log("missing or invalid token")
+	{
+		for _, token := range []string{"", "other"} {
+			for _, path := range []string{"/builds", "/builds/1", "/builds/1/log"} {
+				resp := getWithToken(t, httpServer.URL+path, token)
+				require.NoError(t, resp.Body.Close())
+				require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+			}
+		}
+	}
+
+	t.Log("token without bearer prefix")
+	{
+		req, err := http.NewRequest(http.MethodGet, httpServer.URL+"/builds", nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", testAPIToken)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+		require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	}
+
+	t.Log("builds endpoints are disabled without token")
+	{
+		srv.config.APIToken = ""
+		resp := getWithToken(t, httpServer.URL+"/builds", "")
+		require.NoError(t, resp.Body.Close())
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	}
+}
+
+func TestTriggerRunnerForkPullRequest(t *testing.T) {
+	workDir, err := ioutil.TempDir("", "serve-workdir")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(workDir))
+	}()
+
+	build := Build{
+		CommitHash: "a1b2c3d",
+		webhookEvent: webhook.Event{
+			PRSourceBranch:           "feature",
+			PRTargetBranch:           "master",
+			CommitHash:               "a1b2c3d",
+			RepositoryURL:            "https://github.com/bitrise-io/bitrise.git",
+			PullRequestRepositoryURL: "https://github.com/fork/bitrise.git",
+		},
+	}
+
+	t.Log("fork pull requests are not built by default")
+	{
+		var log strings.Builder
+		err := NewTriggerRunner("bitrise", workDir, "", false)(build, &log)
+		require.EqualError(t, err, "pull request from a fork (https://github.com/fork/bitrise.git) is not built, pull requests from forks are only built with --build-fork-prs")
+		require.Equal(t, "", log.String())
+	}
+
+	t.Log("unsigned fork pull request")
+	{
+		var log strings.Builder
+		err := NewTriggerRunner("bitrise", workDir, "", true)(build, &log)
+		require.EqualError(t, err, "pull request from a fork (https://github.com/fork/bitrise.git) is only built for signed webhooks, define a webhook secret")
+		require.Equal(t, "", log.String())
+	}
+
+	t.Log("option like fork repository URL and branch")
+	{
+		signedBuild := build
+		signedBuild.signed = true
+
+		signedBuild.webhookEvent.PRSourceBranch = "--upload-pack=touch /tmp/pwned"
+		var log strings.Builder
+		err := NewTriggerRunner("bitrise", workDir, "", true)(signedBuild, &log)
+		require.EqualError(t, err, "invalid pull request branch: --upload-pack=touch /tmp/pwned")
+
+		signedBuild.webhookEvent.PRSourceBranch = "feature:refs/heads/master"
+		err = NewTriggerRunner("bitrise", workDir, "", true)(signedBuild, &log)
+		require.EqualError(t, err, "invalid pull request branch: feature:refs/heads/master")
+
+		signedBuild.webhookEvent.PRSourceBranch = "feature"
+		signedBuild.webhookEvent.PullRequestRepositoryURL = "--upload-pack=touch /tmp/pwned"
+		err = NewTriggerRunner("bitrise", workDir, "", true)(signedBuild, &log)
+		require.EqualError(t, err, "invalid pull request repository URL: --upload-pack=touch /tmp/pwned")
+		require.Equal(t, "", log.String())
+	}
+
+	t.Log("invalid commit hash")
+	{
+		invalidBuild := build
+		invalidBuild.CommitHash = "--orphan=evil"
+		var log strings.Builder
+		err := NewTriggerRunner("bitrise", workDir, "", true)(invalidBuild, &log)
+		require.EqualError(t, err, "invalid commit hash: --orphan=evil")
+		require.Equal(t, "", log.String())
+	}
+}
@@ -0,0 +1,60 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/tothszabi/bitrise-test/webhook"
+)
+
+const (
+	gitHubSignatureHeader    = "X-Hub-Signature-256"
+	bitbucketSignatureHeader = "X-Hub-Signature"
+	gitLabTokenHeader        = "X-Gitlab-Token"
+	signaturePrefix          = "sha256="
+)
+
+// verifySignature verifies the webhook request with the shared secret:
+// GitHub and Bitbucket sign the payload with HMAC SHA256, while GitLab sends the secret token itself.
+func verifySignature(provider webhook.Provider, secret string, header http.Header, payload []byte) error {
+	if provider == webhook.ProviderGitLab {
+		token := header.Get(gitLabTokenHeader)
+		if token == "" {
+			return errors.New("missing " + gitLabTokenHeader + " header")
+		}
+		if subtle.ConstantTimeCompare([]byte(token), []byte(secret)) != 1 {
+			return errors.New("invalid " + gitLabTokenHeader + " header")
+		}
+		return nil
+	}
+
+	signatureHeader := gitHubSignatureHeader
+	if provider == webhook.ProviderBitbucket {
+		signatureHeader = bitbucketSignatureHeader
+	}
+
+	signature := header.Get(signatureHeader)
+	if !strings.HasPrefix(signature, signaturePrefix) {
+		return errors.New("missing or invalid " + signatureHeader + " header")
+	}
+	signatureBytes, err := hex.DecodeString(strings.TrimPrefix(signature, signaturePrefix))
+	if err != nil {
+		return errors.New("invalid " + signatureHeader + " header")
+	}
+
+	if !hmac.Equal(signatureBytes, payloadSignature(secret, payload)) {
+		return errors.New("signature mismatch")
+	}
+	return nil
+}
+
+func payloadSignature(secret string, payload []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
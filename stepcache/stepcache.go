@@ -0,0 +1,568 @@
+// Package stepcache packs the cached step sources, step.yml files and step binaries into a single archive,
+// which can be imported on an other machine, to prefill its step caches.
+package stepcache
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tothszabi/bitrise-test/log"
+)
+
+const (
+	manifestName  = "manifest.json"
+	sourceDirName = "source"
+	stepYMLName   = "step.yml"
+	binaryName    = "binary"
+)
+
+// Entry is a step's cached files on the local machine.
+type Entry struct {
+	Source  string
+	ID      string
+	Version string
+	// SourceDir is the step's source code directory, required.
+	SourceDir string
+	// StepYMLPath is the step's step.yml, optional.
+	StepYMLPath string
+	// BinaryPath is the step's compiled binary (like a Go toolkit step's binary), optional.
+	BinaryPath string
+}
+
+// ManifestItem is a step of the archive.
+type ManifestItem struct {
+	Source      string `json:"source"`
+	ID          string `json:"id"`
+	Version     string `json:"version"`
+	SourceHash  string `json:"source_hash"`
+	StepYMLHash string `json:"step_yml_hash,omitempty"`
+	BinaryHash  string `json:"binary_hash,omitempty"`
+	// BinaryName is the binary's file name in the exporting machine's toolkit cache, informational only:
+	// the importer derives the binary's location from the step's source, ID and version.
+	BinaryName string `json:"binary_name,omitempty"`
+}
+
+// Manifest lists the steps of the archive, with the SHA-256 hashes of their files.
+type Manifest struct {
+	CreatedAt time.Time      `json:"created_at"`
+	Steps     []ManifestItem `json:"steps"`
+}
+
+// ImportResult ...
+type ImportResult struct {
+	Imported []ManifestItem
+	// Skipped are the steps, which are already cached on the machine.
+	Skipped []ManifestItem
+}
+
+// Export writes the entries into a gzipped tar archive.
+// The archive contains the manifest and a steps/<index> directory for every entry,
+// with the step's source directory, step.yml and binary.
+func Export(archivePath string, entries []Entry) (Manifest, error) {
+	manifest := Manifest{CreatedAt: time.Now().UTC(), Steps: []ManifestItem{}}
+	for _, entry := range entries {
+		item := ManifestItem{Source: entry.Source, ID: entry.ID, Version: entry.Version}
+
+		hash, err := DirHash(entry.SourceDir)
+		if err != nil {
+			return Manifest{}, fmt.Errorf("failed to hash %s: %s", entry.SourceDir, err)
+		}
+		item.SourceHash = hash
+
+		if entry.StepYMLPath != "" {
+			if item.StepYMLHash, err = fileHash(entry.StepYMLPath); err != nil {
+				return Manifest{}, err
+			}
+		}
+		if entry.BinaryPath != "" {
+			if item.BinaryHash, err = fileHash(entry.BinaryPath); err != nil {
+				return Manifest{}, err
+			}
+			item.BinaryName = filepath.Base(entry.BinaryPath)
+		}
+
+		manifest.Steps = append(manifest.Steps, item)
+	}
+
+	file, err := os.Create(archivePath)
+	if err != nil {
+		return Manifest{}, err
+	}
+	gzipWriter := gzip.NewWriter(file)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	writeErr := func() error {
+		manifestBytes, err := json.MarshalIndent(manifest, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := tarWriter.WriteHeader(&tar.Header{Name: manifestName, Mode: 0644, Size: int64(len(manifestBytes)), ModTime: manifest.CreatedAt}); err != nil {
+			return err
+		}
+		if _, err := tarWriter.Write(manifestBytes); err != nil {
+			return err
+		}
+
+		for idx, entry := range entries {
+			prefix := path.Join("steps", strconv.Itoa(idx))
+			if err := addDir(tarWriter, entry.SourceDir, path.Join(prefix, sourceDirName)); err != nil {
+				return err
+			}
+			if entry.StepYMLPath != "" {
+				if err := addFile(tarWriter, entry.StepYMLPath, path.Join(prefix, stepYMLName)); err != nil {
+					return err
+				}
+			}
+			if entry.BinaryPath != "" {
+				if err := addFile(tarWriter, entry.BinaryPath, path.Join(prefix, binaryName)); err != nil {
+					return err
+				}
+			}
+		}
+
+		if err := tarWriter.Close(); err != nil {
+			return err
+		}
+		return gzipWriter.Close()
+	}()
+	if closeErr := file.Close(); writeErr == nil {
+		writeErr = closeErr
+	}
+	if writeErr != nil {
+		return Manifest{}, fmt.Errorf("failed to write %s: %s", archivePath, writeErr)
+	}
+
+	return manifest, nil
+}
+
+// ReadManifest reads the manifest of the archive.
+func ReadManifest(archivePath string) (Manifest, error) {
+	var manifest Manifest
+	found := false
+	err := walkArchive(archivePath, func(header *tar.Header, reader io.Reader) error {
+		if header.Name != manifestName {
+			return nil
+		}
+		found = true
+		return json.NewDecoder(reader).Decode(&manifest)
+	})
+	if err != nil {
+		return Manifest{}, err
+	}
+	if !found {
+		return Manifest{}, fmt.Errorf("no %s found in %s", manifestName, archivePath)
+	}
+	return manifest, nil
+}
+
+// Import verifies the archive's content against its manifest, and extracts the steps to the locations returned by locate.
+// The steps, which source directory already exists, are skipped, unless overwrite is set.
+func Import(archivePath string, locate func(item ManifestItem) (Entry, error), overwrite bool) (ImportResult, error) {
+	manifest, err := ReadManifest(archivePath)
+	if err != nil {
+		return ImportResult{}, err
+	}
+
+	for _, item := range manifest.Steps {
+		if err := validateManifestItem(item); err != nil {
+			return ImportResult{}, err
+		}
+	}
+
+	if err := verifyArchive(archivePath, manifest); err != nil {
+		return ImportResult{}, err
+	}
+
+	var result ImportResult
+	targets := map[string]Entry{}
+	for idx, item := range manifest.Steps {
+		entry, err := locate(item)
+		if err != nil {
+			return ImportResult{}, fmt.Errorf("step (%s@%s): %s", item.ID, item.Version, err)
+		}
+
+		if !overwrite {
+			if _, err := os.Stat(entry.SourceDir); err == nil {
+				result.Skipped = append(result.Skipped, item)
+				continue
+			}
+		}
+		if err := os.RemoveAll(entry.SourceDir); err != nil {
+			return ImportResult{}, err
+		}
+		if err := os.MkdirAll(entry.SourceDir, 0755); err != nil {
+			return ImportResult{}, err
+		}
+
+		targets[strconv.Itoa(idx)] = entry
+		result.Imported = append(result.Imported, item)
+	}
+
+	err = walkArchive(archivePath, func(header *tar.Header, reader io.Reader) error {
+		idx, kind, rel, ok := splitStepPath(header.Name)
+		if !ok {
+			return nil
+		}
+		entry, ok := targets[idx]
+		if !ok {
+			return nil
+		}
+
+		switch kind {
+		case sourceDirName:
+			destination := filepath.Join(entry.SourceDir, filepath.FromSlash(rel))
+			if err := ensureInsideDir(entry.SourceDir, filepath.Dir(destination)); err != nil {
+				return err
+			}
+			return extractEntry(header, reader, destination)
+		case stepYMLName:
+			if entry.StepYMLPath == "" {
+				return nil
+			}
+			return extractEntry(header, reader, entry.StepYMLPath)
+		case binaryName:
+			if entry.BinaryPath == "" {
+				return nil
+			}
+			return extractEntry(header, reader, entry.BinaryPath)
+		}
+		return nil
+	})
+	if err != nil {
+		return ImportResult{}, err
+	}
+
+	return result, nil
+}
+
+// DirHash returns the SHA-256 hash of the directory's files (their relative paths, executable bits and contents)
+// and symlinks (their relative paths and targets). The .git directory is ignored.
+func DirHash(dir string) (string, error) {
+	var lines []string
+	err := filepath.Walk(dir, func(pth string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, pth)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+
+		if info.IsDir() {
+			if info.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			target, err := os.Readlink(pth)
+			if err != nil {
+				return err
+			}
+			lines = append(lines, hashLine(rel, "l", target))
+			return nil
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		hash, err := fileHash(pth)
+		if err != nil {
+			return err
+		}
+		lines = append(lines, hashLine(rel, fileKind(int64(info.Mode())), hash))
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return linesHash(lines), nil
+}
+
+func verifyArchive(archivePath string, manifest Manifest) error {
+	sourceLines := map[string][]string{}
+	fileHashes := map[string]string{}
+
+	err := walkArchive(archivePath, func(header *tar.Header, reader io.Reader) error {
+		idx, kind, rel, ok := splitStepPath(header.Name)
+		if !ok {
+			return nil
+		}
+
+		switch header.Typeflag {
+		case tar.TypeSymlink:
+			if kind == sourceDirName {
+				sourceLines[idx] = append(sourceLines[idx], hashLine(rel, "l", header.Linkname))
+			}
+		case tar.TypeReg:
+			hash := sha256.New()
+			if _, err := io.Copy(hash, reader); err != nil {
+				return err
+			}
+			sum := hex.EncodeToString(hash.Sum(nil))
+			if kind == sourceDirName {
+				sourceLines[idx] = append(sourceLines[idx], hashLine(rel, fileKind(header.Mode), sum))
+			} else {
+				fileHashes[idx+"/"+kind] = sum
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for idx, item := range manifest.Steps {
+		key := strconv.Itoa(idx)
+		if hash := linesHash(sourceLines[key]); hash != item.SourceHash {
+			return fmt.Errorf("step (%s@%s) source hash mismatch: expected %s, got %s", item.ID, item.Version, item.SourceHash, hash)
+		}
+		if hash := fileHashes[key+"/"+stepYMLName]; hash != item.StepYMLHash {
+			return fmt.Errorf("step (%s@%s) step.yml hash mismatch: expected %s, got %s", item.ID, item.Version, item.StepYMLHash, hash)
+		}
+		if hash := fileHashes[key+"/"+binaryName]; hash != item.BinaryHash {
+			return fmt.Errorf("step (%s@%s) binary hash mismatch: expected %s, got %s", item.ID, item.Version, item.BinaryHash, hash)
+		}
+	}
+	return nil
+}
+
+// splitStepPath splits a steps/<index>/<kind>[/<relative path>] archive path.
+func splitStepPath(name string) (string, string, string, bool) {
+	split := strings.SplitN(name, "/", 4)
+	if len(split) < 3 || split[0] != "steps" {
+		return "", "", "", false
+	}
+	rel := ""
+	if len(split) == 4 {
+		rel = split[3]
+	}
+	return split[1], split[2], rel, true
+}
+
+func walkArchive(archivePath string, fn func(header *tar.Header, reader io.Reader) error) error {
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			log.Warnf("Failed to close %s: %s", archivePath, err)
+		}
+	}()
+
+	gzipReader, err := gzip.NewReader(file)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %s", archivePath, err)
+	}
+	tarReader := tar.NewReader(gzipReader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %s", archivePath, err)
+		}
+		cleaned := path.Clean(header.Name)
+		if path.IsAbs(cleaned) || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+			return fmt.Errorf("invalid path in archive: %s", header.Name)
+		}
+		header.Name = cleaned
+		if err := fn(header, tarReader); err != nil {
+			return err
+		}
+	}
+}
+
+func addDir(tarWriter *tar.Writer, dir, prefix string) error {
+	return filepath.Walk(dir, func(pth string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, pth)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+		name := path.Join(prefix, filepath.ToSlash(rel))
+
+		switch {
+		case info.IsDir():
+			if info.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return tarWriter.WriteHeader(&tar.Header{Name: name + "/", Typeflag: tar.TypeDir, Mode: 0755, ModTime: info.ModTime()})
+		case info.Mode()&os.ModeSymlink != 0:
+			target, err := os.Readlink(pth)
+			if err != nil {
+				return err
+			}
+			return tarWriter.WriteHeader(&tar.Header{Name: name, Typeflag: tar.TypeSymlink, Linkname: target, Mode: 0777, ModTime: info.ModTime()})
+		case info.Mode().IsRegular():
+			return addFile(tarWriter, pth, name)
+		}
+		return nil
+	})
+}
+
+func addFile(tarWriter *tar.Writer, pth, name string) error {
+	file, err := os.Open(pth)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			log.Warnf("Failed to close %s: %s", pth, err)
+		}
+	}()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	if err := tarWriter.WriteHeader(&tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: int64(info.Mode().Perm()), Size: info.Size(), ModTime: info.ModTime()}); err != nil {
+		return err
+	}
+	_, err = io.Copy(tarWriter, file)
+	return err
+}
+
+func extractEntry(header *tar.Header, reader io.Reader, destination string) error {
+	switch header.Typeflag {
+	case tar.TypeDir:
+		return os.MkdirAll(destination, 0755)
+	case tar.TypeSymlink:
+		if err := os.MkdirAll(filepath.Dir(destination), 0755); err != nil {
+			return err
+		}
+		return os.Symlink(header.Linkname, destination)
+	case tar.TypeReg:
+		if err := os.MkdirAll(filepath.Dir(destination), 0755); err != nil {
+			return err
+		}
+		tmpFile, err := ioutil.TempFile(filepath.Dir(destination), "."+filepath.Base(destination)+"-")
+		if err != nil {
+			return err
+		}
+		_, copyErr := io.Copy(tmpFile, reader)
+		if err := tmpFile.Close(); copyErr == nil {
+			copyErr = err
+		}
+		if copyErr == nil {
+			copyErr = os.Chmod(tmpFile.Name(), os.FileMode(header.Mode).Perm())
+		}
+		if copyErr == nil {
+			copyErr = os.Rename(tmpFile.Name(), destination)
+		}
+		if copyErr != nil {
+			if err := os.Remove(tmpFile.Name()); err != nil && !os.IsNotExist(err) {
+				log.Warnf("Failed to remove %s: %s", tmpFile.Name(), err)
+			}
+			return copyErr
+		}
+		return nil
+	}
+	return nil
+}
+
+// validateManifestItem checks that the step's ID and version can be used as a path component,
+// as the import locations are built from them.
+func validateManifestItem(item ManifestItem) error {
+	for _, value := range []string{item.ID, item.Version} {
+		if value == "" || value == "." || strings.Contains(value, "..") || strings.ContainsAny(value, `/\`) {
+			return fmt.Errorf("invalid step (%s@%s) in manifest: id and version can not be empty or contain path separators or '..'", item.ID, item.Version)
+		}
+	}
+	return nil
+}
+
+// ensureInsideDir checks that the already extracted symlinks don't redirect the dir outside of the root dir.
+func ensureInsideDir(root, dir string) error {
+	resolvedRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return err
+	}
+
+	// the dir might not exist yet: resolve its longest existing parent
+	existing := dir
+	for {
+		if _, err := os.Lstat(existing); err == nil {
+			break
+		}
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			break
+		}
+		existing = parent
+	}
+	resolved, err := filepath.EvalSymlinks(existing)
+	if err != nil {
+		return err
+	}
+
+	if resolved != resolvedRoot && !strings.HasPrefix(resolved, resolvedRoot+string(filepath.Separator)) {
+		return fmt.Errorf("invalid path in archive: %s points outside of %s", dir, root)
+	}
+	return nil
+}
+
+func fileHash(pth string) (string, error) {
+	file, err := os.Open(pth)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			log.Warnf("Failed to close %s: %s", pth, err)
+		}
+	}()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// fileKind marks the executable files, so that losing the executable bit changes the hash.
+func fileKind(mode int64) string {
+	if mode&0111 != 0 {
+		return "x"
+	}
+	return "f"
+}
+
+func hashLine(rel, kind, value string) string {
+	return rel + "\x00" + kind + "\x00" + value
+}
+
+func linesHash(lines []string) string {
+	sorted := append([]string{}, lines...)
+	sort.Strings(sorted)
+
+	hash := sha256.New()
+	for _, line := range sorted {
+		_, _ = hash.Write([]byte(line + "\n"))
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
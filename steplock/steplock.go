@@ -0,0 +1,110 @@
+// Package steplock reads and writes the step lock file (bitrise.lock),
+// which records the exact versions the StepLib steps of a config are resolved to.
+package steplock
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/tothszabi/bitrise-test/models"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// FileName is the lock file's name, the lock file is stored next to the config.
+	FileName = "bitrise.lock"
+
+	formatVersion = "1"
+)
+
+// Step is the resolved version of a StepLib step reference.
+type Step struct {
+	Source  string `yaml:"source"`
+	ID      string `yaml:"id"`
+	Version string `yaml:"version"`
+	// Commit is the step's source commit hash of the version, as defined by the StepLib.
+	Commit string `yaml:"commit,omitempty"`
+}
+
+// Model is the content of the lock file.
+type Model struct {
+	FormatVersion string `yaml:"format_version"`
+	// Steps are keyed by the step references of the config, see Key.
+	Steps map[string]Step `yaml:"steps"`
+}
+
+// New returns an empty lock.
+func New() Model {
+	return Model{FormatVersion: formatVersion, Steps: map[string]Step{}}
+}
+
+// Key returns the lock key of a step reference: the StepLib source, the step ID and the version (constraint)
+// as they are referenced in the config, like https://github.com/bitrise-io/bitrise-steplib.git::script@1.
+func Key(stepIDData models.StepIDData) string {
+	return stepIDData.SteplibSource + "::" + stepIDData.IDorURI + "@" + stepIDData.Version
+}
+
+// PathForConfig returns the path of the config's lock file.
+func PathForConfig(configPth string) string {
+	return filepath.Join(filepath.Dir(configPth), FileName)
+}
+
+// Lookup returns the locked step of the step reference, a nil lock has no locked steps.
+func (m *Model) Lookup(stepIDData models.StepIDData) (Step, bool) {
+	if m == nil {
+		return Step{}, false
+	}
+	step, found := m.Steps[Key(stepIDData)]
+	return step, found
+}
+
+// Keys returns the lock keys in sorted order.
+func (m Model) Keys() []string {
+	var keys []string
+	for key := range m.Steps {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Read reads the lock file, returns false if it does not exist.
+func Read(pth string) (Model, bool, error) {
+	content, err := ioutil.ReadFile(pth)
+	if os.IsNotExist(err) {
+		return Model{}, false, nil
+	} else if err != nil {
+		return Model{}, false, err
+	}
+
+	var lock Model
+	if err := yaml.Unmarshal(content, &lock); err != nil {
+		return Model{}, false, fmt.Errorf("failed to parse lock file (%s): %s", pth, err)
+	}
+	if lock.FormatVersion != formatVersion {
+		return Model{}, false, fmt.Errorf("unsupported lock file (%s) format version: %s", pth, lock.FormatVersion)
+	}
+	if lock.Steps == nil {
+		lock.Steps = map[string]Step{}
+	}
+	for key, step := range lock.Steps {
+		if step.Source == "" || step.ID == "" || step.Version == "" {
+			return Model{}, false, fmt.Errorf("invalid lock file (%s): source, id and version are required (%s)", pth, key)
+		}
+	}
+	return lock, true, nil
+}
+
+// Write writes the lock file.
+func Write(pth string, lock Model) error {
+	content, err := yaml.Marshal(lock)
+	if err != nil {
+		return err
+	}
+
+	header := "# This file is generated by 'bitrise lock', do not edit it manually.\n"
+	return ioutil.WriteFile(pth, append([]byte(header), content...), 0644)
+}
@@ -0,0 +1,71 @@
+package steplock
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tothszabi/bitrise-test/models"
+)
+
+func TestWriteAndRead(t *testing.T) {
+	pth := filepath.Join(t.TempDir(), FileName)
+
+	t.Log("missing lock file")
+	{
+		_, found, err := Read(pth)
+		require.NoError(t, err)
+		require.False(t, found)
+	}
+
+	t.Log("written lock is read back")
+	{
+		lock := New()
+		lock.Steps["https://github.com/bitrise-io/bitrise-steplib.git::script@1"] = Step{
+			Source:  "https://github.com/bitrise-io/bitrise-steplib.git",
+			ID:      "script",
+			Version: "1.2.0",
+			Commit:  "6d2ff1f7a7aa2d8b7a3b4a2f0c1d1f07fd3e5a36",
+		}
+		require.NoError(t, Write(pth, lock))
+
+		read, found, err := Read(pth)
+		require.NoError(t, err)
+		require.True(t, found)
+		require.Equal(t, lock, read)
+	}
+
+	t.Log("invalid locked step")
+	{
+		require.NoError(t, ioutil.WriteFile(pth, []byte("format_version: \"1\"\nsteps:\n  https://github.com/bitrise-io/bitrise-steplib.git::script@1:\n    id: script\n"), 0644))
+		_, _, err := Read(pth)
+		require.Error(t, err)
+	}
+
+	t.Log("unsupported format version")
+	{
+		require.NoError(t, ioutil.WriteFile(pth, []byte("format_version: \"2\"\n"), 0644))
+		_, _, err := Read(pth)
+		require.Error(t, err)
+	}
+}
+
+func TestLookup(t *testing.T) {
+	stepIDData := models.StepIDData{SteplibSource: "https://github.com/bitrise-io/bitrise-steplib.git", IDorURI: "script", Version: "1"}
+	require.Equal(t, "https://github.com/bitrise-io/bitrise-steplib.git::script@1", Key(stepIDData))
+
+	var nilLock *Model
+	_, found := nilLock.Lookup(stepIDData)
+	require.False(t, found)
+
+	lock := New()
+	lock.Steps[Key(stepIDData)] = Step{Source: stepIDData.SteplibSource, ID: "script", Version: "1.2.0"}
+	step, found := lock.Lookup(stepIDData)
+	require.True(t, found)
+	require.Equal(t, "1.2.0", step.Version)
+
+	stepIDData.Version = "2"
+	_, found = lock.Lookup(stepIDData)
+	require.False(t, found)
+}
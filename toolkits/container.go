@@ -0,0 +1,362 @@
+package toolkits
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/command"
+	"github.com/bitrise-io/go-utils/stringutil"
+	stepmanModels "github.com/bitrise-io/stepman/models"
+	"github.com/tothszabi/bitrise-test/configs"
+	"github.com/tothszabi/bitrise-test/log"
+	"github.com/tothszabi/bitrise-test/models"
+	"github.com/tothszabi/bitrise-test/tools"
+)
+
+// containerEnvmanPath is the path of the envman shim in the container, the steps export their outputs with it (envman add).
+// The host's envman binary can not run in the container (it might be built for another OS or libc),
+// so the shim only records the added envs, and CollectStepOutputs adds them to the host's envstore after the step run.
+const containerEnvmanPath = "/usr/local/bin/envman"
+
+// containerEnvmanShim is a POSIX shell implementation of envman add, it records the envs in the outputs dir:
+// the value of the nth env in n.value, its key and flags (one per line) in n.env.
+const containerEnvmanShim = `#!/bin/sh
+set -e
+outputs_dir=%s
+
+if [ "$1" != "add" ]; then
+  echo "envman $1: only envman add is supported in containers" >&2
+  exit 1
+fi
+shift
+
+idx=$(( $(ls "$outputs_dir" | grep -c '\.env$' || true) + 1 ))
+out="$outputs_dir/$idx"
+key=""
+flags=""
+has_value=""
+while [ $# -gt 0 ]; do
+  case "$1" in
+    --key|-k) key="$2"; shift 2 ;;
+    --key=*) key="${1#--key=}"; shift ;;
+    --value|-v) printf '%%s' "$2" > "$out.value"; has_value=1; shift 2 ;;
+    --value=*) printf '%%s' "${1#--value=}" > "$out.value"; has_value=1; shift ;;
+    --valuefile|-f) cat "$2" > "$out.value"; has_value=1; shift 2 ;;
+    --valuefile=*) cat "${1#--valuefile=}" > "$out.value"; has_value=1; shift ;;
+    --no-expand|-n) flags="$flags no-expand"; shift ;;
+    --append|-a) flags="$flags append"; shift ;;
+    --skip-if-empty) flags="$flags skip-if-empty"; shift ;;
+    --sensitive) flags="$flags sensitive"; shift ;;
+    *) shift ;;
+  esac
+done
+
+if [ -z "$key" ]; then
+  echo "envman add: --key is required" >&2
+  exit 1
+fi
+if [ -z "$has_value" ]; then
+  if [ -p /dev/stdin ]; then cat > "$out.value"; else : > "$out.value"; fi
+fi
+printf '%%s\n' "$key" $flags > "$out.env"
+`
+
+// StepOutputCollector is implemented by the toolkits, which collect the step outputs themselves after the step run.
+type StepOutputCollector interface {
+	CollectStepOutputs(envstorePth string) error
+}
+
+// ContainerToolkit runs the steps of the wrapped toolkit inside a Docker or Podman container.
+// The source dir, the step dir and the bitrise work dirs are mounted to the same paths in the container,
+// so the paths of the envs remain valid.
+type ContainerToolkit struct {
+	Container models.ContainerModel
+	Toolkit   Toolkit
+	SourceDir string
+}
+
+// NewContainerToolkit ...
+func NewContainerToolkit(container models.ContainerModel, toolkit Toolkit, sourceDir string) ContainerToolkit {
+	if container.Engine == "" {
+		container.Engine = models.ContainerEngineDocker
+	}
+	return ContainerToolkit{Container: container, Toolkit: toolkit, SourceDir: sourceDir}
+}
+
+// ToolkitName ...
+func (toolkit ContainerToolkit) ToolkitName() string {
+	return fmt.Sprintf("%s (%s: %s)", toolkit.Toolkit.ToolkitName(), toolkit.Container.Engine, toolkit.Container.Image)
+}
+
+// Check ...
+func (toolkit ContainerToolkit) Check() (bool, ToolkitCheckResult, error) {
+	binPath, err := exec.LookPath(toolkit.Container.Engine)
+	if err != nil {
+		return true, ToolkitCheckResult{}, nil
+	}
+
+	verOut, err := command.RunCommandAndReturnStdout(toolkit.Container.Engine, "--version")
+	if err != nil {
+		return false, ToolkitCheckResult{}, fmt.Errorf("Failed to check %s version, error: %s", toolkit.Container.Engine, err)
+	}
+
+	return false, ToolkitCheckResult{
+		Path:    binPath,
+		Version: stringutil.ReadFirstLine(verOut, true),
+	}, nil
+}
+
+// IsToolAvailableInPATH ...
+func (toolkit ContainerToolkit) IsToolAvailableInPATH() bool {
+	_, err := exec.LookPath(toolkit.Container.Engine)
+	return err == nil
+}
+
+// Bootstrap ...
+func (toolkit ContainerToolkit) Bootstrap() error {
+	return nil
+}
+
+// Install ...
+func (toolkit ContainerToolkit) Install() error {
+	return fmt.Errorf("container engine (%s) not found, please install it", toolkit.Container.Engine)
+}
+
+// PrepareForStepRun ...
+func (toolkit ContainerToolkit) PrepareForStepRun(step stepmanModels.StepModel, sIDData models.StepIDData, stepAbsDirPath string) error {
+	if err := toolkit.checkWrappedToolkit(); err != nil {
+		return err
+	}
+	if !toolkit.IsToolAvailableInPATH() {
+		return toolkit.Install()
+	}
+	return toolkit.Toolkit.PrepareForStepRun(step, sIDData, stepAbsDirPath)
+}
+
+// checkWrappedToolkit rejects the toolkits, which prepare the step's environment on the host:
+// the Python venv links the host's interpreter and the Node modules are linked from the host's cache,
+// these are not available in the container.
+func (toolkit ContainerToolkit) checkWrappedToolkit() error {
+	switch toolkit.Toolkit.(type) {
+	case PythonToolkit, NodeToolkit:
+		return fmt.Errorf("%s steps can not run in a container, as their environment is prepared on the host", toolkit.Toolkit.ToolkitName())
+	}
+	return nil
+}
+
+// StepRunCommandArguments wraps the wrapped toolkit's command into a container run command.
+func (toolkit ContainerToolkit) StepRunCommandArguments(step stepmanModels.StepModel, sIDData models.StepIDData, stepAbsDirPath string) ([]string, error) {
+	if err := toolkit.checkWrappedToolkit(); err != nil {
+		return nil, err
+	}
+
+	cmdArgs, err := toolkit.Toolkit.StepRunCommandArguments(step, sIDData, stepAbsDirPath)
+	if err != nil {
+		return nil, err
+	}
+
+	envs, err := tools.EnvmanReadEnvList(configs.InputEnvstorePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read envstore: %s", err)
+	}
+	envKeys := []string{}
+	for key := range envs {
+		envKeys = append(envKeys, key)
+	}
+	for _, env := range os.Environ() {
+		key := strings.SplitN(env, "=", 2)[0]
+		if isContainerPassedHostEnv(key) {
+			envKeys = append(envKeys, key)
+		}
+	}
+
+	mounts := []string{toolkit.SourceDir, stepAbsDirPath, configs.BitriseWorkDirPath}
+	for _, key := range []string{configs.BitriseDeployDirEnvKey, configs.BitriseTestDeployDirEnvKey, configs.BitriseTmpDirEnvKey} {
+		if dir := os.Getenv(key); dir != "" {
+			mounts = append(mounts, dir)
+		}
+	}
+	if filepath.IsAbs(cmdArgs[0]) {
+		// compiled step binaries (go toolkit) are stored outside of the step dir
+		mounts = append(mounts, filepath.Dir(cmdArgs[0]))
+	}
+
+	envmanShimPath, err := writeContainerEnvmanShim(containerOutputsDirPath())
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare envman for the container: %s", err)
+	}
+
+	return containerRunCommandArguments(toolkit.Container, toolkit.SourceDir, mounts, envKeys, envmanShimPath, containerUser(toolkit.Container.Options), cmdArgs), nil
+}
+
+// CollectStepOutputs adds the envs, recorded by the container's envman shim during the step run, to the envstore.
+func (toolkit ContainerToolkit) CollectStepOutputs(envstorePth string) error {
+	outputsDir := containerOutputsDirPath()
+	defer func() {
+		if err := os.RemoveAll(outputsDir); err != nil {
+			log.Warnf("Failed to remove the container outputs dir: %s", err)
+		}
+	}()
+	return addContainerOutputs(outputsDir, envstorePth)
+}
+
+// containerOutputsDirPath returns the dir of the envs recorded by the envman shim, inside the mounted bitrise work dir.
+func containerOutputsDirPath() string {
+	return filepath.Join(configs.BitriseWorkDirPath, "container_outputs")
+}
+
+// writeContainerEnvmanShim recreates the outputs dir, and writes the envman shim next to it.
+func writeContainerEnvmanShim(outputsDir string) (string, error) {
+	if err := os.RemoveAll(outputsDir); err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(outputsDir, 0755); err != nil {
+		return "", err
+	}
+
+	shimPath := outputsDir + "_envman"
+	content := fmt.Sprintf(containerEnvmanShim, shellQuote(outputsDir))
+	if err := ioutil.WriteFile(shimPath, []byte(content), 0755); err != nil {
+		return "", err
+	}
+	return shimPath, os.Chmod(shimPath, 0755)
+}
+
+// addContainerOutputs adds the envs of the outputs dir to the envstore, in the order of their envman add calls.
+func addContainerOutputs(outputsDir, envstorePth string) error {
+	entries, err := ioutil.ReadDir(outputsDir)
+	if err != nil {
+		return err
+	}
+	var indexes []int
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Name(), ".env") {
+			continue
+		}
+		idx, err := strconv.Atoi(strings.TrimSuffix(entry.Name(), ".env"))
+		if err != nil {
+			continue
+		}
+		indexes = append(indexes, idx)
+	}
+	sort.Ints(indexes)
+
+	for _, idx := range indexes {
+		envContent, err := ioutil.ReadFile(filepath.Join(outputsDir, fmt.Sprintf("%d.env", idx)))
+		if err != nil {
+			return err
+		}
+		lines := strings.Split(strings.TrimSuffix(string(envContent), "\n"), "\n")
+		key := lines[0]
+		flags := map[string]bool{}
+		for _, flag := range lines[1:] {
+			flags[flag] = true
+		}
+
+		value, err := ioutil.ReadFile(filepath.Join(outputsDir, fmt.Sprintf("%d.value", idx)))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+
+		if err := tools.EnvmanAddEnv(envstorePth, key, string(value), !flags["no-expand"], !flags["append"], flags["skip-if-empty"], flags["sensitive"]); err != nil {
+			return fmt.Errorf("failed to add the step output (%s): %s", key, err)
+		}
+	}
+	return nil
+}
+
+// containerUser returns the host's user and group ID (uid:gid), so the files written into the mounted dirs are owned by the host's user.
+// Returns an empty string if the container options already define the user, or if the host has no user IDs (Windows).
+func containerUser(options []string) string {
+	for _, option := range options {
+		if option == "--user" || option == "-u" || strings.HasPrefix(option, "--user=") || (strings.HasPrefix(option, "-u") && !strings.HasPrefix(option, "--")) {
+			return ""
+		}
+	}
+	uid, gid := os.Getuid(), os.Getgid()
+	if uid < 0 || gid < 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d:%d", uid, gid)
+}
+
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// isContainerPassedHostEnv returns true for the envs set by the CLI in its own process (like BITRISE_DEPLOY_DIR and CI),
+// the rest of the host envs (like PATH and HOME) would break the container's environment.
+func isContainerPassedHostEnv(key string) bool {
+	switch key {
+	case configs.CIModeEnvKey, configs.PRModeEnvKey, configs.PullRequestIDEnvKey, configs.EnvstorePathEnvKey:
+		return true
+	}
+	return strings.HasPrefix(key, "BITRISE")
+}
+
+// containerRunCommandArguments returns the container run command, which runs cmdArgs in the container.
+// The envs are passed by key, the container engine reads their values from its own environment (prepared by envman).
+func containerRunCommandArguments(container models.ContainerModel, workDir string, mounts, envKeys []string, envmanPath, user string, cmdArgs []string) []string {
+	args := []string{container.Engine, "run", "--rm", "-i", "-w", workDir}
+	if configs.IsOfflineMode {
+		args = append(args, "--pull=never")
+	}
+	if user != "" {
+		args = append(args, "--user", user)
+	}
+
+	for _, mount := range uniqueMounts(mounts) {
+		args = append(args, "-v", mount+":"+mount)
+	}
+	if envmanPath != "" {
+		args = append(args, "-v", envmanPath+":"+containerEnvmanPath+":ro")
+	}
+
+	sort.Strings(envKeys)
+	for idx, key := range envKeys {
+		if idx > 0 && envKeys[idx-1] == key {
+			continue
+		}
+		args = append(args, "-e", key)
+	}
+
+	args = append(args, container.Options...)
+	args = append(args, container.Image)
+	return append(args, cmdArgs...)
+}
+
+// uniqueMounts returns the sorted, absolute mount paths, without the paths nested into an other mount path.
+func uniqueMounts(mounts []string) []string {
+	var cleaned []string
+	for _, mount := range mounts {
+		if mount == "" {
+			continue
+		}
+		if absMount, err := filepath.Abs(mount); err == nil {
+			mount = absMount
+		}
+		cleaned = append(cleaned, filepath.Clean(mount))
+	}
+	sort.Strings(cleaned)
+
+	var unique []string
+	for _, mount := range cleaned {
+		nested := false
+		for _, parent := range unique {
+			if mount == parent || strings.HasPrefix(mount, parent+string(filepath.Separator)) {
+				nested = true
+				break
+			}
+		}
+		if !nested {
+			unique = append(unique, mount)
+		}
+	}
+	return unique
+}
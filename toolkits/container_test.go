@@ -0,0 +1,148 @@
+package toolkits
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	envmanModels "github.com/bitrise-io/envman/models"
+	stepmanModels "github.com/bitrise-io/stepman/models"
+	"github.com/stretchr/testify/require"
+	"github.com/tothszabi/bitrise-test/configs"
+	"github.com/tothszabi/bitrise-test/models"
+	"github.com/tothszabi/bitrise-test/tools"
+	"gopkg.in/yaml.v2"
+)
+
+func Test_containerRunCommandArguments(t *testing.T) {
+	t.Log("docker with envman and options")
+	{
+		container := models.ContainerModel{Image: "ubuntu:22.04", Engine: "docker", Options: []string{"--network", "host"}}
+		args := containerRunCommandArguments(container, "/src", []string{"/src", "/tmp/step"}, []string{"B_KEY", "A_KEY", "B_KEY"}, "/usr/bin/envman", "501:20", []string{"bash", "/tmp/step/step.sh"})
+		require.Equal(t, []string{
+			"docker", "run", "--rm", "-i", "-w", "/src",
+			"--user", "501:20",
+			"-v", "/src:/src",
+			"-v", "/tmp/step:/tmp/step",
+			"-v", "/usr/bin/envman:/usr/local/bin/envman:ro",
+			"-e", "A_KEY",
+			"-e", "B_KEY",
+			"--network", "host",
+			"ubuntu:22.04",
+			"bash", "/tmp/step/step.sh",
+		}, args)
+	}
+
+	t.Log("podman without envman")
+	{
+		container := models.ContainerModel{Image: "alpine", Engine: "podman"}
+		args := containerRunCommandArguments(container, "/src", []string{"/src"}, nil, "", "", []string{"sh"})
+		require.Equal(t, []string{"podman", "run", "--rm", "-i", "-w", "/src", "-v", "/src:/src", "alpine", "sh"}, args)
+	}
+
+	t.Log("offline mode never pulls the image")
+	{
+		defer func() { configs.IsOfflineMode = false }()
+		configs.IsOfflineMode = true
+
+		container := models.ContainerModel{Image: "alpine", Engine: "docker"}
+		args := containerRunCommandArguments(container, "/src", nil, nil, "", "", []string{"sh"})
+		require.Equal(t, []string{"docker", "run", "--rm", "-i", "-w", "/src", "--pull=never", "alpine", "sh"}, args)
+	}
+}
+
+func Test_containerUser(t *testing.T) {
+	t.Log("host user")
+	{
+		if os.Getuid() >= 0 {
+			require.Equal(t, fmt.Sprintf("%d:%d", os.Getuid(), os.Getgid()), containerUser([]string{"--network", "host"}))
+		}
+	}
+
+	t.Log("user defined by the options")
+	{
+		for _, options := range [][]string{{"--user", "root"}, {"-u", "0"}, {"--user=root"}, {"-u0"}} {
+			require.Equal(t, "", containerUser(options))
+		}
+	}
+}
+
+func TestContainerEnvmanShim(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not found")
+	}
+
+	outputsDir := filepath.Join(t.TempDir(), "container_outputs")
+	shimPath, err := writeContainerEnvmanShim(outputsDir)
+	require.NoError(t, err)
+
+	valueFile := filepath.Join(t.TempDir(), "value.txt")
+	require.NoError(t, ioutil.WriteFile(valueFile, []byte("from\nfile"), 0644))
+
+	run := func(stdin string, args ...string) error {
+		cmd := exec.Command(shimPath, args...)
+		cmd.Stdin = strings.NewReader(stdin)
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("%s: %s", err, out)
+		}
+		return nil
+	}
+	require.NoError(t, run("", "add", "--key", "FLAG_VALUE", "--value", "100% it's $HOME"))
+	require.NoError(t, run("", "add", "--key=FILE_VALUE", "--valuefile", valueFile, "--no-expand"))
+	require.NoError(t, run("piped value", "add", "-k", "PIPED_VALUE", "--sensitive"))
+	require.NoError(t, run("", "add", "--key", "FLAG_VALUE", "--value", "replaced"))
+	require.Error(t, run("", "clear"))
+	require.Error(t, run("", "add", "--value", "no key"))
+
+	envstorePth := filepath.Join(t.TempDir(), "envstore.yml")
+	require.NoError(t, tools.EnvmanInit(envstorePth, true))
+	require.NoError(t, addContainerOutputs(outputsDir, envstorePth))
+
+	envs, err := tools.EnvmanReadEnvList(envstorePth)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"FLAG_VALUE":  "replaced",
+		"FILE_VALUE":  "from\nfile",
+		"PIPED_VALUE": "piped value",
+	}, map[string]string(envs))
+
+	content, err := ioutil.ReadFile(envstorePth)
+	require.NoError(t, err)
+	var envstore envmanModels.EnvsSerializeModel
+	require.NoError(t, yaml.Unmarshal(content, &envstore))
+	require.Equal(t, 3, len(envstore.Envs))
+	for _, env := range envstore.Envs {
+		key, _, err := env.GetKeyValuePair()
+		require.NoError(t, err)
+		options, err := env.GetOptions()
+		require.NoError(t, err)
+		require.Equal(t, key == "PIPED_VALUE", options.IsSensitive != nil && *options.IsSensitive, key)
+		require.Equal(t, key != "FILE_VALUE", options.IsExpand == nil || *options.IsExpand, key)
+	}
+}
+
+func Test_uniqueMounts(t *testing.T) {
+	require.Equal(t, []string{"/a", "/a-b", "/b"}, uniqueMounts([]string{"/b", "/a/c", "", "/a-b", "/a", "/a/", "/a/c/d"}))
+	require.Equal(t, 0, len(uniqueMounts(nil)))
+}
+
+func TestContainerToolkitWrappedToolkit(t *testing.T) {
+	container := models.ContainerModel{Image: "python:3.10"}
+
+	for _, toolkit := range []Toolkit{PythonToolkit{}, NodeToolkit{}} {
+		containerToolkit := NewContainerToolkit(container, toolkit, "/src")
+		err := containerToolkit.PrepareForStepRun(stepmanModels.StepModel{}, models.StepIDData{}, "/step")
+		require.EqualError(t, err, toolkit.ToolkitName()+" steps can not run in a container, as their environment is prepared on the host")
+
+		_, err = containerToolkit.StepRunCommandArguments(stepmanModels.StepModel{}, models.StepIDData{}, "/step")
+		require.Error(t, err)
+	}
+
+	require.NoError(t, NewContainerToolkit(container, BashToolkit{}, "/src").checkWrappedToolkit())
+	require.NoError(t, NewContainerToolkit(container, GoToolkit{}, "/src").checkWrappedToolkit())
+}
@@ -1,6 +1,9 @@
 package toolkits
 
-import "github.com/bitrise-io/go-utils/command"
+import (
+	"github.com/bitrise-io/go-utils/command"
+	"github.com/tothszabi/bitrise-test/configs"
+)
 
 type goCmdBuilder struct {
 	goConfig GoConfigurationModel
@@ -32,6 +35,10 @@ func (g goCmdBuilder) goBuildEnv(stepAbsDir string, shouldCheckGoSum bool) []str
 	if !shouldCheckGoSum {
 		envs = append(envs, "GOSUMDB=off")
 	}
+	if configs.IsOfflineMode {
+		// GOPROXY=off disables downloading modules, only the vendored and the already cached modules are used.
+		envs = append(envs, "GOPROXY=off")
+	}
 
 	return envs
 }
@@ -207,6 +207,10 @@ func installGoTar(goTarGzPath string) error {
 
 // Install ...
 func (toolkit GoToolkit) Install() error {
+	if err := configs.CheckNetworkAccess("installing the Go toolkit"); err != nil {
+		return err
+	}
+
 	versionStr := minGoVersionForToolkit
 	osStr := runtime.GOOS
 	archStr := runtime.GOARCH
@@ -344,6 +348,11 @@ func stepBinaryCacheFullPath(sIDData models.StepIDData) string {
 	return filepath.Join(goToolkitCacheRootPath(), stepBinaryFilename(sIDData))
 }
 
+// GoStepBinaryCachePath returns the path of the step's compiled binary in the Go toolkit's cache.
+func GoStepBinaryCachePath(sIDData models.StepIDData) string {
+	return stepBinaryCacheFullPath(sIDData)
+}
+
 // PrepareForStepRun ...
 func (toolkit GoToolkit) PrepareForStepRun(step stepmanModels.StepModel, sIDData models.StepIDData, stepAbsDirPath string) error {
 	fullStepBinPath := stepBinaryCacheFullPath(sIDData)
@@ -357,7 +366,17 @@ func (toolkit GoToolkit) PrepareForStepRun(step stepmanModels.StepModel, sIDData
 		}
 	}
 
-	// it's not cached, so compile it
+	// it's not cached, try to use the prebuilt binary
+	if installPrebuiltStepBinary(prebuiltStepBinaryClient, sIDData, stepAbsDirPath, fullStepBinPath, runtime.GOOS, runtime.GOARCH) {
+		return nil
+	}
+
+	// no prebuilt binary is available, so compile it
+	return BuildGoStep(step, stepAbsDirPath, fullStepBinPath)
+}
+
+// BuildGoStep compiles the Go step of the step directory into the output binary.
+func BuildGoStep(step stepmanModels.StepModel, stepAbsDirPath, outputBinPath string) error {
 	if step.Toolkit == nil {
 		return errors.New("No Toolkit information specified in step")
 	}
@@ -374,7 +393,7 @@ func (toolkit GoToolkit) PrepareForStepRun(step stepmanModels.StepModel, sIDData
 			"Found Go version is older than required. Please run 'bitrise setup' to check and install the required version")
 	}
 
-	return goBuildStep(&defaultRunner{}, goConfig, step.Toolkit.Go.PackageName, stepAbsDirPath, fullStepBinPath)
+	return goBuildStep(&defaultRunner{}, goConfig, step.Toolkit.Go.PackageName, stepAbsDirPath, outputBinPath)
 }
 
 // === Toolkit: Step Run ===
@@ -0,0 +1,147 @@
+package toolkits
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/tothszabi/bitrise-test/configs"
+	"github.com/tothszabi/bitrise-test/log"
+	"github.com/tothszabi/bitrise-test/models"
+)
+
+// PrebuiltStepBinaryModel is a prebuilt binary of a Go step for an OS and architecture.
+type PrebuiltStepBinaryModel struct {
+	// OS and Arch are Go's GOOS and GOARCH values, like linux and amd64.
+	OS   string `json:"os" yaml:"os"`
+	Arch string `json:"arch" yaml:"arch"`
+	URL  string `json:"url" yaml:"url"`
+	// SHA256 is the hex encoded SHA-256 checksum of the binary, required.
+	SHA256 string `json:"sha256" yaml:"sha256"`
+}
+
+// GoStepToolkitDeclarationModel is the Go toolkit declaration's part, which is not part of the stepman step model.
+type GoStepToolkitDeclarationModel struct {
+	Binaries []PrebuiltStepBinaryModel `json:"binaries,omitempty" yaml:"binaries,omitempty"`
+}
+
+var prebuiltStepBinaryClient = &http.Client{Timeout: 5 * time.Minute}
+
+// installPrebuiltStepBinary downloads the step's prebuilt binary for the given OS and architecture to outputBinPath,
+// and verifies its checksum.
+// Returns false if the step is not a StepLib step with a version (local and git steps are always compiled from their source),
+// if the step declares no prebuilt binary for the platform, or if the download or the verification failed,
+// in this case the step has to be compiled.
+func installPrebuiltStepBinary(client *http.Client, sIDData models.StepIDData, stepAbsDirPath, outputBinPath, goos, goarch string) bool {
+	if !sIDData.IsUniqueResourceID() {
+		return false
+	}
+
+	declaration, err := ReadStepToolkitDeclaration(stepAbsDirPath)
+	if err != nil {
+		log.Warnf("Failed to read the step's toolkit declaration: %s", err)
+		return false
+	}
+	if declaration.Go == nil {
+		return false
+	}
+
+	binary, found := selectPrebuiltStepBinary(declaration.Go.Binaries, goos, goarch)
+	if !found {
+		log.Debugf("No prebuilt step binary declared for %s/%s", goos, goarch)
+		return false
+	}
+
+	if err := configs.CheckNetworkAccess("downloading the prebuilt step binary"); err != nil {
+		log.Debugf("%s, the step will be compiled", err)
+		return false
+	}
+
+	log.Debugf("Downloading prebuilt step binary: %s", binary.URL)
+	if err := downloadPrebuiltStepBinary(client, binary, outputBinPath); err != nil {
+		log.Warnf("Failed to install the prebuilt step binary, the step will be compiled: %s", err)
+		return false
+	}
+	return true
+}
+
+func selectPrebuiltStepBinary(binaries []PrebuiltStepBinaryModel, goos, goarch string) (PrebuiltStepBinaryModel, bool) {
+	for _, binary := range binaries {
+		if binary.OS == goos && binary.Arch == goarch {
+			return binary, true
+		}
+	}
+	return PrebuiltStepBinaryModel{}, false
+}
+
+// downloadPrebuiltStepBinary downloads the binary next to outputBinPath,
+// and moves it to outputBinPath only if its checksum matches the declared one.
+func downloadPrebuiltStepBinary(client *http.Client, binary PrebuiltStepBinaryModel, outputBinPath string) error {
+	if binary.URL == "" {
+		return errors.New("no url declared")
+	}
+	if binary.SHA256 == "" {
+		return fmt.Errorf("no sha256 checksum declared for %s", binary.URL)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(outputBinPath), 0755); err != nil {
+		return err
+	}
+	tmpFile, err := ioutil.TempFile(filepath.Dir(outputBinPath), filepath.Base(outputBinPath)+".download-")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmpFile.Name()
+	defer func() {
+		if err := os.RemoveAll(tmpPath); err != nil {
+			log.Warnf("Failed to remove %s: %s", tmpPath, err)
+		}
+	}()
+
+	checksum, err := downloadWithChecksum(client, binary.URL, tmpFile)
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	if !strings.EqualFold(checksum, binary.SHA256) {
+		return fmt.Errorf("checksum mismatch for %s: expected %s, got %s", binary.URL, binary.SHA256, checksum)
+	}
+
+	if err := os.Chmod(tmpPath, 0755); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, outputBinPath)
+}
+
+func downloadWithChecksum(client *http.Client, url string, writer io.Writer) (string, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return "", fmt.Errorf("failed to download %s: %s", url, err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			log.Warnf("Failed to close response body: %s", err)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to download %s: status: %s", url, resp.Status)
+	}
+
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(writer, hash), resp.Body); err != nil {
+		return "", fmt.Errorf("failed to download %s: %s", url, err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,108 @@
+package toolkits
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tothszabi/bitrise-test/models"
+)
+
+func Test_installPrebuiltStepBinary(t *testing.T) {
+	binaryContent := []byte("#!/bin/sh\necho hello\n")
+	sum := sha256.Sum256(binaryContent)
+	checksum := hex.EncodeToString(sum[:])
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/step-linux-amd64", func(w http.ResponseWriter, r *http.Request) {
+		_, err := w.Write(binaryContent)
+		require.NoError(t, err)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	stepLibStep := models.StepIDData{SteplibSource: "https://github.com/bitrise-io/bitrise-steplib.git", IDorURI: "my-step", Version: "1.0.0"}
+
+	writeStepYML := func(t *testing.T, path, sha string) string {
+		dir := t.TempDir()
+		content := fmt.Sprintf(`toolkit:
+  go:
+    package_name: github.com/bitrise-steplib/my-step
+    binaries:
+    - os: linux
+      arch: amd64
+      url: %s%s
+      sha256: %s
+`, server.URL, path, sha)
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "step.yml"), []byte(content), 0600))
+		return dir
+	}
+
+	t.Log("verified download")
+	{
+		outputBinPath := filepath.Join(t.TempDir(), "cache", "my-step")
+		require.True(t, installPrebuiltStepBinary(server.Client(), stepLibStep, writeStepYML(t, "/step-linux-amd64", checksum), outputBinPath, "linux", "amd64"))
+
+		content, err := ioutil.ReadFile(outputBinPath)
+		require.NoError(t, err)
+		require.Equal(t, binaryContent, content)
+		info, err := os.Stat(outputBinPath)
+		require.NoError(t, err)
+		require.Equal(t, os.FileMode(0755), info.Mode().Perm())
+
+		files, err := ioutil.ReadDir(filepath.Dir(outputBinPath))
+		require.NoError(t, err)
+		require.Equal(t, 1, len(files))
+	}
+
+	t.Log("checksum mismatch falls back to compiling")
+	{
+		outputBinPath := filepath.Join(t.TempDir(), "my-step")
+		require.False(t, installPrebuiltStepBinary(server.Client(), stepLibStep, writeStepYML(t, "/step-linux-amd64", "0000"), outputBinPath, "linux", "amd64"))
+		require.NoFileExists(t, outputBinPath)
+		files, err := ioutil.ReadDir(filepath.Dir(outputBinPath))
+		require.NoError(t, err)
+		require.Equal(t, 0, len(files))
+	}
+
+	t.Log("failed download falls back to compiling")
+	{
+		outputBinPath := filepath.Join(t.TempDir(), "my-step")
+		require.False(t, installPrebuiltStepBinary(server.Client(), stepLibStep, writeStepYML(t, "/missing", checksum), outputBinPath, "linux", "amd64"))
+		require.NoFileExists(t, outputBinPath)
+	}
+
+	t.Log("missing checksum falls back to compiling")
+	{
+		outputBinPath := filepath.Join(t.TempDir(), "my-step")
+		require.False(t, installPrebuiltStepBinary(server.Client(), stepLibStep, writeStepYML(t, "/step-linux-amd64", ""), outputBinPath, "linux", "amd64"))
+		require.NoFileExists(t, outputBinPath)
+	}
+
+	t.Log("no binary for the platform")
+	{
+		outputBinPath := filepath.Join(t.TempDir(), "my-step")
+		require.False(t, installPrebuiltStepBinary(server.Client(), stepLibStep, writeStepYML(t, "/step-linux-amd64", checksum), outputBinPath, "darwin", "arm64"))
+		require.False(t, installPrebuiltStepBinary(server.Client(), stepLibStep, t.TempDir(), outputBinPath, "linux", "amd64"))
+		require.NoFileExists(t, outputBinPath)
+	}
+
+	t.Log("local and git steps are compiled")
+	{
+		for _, sIDData := range []models.StepIDData{
+			{SteplibSource: "path", IDorURI: "./my-step"},
+			{SteplibSource: "git", IDorURI: "https://github.com/bitrise-steplib/my-step.git", Version: "master"},
+		} {
+			outputBinPath := filepath.Join(t.TempDir(), "my-step")
+			require.False(t, installPrebuiltStepBinary(server.Client(), sIDData, writeStepYML(t, "/step-linux-amd64", checksum), outputBinPath, "linux", "amd64"))
+			require.NoFileExists(t, outputBinPath)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package toolkits
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/bitrise-io/go-utils/command"
+	"github.com/bitrise-io/go-utils/pathutil"
+	stepmanModels "github.com/bitrise-io/stepman/models"
+	"github.com/tothszabi/bitrise-test/configs"
+	"github.com/tothszabi/bitrise-test/log"
+	"github.com/tothszabi/bitrise-test/models"
+	"github.com/tothszabi/bitrise-test/utils"
+)
+
+// NodeToolkit runs the step's JavaScript entry file with node,
+// with the step's npm dependencies installed.
+type NodeToolkit struct {
+	Declaration NodeStepToolkitModel
+}
+
+// ToolkitName ...
+func (toolkit NodeToolkit) ToolkitName() string {
+	return "node"
+}
+
+// Check ...
+func (toolkit NodeToolkit) Check() (bool, ToolkitCheckResult, error) {
+	binPath, err := utils.CheckProgramInstalledPath("node")
+	if err != nil || binPath == "" {
+		return false, ToolkitCheckResult{}, nil
+	}
+
+	verOut, err := command.RunCommandAndReturnStdout("node", "--version")
+	if err != nil {
+		return false, ToolkitCheckResult{}, fmt.Errorf("Failed to check node version, error: %s", err)
+	}
+
+	return false, ToolkitCheckResult{
+		Path:    binPath,
+		Version: verOut,
+	}, nil
+}
+
+// IsToolAvailableInPATH ...
+func (toolkit NodeToolkit) IsToolAvailableInPATH() bool {
+	binPath, err := utils.CheckProgramInstalledPath("node")
+	if err != nil {
+		return false
+	}
+	return len(binPath) > 0
+}
+
+// Bootstrap ...
+func (toolkit NodeToolkit) Bootstrap() error {
+	return nil
+}
+
+// Install ...
+func (toolkit NodeToolkit) Install() error {
+	return nil
+}
+
+// PrepareForStepRun installs the step's npm dependencies (npm ci if the step has a lock file, npm install otherwise)
+// into the toolkit's cache, and links the cached node_modules into the step's directory.
+// The dependencies of a step with a unique resource ID (like a StepLib step with an exact version) are reused.
+func (toolkit NodeToolkit) PrepareForStepRun(step stepmanModels.StepModel, sIDData models.StepIDData, stepAbsDirPath string) error {
+	if !toolkit.IsToolAvailableInPATH() {
+		return fmt.Errorf("node not found in PATH, install Node.js to run the step")
+	}
+
+	return prepareNodeModules(&defaultRunner{}, stepAbsDirPath, nodeModulesCacheFullPath(sIDData), sIDData.IsUniqueResourceID())
+}
+
+// StepRunCommandArguments ...
+func (toolkit NodeToolkit) StepRunCommandArguments(step stepmanModels.StepModel, sIDData models.StepIDData, stepAbsDirPath string) ([]string, error) {
+	entryFile := "index.js"
+	if toolkit.Declaration.EntryFile != "" {
+		entryFile = toolkit.Declaration.EntryFile
+	}
+
+	return []string{"node", filepath.Join(stepAbsDirPath, entryFile)}, nil
+}
+
+func prepareNodeModules(cmdRunner commandRunner, stepAbsDirPath, cachedModulesPath string, useCache bool) error {
+	if exists, err := pathutil.IsPathExists(filepath.Join(stepAbsDirPath, "package.json")); err != nil {
+		return err
+	} else if !exists {
+		return nil
+	}
+
+	stepModulesPath := filepath.Join(stepAbsDirPath, "node_modules")
+	if info, err := os.Lstat(stepModulesPath); err == nil && info.Mode()&os.ModeSymlink == 0 {
+		log.Debugf("Step has its own node_modules, skipping npm install")
+		return nil
+	}
+
+	if useCache {
+		if exists, err := pathutil.IsPathExists(cachedModulesPath); err != nil {
+			log.Warnf("Failed to check cached node_modules for step, error: %s", err)
+		} else if exists {
+			return linkNodeModules(cachedModulesPath, stepModulesPath)
+		}
+	}
+
+	if err := os.RemoveAll(stepModulesPath); err != nil {
+		return err
+	}
+
+	installArgs := []string{"install", "--no-audit", "--no-fund"}
+	for _, lockFile := range []string{"package-lock.json", "npm-shrinkwrap.json"} {
+		if exists, err := pathutil.IsPathExists(filepath.Join(stepAbsDirPath, lockFile)); err != nil {
+			return err
+		} else if exists {
+			installArgs = []string{"ci", "--no-audit", "--no-fund"}
+			break
+		}
+	}
+	if configs.IsOfflineMode {
+		// only the packages available in the npm cache can be installed
+		installArgs = append(installArgs, "--offline")
+	}
+	if _, err := cmdRunner.runForOutput(command.New("npm", installArgs...).SetDir(stepAbsDirPath)); err != nil {
+		return fmt.Errorf("failed to install npm dependencies: %s", err)
+	}
+
+	// the step might not have any dependencies
+	if exists, err := pathutil.IsPathExists(stepModulesPath); err != nil || !exists {
+		return err
+	}
+
+	if err := os.RemoveAll(cachedModulesPath); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(cachedModulesPath), 0755); err != nil {
+		return err
+	}
+	if err := os.Rename(stepModulesPath, cachedModulesPath); err != nil {
+		return fmt.Errorf("failed to cache node_modules: %s", err)
+	}
+	return linkNodeModules(cachedModulesPath, stepModulesPath)
+}
+
+func linkNodeModules(cachedModulesPath, stepModulesPath string) error {
+	if err := os.RemoveAll(stepModulesPath); err != nil {
+		return err
+	}
+	if err := os.Symlink(cachedModulesPath, stepModulesPath); err != nil {
+		return fmt.Errorf("failed to link cached node_modules: %s", err)
+	}
+	return nil
+}
+
+// === Toolkit path utility function ===
+
+func nodeToolkitCacheRootPath() string {
+	return filepath.Join(configs.GetBitriseToolkitsDirPath(), "node", "cache")
+}
+
+func nodeModulesCacheFullPath(sIDData models.StepIDData) string {
+	return filepath.Join(nodeToolkitCacheRootPath(), stepBinaryFilename(sIDData), "node_modules")
+}
@@ -0,0 +1,148 @@
+package toolkits
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/bitrise-io/go-utils/command"
+	"github.com/bitrise-io/go-utils/pathutil"
+	stepmanModels "github.com/bitrise-io/stepman/models"
+	"github.com/tothszabi/bitrise-test/configs"
+	"github.com/tothszabi/bitrise-test/log"
+	"github.com/tothszabi/bitrise-test/models"
+	"github.com/tothszabi/bitrise-test/utils"
+)
+
+// PythonToolkit runs the step's python script in a venv,
+// with the step's requirements installed.
+type PythonToolkit struct {
+	Declaration PythonStepToolkitModel
+}
+
+// ToolkitName ...
+func (toolkit PythonToolkit) ToolkitName() string {
+	return "python"
+}
+
+// Check ...
+func (toolkit PythonToolkit) Check() (bool, ToolkitCheckResult, error) {
+	binPath, err := utils.CheckProgramInstalledPath("python3")
+	if err != nil || binPath == "" {
+		return false, ToolkitCheckResult{}, nil
+	}
+
+	verOut, err := command.RunCommandAndReturnCombinedStdoutAndStderr("python3", "--version")
+	if err != nil {
+		return false, ToolkitCheckResult{}, fmt.Errorf("Failed to check python version, error: %s", err)
+	}
+
+	return false, ToolkitCheckResult{
+		Path:    binPath,
+		Version: verOut,
+	}, nil
+}
+
+// IsToolAvailableInPATH ...
+func (toolkit PythonToolkit) IsToolAvailableInPATH() bool {
+	binPath, err := utils.CheckProgramInstalledPath("python3")
+	if err != nil {
+		return false
+	}
+	return len(binPath) > 0
+}
+
+// Bootstrap ...
+func (toolkit PythonToolkit) Bootstrap() error {
+	return nil
+}
+
+// Install ...
+func (toolkit PythonToolkit) Install() error {
+	return nil
+}
+
+// PrepareForStepRun creates the step's venv and installs the step's requirements into it.
+// The venv of a step with a unique resource ID (like a StepLib step with an exact version) is reused.
+func (toolkit PythonToolkit) PrepareForStepRun(step stepmanModels.StepModel, sIDData models.StepIDData, stepAbsDirPath string) error {
+	venvPath := pythonVenvCacheFullPath(sIDData)
+
+	if sIDData.IsUniqueResourceID() {
+		if exists, err := pathutil.IsPathExists(venvPythonPath(venvPath)); err != nil {
+			log.Warnf("Failed to check cached venv for step, error: %s", err)
+		} else if exists {
+			return nil
+		}
+	}
+
+	if !toolkit.IsToolAvailableInPATH() {
+		return fmt.Errorf("python3 not found in PATH, install Python 3 to run the step")
+	}
+
+	return createPythonVenv(&defaultRunner{}, venvPath, filepath.Join(stepAbsDirPath, toolkit.requirements()))
+}
+
+// StepRunCommandArguments ...
+func (toolkit PythonToolkit) StepRunCommandArguments(step stepmanModels.StepModel, sIDData models.StepIDData, stepAbsDirPath string) ([]string, error) {
+	entryFile := "step.py"
+	if toolkit.Declaration.EntryFile != "" {
+		entryFile = toolkit.Declaration.EntryFile
+	}
+
+	return []string{venvPythonPath(pythonVenvCacheFullPath(sIDData)), filepath.Join(stepAbsDirPath, entryFile)}, nil
+}
+
+func (toolkit PythonToolkit) requirements() string {
+	if toolkit.Declaration.Requirements != "" {
+		return toolkit.Declaration.Requirements
+	}
+	return "requirements.txt"
+}
+
+// createPythonVenv (re)creates the venv and installs the requirements file into it, if the file exists.
+func createPythonVenv(cmdRunner commandRunner, venvPath, requirementsPath string) error {
+	if err := os.RemoveAll(venvPath); err != nil {
+		return fmt.Errorf("failed to remove previous venv: %s", err)
+	}
+
+	if _, err := cmdRunner.runForOutput(command.New("python3", "-m", "venv", venvPath)); err != nil {
+		return fmt.Errorf("failed to create venv: %s", err)
+	}
+
+	exists, err := pathutil.IsPathExists(requirementsPath)
+	if err != nil {
+		return err
+	}
+	if exists {
+		installArgs := []string{"-m", "pip", "install", "--disable-pip-version-check"}
+		if configs.IsOfflineMode {
+			// only the packages available in the configured local indexes (--find-links) can be installed
+			installArgs = append(installArgs, "--no-index")
+		}
+		installArgs = append(installArgs, "-r", requirementsPath)
+
+		installCmd := command.New(venvPythonPath(venvPath), installArgs...)
+		if _, err := cmdRunner.runForOutput(installCmd); err != nil {
+			if removeErr := os.RemoveAll(venvPath); removeErr != nil {
+				log.Warnf("Failed to remove venv: %s", removeErr)
+			}
+			return fmt.Errorf("failed to install requirements: %s", err)
+		}
+	}
+
+	return nil
+}
+
+func venvPythonPath(venvPath string) string {
+	return filepath.Join(venvPath, "bin", "python")
+}
+
+// === Toolkit path utility function ===
+
+func pythonToolkitCacheRootPath() string {
+	return filepath.Join(configs.GetBitriseToolkitsDirPath(), "python", "cache")
+}
+
+func pythonVenvCacheFullPath(sIDData models.StepIDData) string {
+	return filepath.Join(pythonToolkitCacheRootPath(), stepBinaryFilename(sIDData))
+}
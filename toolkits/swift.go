@@ -1,12 +1,14 @@
 package toolkits
 
 import (
+	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"path/filepath"
 
 	stepmanModels "github.com/bitrise-io/stepman/models"
+	"github.com/tothszabi/bitrise-test/configs"
 	"github.com/tothszabi/bitrise-test/models"
 	"github.com/tothszabi/bitrise-test/utils"
 )
@@ -51,6 +53,10 @@ func (toolkit SwiftToolkit) PrepareForStepRun(step stepmanModels.StepModel, sIDD
 		return nil
 	}
 
+	if err := configs.CheckNetworkAccess(fmt.Sprintf("downloading step binary (%s)", binaryLocation)); err != nil {
+		return err
+	}
+
 	resp, err := http.Get(binaryLocation)
 	if err != nil {
 		return err
@@ -1,8 +1,15 @@
 package toolkits
 
 import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
 	stepmanModels "github.com/bitrise-io/stepman/models"
+	"github.com/tothszabi/bitrise-test/log"
 	"github.com/tothszabi/bitrise-test/models"
+	"gopkg.in/yaml.v2"
 )
 
 // ToolkitCheckResult ...
@@ -61,21 +68,84 @@ type Toolkit interface {
 //
 // === Utils ===
 
+// PythonStepToolkitModel ...
+type PythonStepToolkitModel struct {
+	// EntryFile is the step's script, step.py by default.
+	EntryFile string `json:"entry_file,omitempty" yaml:"entry_file,omitempty"`
+	// Requirements is the pip requirements file installed into the step's venv, requirements.txt by default.
+	Requirements string `json:"requirements,omitempty" yaml:"requirements,omitempty"`
+}
+
+// NodeStepToolkitModel ...
+type NodeStepToolkitModel struct {
+	// EntryFile is the step's script, index.js by default.
+	EntryFile string `json:"entry_file,omitempty" yaml:"entry_file,omitempty"`
+}
+
+// StepToolkitDeclarationModel is the toolkit section of the step.yml,
+// with the toolkits which are not part of the stepman step model.
+type StepToolkitDeclarationModel struct {
+	Go     *GoStepToolkitDeclarationModel `json:"go,omitempty" yaml:"go,omitempty"`
+	Python *PythonStepToolkitModel        `json:"python,omitempty" yaml:"python,omitempty"`
+	Node   *NodeStepToolkitModel          `json:"node,omitempty" yaml:"node,omitempty"`
+}
+
+// ReadStepToolkitDeclaration reads the toolkit declaration of the step.yml in the step's directory,
+// an empty declaration is returned if the step has no step.yml.
+func ReadStepToolkitDeclaration(stepAbsDirPath string) (StepToolkitDeclarationModel, error) {
+	pth := filepath.Join(stepAbsDirPath, "step.yml")
+	bytes, err := ioutil.ReadFile(pth)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return StepToolkitDeclarationModel{}, nil
+		}
+		return StepToolkitDeclarationModel{}, err
+	}
+
+	var stepYML struct {
+		Toolkit *StepToolkitDeclarationModel `yaml:"toolkit"`
+	}
+	if err := yaml.Unmarshal(bytes, &stepYML); err != nil {
+		return StepToolkitDeclarationModel{}, fmt.Errorf("failed to parse %s: %s", pth, err)
+	}
+	if stepYML.Toolkit == nil {
+		return StepToolkitDeclarationModel{}, nil
+	}
+	return *stepYML.Toolkit, nil
+}
+
 // ToolkitForStep ...
-func ToolkitForStep(step stepmanModels.StepModel) Toolkit {
+// The Python and Node toolkits are selected by the toolkit declaration of the step.yml in stepAbsDirPath.
+func ToolkitForStep(step stepmanModels.StepModel, stepAbsDirPath string) Toolkit {
 	var toolkit Toolkit = BashToolkit{}
 	if step.Toolkit != nil {
 		stepToolkit := step.Toolkit
 		if stepToolkit.Go != nil {
-			toolkit = GoToolkit{}
+			return GoToolkit{}
 		} else if stepToolkit.Swift != nil {
-			toolkit = SwiftToolkit{}
+			return SwiftToolkit{}
+		} else if stepToolkit.Bash != nil {
+			return toolkit
 		}
 	}
+
+	if stepAbsDirPath == "" {
+		return toolkit
+	}
+	declaration, err := ReadStepToolkitDeclaration(stepAbsDirPath)
+	if err != nil {
+		log.Warnf("Failed to read the step's toolkit declaration: %s", err)
+		return toolkit
+	}
+	if declaration.Python != nil {
+		toolkit = PythonToolkit{Declaration: *declaration.Python}
+	} else if declaration.Node != nil {
+		toolkit = NodeToolkit{Declaration: *declaration.Node}
+	}
 	return toolkit
 }
 
 // AllSupportedToolkits ...
 func AllSupportedToolkits() []Toolkit {
-	return []Toolkit{GoToolkit{}, BashToolkit{}, SwiftToolkit{}}
+	return []Toolkit{GoToolkit{}, BashToolkit{}, SwiftToolkit{}, PythonToolkit{}, NodeToolkit{}}
 }
@@ -1 +1,154 @@
 package toolkits
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/go-utils/command"
+	"github.com/bitrise-io/go-utils/pointers"
+	stepmanModels "github.com/bitrise-io/stepman/models"
+	"github.com/stretchr/testify/require"
+	"github.com/tothszabi/bitrise-test/models"
+)
+
+func TestToolkitForStep(t *testing.T) {
+	writeStepYML := func(t *testing.T, content string) string {
+		dir := t.TempDir()
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "step.yml"), []byte(content), 0600))
+		return dir
+	}
+
+	t.Log("go and swift toolkits are selected by the step model")
+	{
+		step := stepmanModels.StepModel{Toolkit: &stepmanModels.StepToolkitModel{Go: &stepmanModels.GoStepToolkitModel{PackageName: "github.com/bitrise-steplib/my-step"}}}
+		require.Equal(t, "go", ToolkitForStep(step, "").ToolkitName())
+
+		step = stepmanModels.StepModel{Toolkit: &stepmanModels.StepToolkitModel{Swift: &stepmanModels.SwiftStepToolkitModel{}}}
+		require.Equal(t, "swift", ToolkitForStep(step, "").ToolkitName())
+	}
+
+	t.Log("python toolkit")
+	{
+		dir := writeStepYML(t, "title: My step\ntoolkit:\n  python:\n    entry_file: main.py\n")
+		toolkit := ToolkitForStep(stepmanModels.StepModel{Title: pointers.NewStringPtr("My step")}, dir)
+		require.Equal(t, PythonToolkit{Declaration: PythonStepToolkitModel{EntryFile: "main.py"}}, toolkit)
+	}
+
+	t.Log("node toolkit")
+	{
+		dir := writeStepYML(t, "toolkit:\n  node: {}\n")
+		require.Equal(t, NodeToolkit{}, ToolkitForStep(stepmanModels.StepModel{Toolkit: &stepmanModels.StepToolkitModel{}}, dir))
+	}
+
+	t.Log("bash is the default")
+	{
+		require.Equal(t, BashToolkit{}, ToolkitForStep(stepmanModels.StepModel{}, writeStepYML(t, "title: My step\n")))
+		require.Equal(t, BashToolkit{}, ToolkitForStep(stepmanModels.StepModel{}, t.TempDir()))
+
+		bashStep := stepmanModels.StepModel{Toolkit: &stepmanModels.StepToolkitModel{Bash: &stepmanModels.BashStepToolkitModel{}}}
+		require.Equal(t, BashToolkit{}, ToolkitForStep(bashStep, writeStepYML(t, "toolkit:\n  python: {}\n")))
+	}
+}
+
+func TestPythonToolkitStepRunCommandArguments(t *testing.T) {
+	toolkit := PythonToolkit{}
+	args, err := toolkit.StepRunCommandArguments(stepmanModels.StepModel{}, models.StepIDData{SteplibSource: "https://github.com/bitrise-io/bitrise-steplib.git", IDorURI: "my-step", Version: "1.0.0"}, "/step")
+	require.NoError(t, err)
+	require.Equal(t, 2, len(args))
+	require.Equal(t, "/step/step.py", args[1])
+	require.Equal(t, filepath.Join(pythonToolkitCacheRootPath(), "https___github.com_bitrise-io_bitrise-steplib.git-my-step-1.0.0", "bin", "python"), args[0])
+
+	toolkit = PythonToolkit{Declaration: PythonStepToolkitModel{EntryFile: "src/main.py"}}
+	args, err = toolkit.StepRunCommandArguments(stepmanModels.StepModel{}, models.StepIDData{SteplibSource: "https://github.com/bitrise-io/bitrise-steplib.git", IDorURI: "my-step", Version: "1.0.0"}, "/step")
+	require.NoError(t, err)
+	require.Equal(t, "/step/src/main.py", args[1])
+}
+
+func Test_createPythonVenv(t *testing.T) {
+	stepDir := t.TempDir()
+	venvPath := filepath.Join(t.TempDir(), "venv")
+
+	t.Log("without requirements")
+	{
+		runner := &mockRunner{}
+		require.NoError(t, createPythonVenv(runner, venvPath, filepath.Join(stepDir, "requirements.txt")))
+		require.Equal(t, []string{`python3 "-m" "venv" "` + venvPath + `"`}, runner.cmds)
+	}
+
+	t.Log("with requirements")
+	{
+		requirementsPath := filepath.Join(stepDir, "requirements.txt")
+		require.NoError(t, ioutil.WriteFile(requirementsPath, []byte("requests==2.28.1\n"), 0600))
+
+		runner := &mockRunner{}
+		require.NoError(t, createPythonVenv(runner, venvPath, requirementsPath))
+		require.Equal(t, []string{
+			`python3 "-m" "venv" "` + venvPath + `"`,
+			venvPath + `/bin/python "-m" "pip" "install" "--disable-pip-version-check" "-r" "` + requirementsPath + `"`,
+		}, runner.cmds)
+	}
+}
+
+type npmRunner struct {
+	cmds []string
+}
+
+func (r *npmRunner) runForOutput(cmd *command.Model) (string, error) {
+	r.cmds = append(r.cmds, cmd.PrintableCommandArgs())
+	return "", os.MkdirAll(filepath.Join(cmd.GetCmd().Dir, "node_modules", "left-pad"), 0755)
+}
+
+func Test_prepareNodeModules(t *testing.T) {
+	t.Log("no package.json")
+	{
+		runner := &npmRunner{}
+		require.NoError(t, prepareNodeModules(runner, t.TempDir(), filepath.Join(t.TempDir(), "node_modules"), true))
+		require.Equal(t, 0, len(runner.cmds))
+	}
+
+	t.Log("npm ci, then the cached node_modules are linked")
+	{
+		stepDir := t.TempDir()
+		cachePath := filepath.Join(t.TempDir(), "my-step", "node_modules")
+		require.NoError(t, ioutil.WriteFile(filepath.Join(stepDir, "package.json"), []byte("{}"), 0600))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(stepDir, "package-lock.json"), []byte("{}"), 0600))
+
+		runner := &npmRunner{}
+		require.NoError(t, prepareNodeModules(runner, stepDir, cachePath, true))
+		require.Equal(t, []string{`npm "ci" "--no-audit" "--no-fund"`}, runner.cmds)
+
+		target, err := os.Readlink(filepath.Join(stepDir, "node_modules"))
+		require.NoError(t, err)
+		require.Equal(t, cachePath, target)
+		require.DirExists(t, filepath.Join(cachePath, "left-pad"))
+
+		require.NoError(t, prepareNodeModules(runner, stepDir, cachePath, true))
+		require.Equal(t, 1, len(runner.cmds))
+
+		require.NoError(t, prepareNodeModules(runner, stepDir, cachePath, false))
+		require.Equal(t, 2, len(runner.cmds))
+	}
+
+	t.Log("npm install without lock file")
+	{
+		stepDir := t.TempDir()
+		require.NoError(t, ioutil.WriteFile(filepath.Join(stepDir, "package.json"), []byte("{}"), 0600))
+
+		runner := &npmRunner{}
+		require.NoError(t, prepareNodeModules(runner, stepDir, filepath.Join(t.TempDir(), "node_modules"), true))
+		require.Equal(t, []string{`npm "install" "--no-audit" "--no-fund"`}, runner.cmds)
+	}
+
+	t.Log("vendored node_modules")
+	{
+		stepDir := t.TempDir()
+		require.NoError(t, ioutil.WriteFile(filepath.Join(stepDir, "package.json"), []byte("{}"), 0600))
+		require.NoError(t, os.MkdirAll(filepath.Join(stepDir, "node_modules"), 0755))
+
+		runner := &npmRunner{}
+		require.NoError(t, prepareNodeModules(runner, stepDir, filepath.Join(t.TempDir(), "node_modules"), true))
+		require.Equal(t, 0, len(runner.cmds))
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"time"
 
 	envman "github.com/bitrise-io/envman/cli"
@@ -20,6 +21,7 @@ import (
 	"github.com/bitrise-io/go-utils/pathutil"
 	stepman "github.com/bitrise-io/stepman/cli"
 	stepmanModels "github.com/bitrise-io/stepman/models"
+	steplib "github.com/bitrise-io/stepman/stepman"
 	"github.com/tothszabi/bitrise-test/configs"
 	"github.com/tothszabi/bitrise-test/log"
 	"github.com/tothszabi/bitrise-test/tools/timeoutcmd"
@@ -65,6 +67,10 @@ func InstallToolFromGitHub(toolname, githubUser, toolVersion string) error {
 
 // DownloadFile ...
 func DownloadFile(downloadURL, targetDirPath string) error {
+	if err := configs.CheckNetworkAccess(fmt.Sprintf("downloading %s", downloadURL)); err != nil {
+		return err
+	}
+
 	outFile, err := os.Create(targetDirPath)
 	defer func() {
 		if err := outFile.Close(); err != nil {
@@ -136,28 +142,99 @@ func InstallFromURL(toolBinName, downloadURL string) error {
 
 // StepmanSetup ...
 func StepmanSetup(collection string) error {
+	if configs.IsOfflineMode && !isLocalStepLib(collection) {
+		_, err := OfflineStepLibRoute(collection)
+		return err
+	}
+
 	log := log.NewLogger(log.GetGlobalLoggerOpts())
 	return stepman.Setup(collection, "", log)
 }
 
 // StepmanUpdate ...
 func StepmanUpdate(collection string) error {
+	if !isLocalStepLib(collection) {
+		if err := configs.CheckNetworkAccess(fmt.Sprintf("updating the StepLib (%s)", collection)); err != nil {
+			return err
+		}
+	}
+
 	log := log.NewLogger(log.GetGlobalLoggerOpts())
 	return stepman.UpdateLibrary(collection, log)
 }
 
 // StepmanActivate ...
 func StepmanActivate(collection, stepID, stepVersion, dir, ymlPth string) error {
+	if configs.IsOfflineMode {
+		// stepman downloads the step, if it is not cached yet
+		if _, err := OfflineStepCacheDirPath(collection, stepID, stepVersion); err != nil {
+			return err
+		}
+	}
+
 	log := log.NewLogger(log.GetGlobalLoggerOpts())
 	return stepman.Activate(collection, stepID, stepVersion, dir, ymlPth, false, log)
 }
 
 // StepmanStepInfo ...
 func StepmanStepInfo(collection, stepID, stepVersion string) (stepmanModels.StepInfoModel, error) {
+	if configs.IsOfflineMode {
+		switch {
+		case collection == "path":
+		case collection == "git":
+			// stepman clones the step's repository
+			if err := configs.CheckNetworkAccess(fmt.Sprintf("querying git step (%s)", stepID)); err != nil {
+				return stepmanModels.StepInfoModel{}, err
+			}
+		case !isLocalStepLib(collection):
+			// stepman sets up the StepLib, if it is not set up yet
+			if _, err := OfflineStepLibRoute(collection); err != nil {
+				return stepmanModels.StepInfoModel{}, err
+			}
+		}
+	}
+
 	log := log.NewLogger(log.GetGlobalLoggerOpts())
 	return stepman.QueryStepInfo(collection, stepID, stepVersion, log)
 }
 
+// OfflineStepLibRoute returns the route of an already set up StepLib,
+// a StepLib can not be set up in offline mode (unless it is a local StepLib).
+func OfflineStepLibRoute(collection string) (steplib.SteplibRoute, error) {
+	route, found := steplib.ReadRoute(collection)
+	if !found {
+		return steplib.SteplibRoute{}, fmt.Errorf("offline mode: StepLib (%s) is not set up", collection)
+	}
+	return route, nil
+}
+
+// OfflineStepCacheDirPath returns the StepLib cache directory of the step version,
+// which has to exist in offline mode, as the step can not be downloaded.
+func OfflineStepCacheDirPath(collection, stepID, stepVersion string) (string, error) {
+	info, err := StepmanStepInfo(collection, stepID, stepVersion)
+	if err != nil {
+		return "", err
+	}
+
+	route, found := steplib.ReadRoute(collection)
+	if !found {
+		return "", fmt.Errorf("offline mode: StepLib (%s) is not set up", collection)
+	}
+
+	cacheDir := steplib.GetStepCacheDirPath(route, info.ID, info.Version)
+	if exist, err := pathutil.IsDirExists(cacheDir); err != nil {
+		return "", err
+	} else if !exist {
+		return "", fmt.Errorf("offline mode: step (%s@%s) is not cached for StepLib (%s)", info.ID, info.Version, collection)
+	}
+	return cacheDir, nil
+}
+
+// isLocalStepLib returns true if the StepLib is a local git repository (file://...), which does not require network access.
+func isLocalStepLib(collection string) bool {
+	return strings.HasPrefix(collection, "file://")
+}
+
 // StepmanRawStepList ...
 func StepmanRawStepList(collection string) (string, error) {
 	args := []string{"step-list", "--collection", collection, "--format", "raw"}
@@ -224,6 +301,11 @@ func EnvmanAdd(envStorePth, key, value string, expand, skipIfEmpty, sensitive bo
 	return envman.AddEnv(envStorePth, key, value, expand, false, skipIfEmpty, sensitive)
 }
 
+// EnvmanAddEnv adds the env like the envman add command: the env replaces the envs with the same key, unless replace is false.
+func EnvmanAddEnv(envStorePth, key, value string, expand, replace, skipIfEmpty, sensitive bool) error {
+	return envman.AddEnv(envStorePth, key, value, expand, replace, skipIfEmpty, sensitive)
+}
+
 // EnvmanAddEnvs ...
 func EnvmanAddEnvs(envstorePth string, envsList []envmanModels.EnvironmentItemModel) error {
 	for _, env := range envsList {
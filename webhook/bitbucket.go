@@ -0,0 +1,137 @@
+package webhook
+
+import (
+	"fmt"
+	"strconv"
+)
+
+type bitbucketRepository struct {
+	Links struct {
+		HTML struct {
+			Href string `json:"href"`
+		} `json:"html"`
+	} `json:"links"`
+}
+
+type bitbucketPushPayload struct {
+	Push struct {
+		Changes []struct {
+			New *struct {
+				Type   string `json:"type"`
+				Name   string `json:"name"`
+				Target struct {
+					Hash    string `json:"hash"`
+					Message string `json:"message"`
+				} `json:"target"`
+			} `json:"new"`
+		} `json:"changes"`
+	} `json:"push"`
+	Repository bitbucketRepository `json:"repository"`
+}
+
+type bitbucketPullRequestEndpoint struct {
+	Branch struct {
+		Name string `json:"name"`
+	} `json:"branch"`
+	Commit struct {
+		Hash string `json:"hash"`
+	} `json:"commit"`
+	Repository *bitbucketRepository `json:"repository"`
+}
+
+type bitbucketPullRequestPayload struct {
+	PullRequest *struct {
+		ID          int                          `json:"id"`
+		Title       string                       `json:"title"`
+		State       string                       `json:"state"`
+		Draft       bool                         `json:"draft"`
+		Source      bitbucketPullRequestEndpoint `json:"source"`
+		Destination bitbucketPullRequestEndpoint `json:"destination"`
+	} `json:"pullrequest"`
+	Repository bitbucketRepository `json:"repository"`
+}
+
+// parseBitbucket parses Bitbucket Cloud webhooks, the payloads do not list the changed files.
+func parseBitbucket(eventType string, payload []byte) (Event, error) {
+	if eventType == "" {
+		var probe struct {
+			Push        interface{} `json:"push"`
+			PullRequest interface{} `json:"pullrequest"`
+		}
+		if err := unmarshalPayload(payload, &probe); err != nil {
+			return Event{}, err
+		}
+		if probe.PullRequest != nil {
+			eventType = "pullrequest:updated"
+		} else if probe.Push != nil {
+			eventType = "repo:push"
+		}
+	}
+
+	switch eventType {
+	case "repo:push":
+		return parseBitbucketPush(payload)
+	case "pullrequest:created", "pullrequest:updated":
+		return parseBitbucketPullRequest(payload)
+	}
+	return Event{}, fmt.Errorf("unsupported bitbucket event: %s", eventType)
+}
+
+func parseBitbucketPush(payload []byte) (Event, error) {
+	var push bitbucketPushPayload
+	if err := unmarshalPayload(payload, &push); err != nil {
+		return Event{}, err
+	}
+	// a push can update multiple refs, the last change is the triggering one
+	if len(push.Push.Changes) == 0 {
+		return Event{}, fmt.Errorf("no changes in the push")
+	}
+	change := push.Push.Changes[len(push.Push.Changes)-1]
+	if change.New == nil {
+		return Event{}, fmt.Errorf("the push deletes a branch or tag, nothing to trigger")
+	}
+
+	event := Event{
+		CommitHash:    change.New.Target.Hash,
+		CommitMessage: change.New.Target.Message,
+		RepositoryURL: push.Repository.Links.HTML.Href,
+	}
+	switch change.New.Type {
+	case "branch":
+		event.PushBranch = change.New.Name
+	case "tag", "annotated_tag":
+		event.Tag = change.New.Name
+	default:
+		return Event{}, fmt.Errorf("unsupported push change type: %s", change.New.Type)
+	}
+
+	return event, nil
+}
+
+func parseBitbucketPullRequest(payload []byte) (Event, error) {
+	var pr bitbucketPullRequestPayload
+	if err := unmarshalPayload(payload, &pr); err != nil {
+		return Event{}, err
+	}
+	if pr.PullRequest == nil {
+		return Event{}, fmt.Errorf("missing pullrequest in the payload")
+	}
+	if pr.PullRequest.State != "" && pr.PullRequest.State != "OPEN" {
+		return Event{}, fmt.Errorf("the pull request is %s, nothing to trigger", pr.PullRequest.State)
+	}
+
+	event := Event{
+		PRSourceBranch: pr.PullRequest.Source.Branch.Name,
+		PRTargetBranch: pr.PullRequest.Destination.Branch.Name,
+		CommitHash:     pr.PullRequest.Source.Commit.Hash,
+		CommitMessage:  pr.PullRequest.Title,
+		PullRequestID:  strconv.Itoa(pr.PullRequest.ID),
+		IsDraftPR:      pr.PullRequest.Draft,
+		RepositoryURL:  pr.Repository.Links.HTML.Href,
+	}
+	if pr.PullRequest.Source.Repository != nil {
+		event.PullRequestRepositoryURL = pr.PullRequest.Source.Repository.Links.HTML.Href
+	}
+
+	return event, nil
+}
@@ -0,0 +1,152 @@
+package webhook
+
+import (
+	"fmt"
+	"strconv"
+)
+
+type gitHubCommit struct {
+	ID       string   `json:"id"`
+	Message  string   `json:"message"`
+	Added    []string `json:"added"`
+	Removed  []string `json:"removed"`
+	Modified []string `json:"modified"`
+}
+
+type gitHubRepository struct {
+	CloneURL string `json:"clone_url"`
+}
+
+type gitHubPushPayload struct {
+	Ref        string           `json:"ref"`
+	After      string           `json:"after"`
+	Deleted    bool             `json:"deleted"`
+	Created    bool             `json:"created"`
+	Forced     bool             `json:"forced"`
+	HeadCommit *gitHubCommit    `json:"head_commit"`
+	Commits    []gitHubCommit   `json:"commits"`
+	Repository gitHubRepository `json:"repository"`
+}
+
+type gitHubPullRequestBranch struct {
+	Ref  string            `json:"ref"`
+	SHA  string            `json:"sha"`
+	Repo *gitHubRepository `json:"repo"`
+}
+
+type gitHubPullRequestPayload struct {
+	Action      string `json:"action"`
+	PullRequest *struct {
+		Number int    `json:"number"`
+		Title  string `json:"title"`
+		Draft  bool   `json:"draft"`
+		Labels []struct {
+			Name string `json:"name"`
+		} `json:"labels"`
+		Head gitHubPullRequestBranch `json:"head"`
+		Base gitHubPullRequestBranch `json:"base"`
+	} `json:"pull_request"`
+	Repository gitHubRepository `json:"repository"`
+}
+
+// gitHubPullRequestBuildActions are the pull request actions, which change the pull request's code or make it ready for a build,
+// the other actions (like labeled or assigned) would only rebuild the same commit.
+var gitHubPullRequestBuildActions = map[string]bool{
+	"opened":           true,
+	"synchronize":      true,
+	"reopened":         true,
+	"ready_for_review": true,
+}
+
+func parseGitHub(eventType string, payload []byte) (Event, error) {
+	if eventType == "" {
+		var probe struct {
+			PullRequest interface{} `json:"pull_request"`
+			Ref         string      `json:"ref"`
+		}
+		if err := unmarshalPayload(payload, &probe); err != nil {
+			return Event{}, err
+		}
+		if probe.PullRequest != nil {
+			eventType = "pull_request"
+		} else if probe.Ref != "" {
+			eventType = "push"
+		}
+	}
+
+	switch eventType {
+	case "push":
+		return parseGitHubPush(payload)
+	case "pull_request":
+		return parseGitHubPullRequest(payload)
+	}
+	return Event{}, fmt.Errorf("unsupported github event: %s", eventType)
+}
+
+func parseGitHubPush(payload []byte) (Event, error) {
+	var push gitHubPushPayload
+	if err := unmarshalPayload(payload, &push); err != nil {
+		return Event{}, err
+	}
+	if push.Deleted || isZeroCommitHash(push.After) {
+		return Event{}, fmt.Errorf("the push deletes %s, nothing to trigger", push.Ref)
+	}
+
+	branch, tag, err := refToBranchOrTag(push.Ref)
+	if err != nil {
+		return Event{}, err
+	}
+
+	event := Event{
+		PushBranch:    branch,
+		Tag:           tag,
+		CommitHash:    push.After,
+		RepositoryURL: push.Repository.CloneURL,
+	}
+	if push.HeadCommit != nil {
+		event.CommitMessage = push.HeadCommit.Message
+	}
+	// the commits of new branches and forced pushes are not listed completely, the changed files are unknown in these cases
+	if branch != "" && !push.Created && !push.Forced && len(push.Commits) > 0 {
+		var fileLists [][]string
+		for _, commit := range push.Commits {
+			fileLists = append(fileLists, commit.Added, commit.Removed, commit.Modified)
+		}
+		event.ChangedFiles = collectChangedFiles(fileLists...)
+	}
+
+	return event, nil
+}
+
+func parseGitHubPullRequest(payload []byte) (Event, error) {
+	var pr gitHubPullRequestPayload
+	if err := unmarshalPayload(payload, &pr); err != nil {
+		return Event{}, err
+	}
+	if pr.PullRequest == nil {
+		return Event{}, fmt.Errorf("missing pull_request in the payload")
+	}
+	if !gitHubPullRequestBuildActions[pr.Action] {
+		return Event{}, fmt.Errorf("the pull request action (%s) does not change the code, nothing to trigger", pr.Action)
+	}
+
+	event := Event{
+		PRSourceBranch: pr.PullRequest.Head.Ref,
+		PRTargetBranch: pr.PullRequest.Base.Ref,
+		CommitHash:     pr.PullRequest.Head.SHA,
+		// the payload has no head commit message, the pull request's title is used instead
+		CommitMessage: pr.PullRequest.Title,
+		PullRequestID: strconv.Itoa(pr.PullRequest.Number),
+		PRLabels:      []string{},
+		IsDraftPR:     pr.PullRequest.Draft,
+		RepositoryURL: pr.Repository.CloneURL,
+	}
+	for _, label := range pr.PullRequest.Labels {
+		event.PRLabels = append(event.PRLabels, label.Name)
+	}
+	if pr.PullRequest.Head.Repo != nil {
+		event.PullRequestRepositoryURL = pr.PullRequest.Head.Repo.CloneURL
+	}
+
+	return event, nil
+}
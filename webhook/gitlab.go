@@ -0,0 +1,144 @@
+package webhook
+
+import (
+	"fmt"
+	"strconv"
+)
+
+type gitLabProject struct {
+	GitHTTPURL string `json:"git_http_url"`
+}
+
+type gitLabPushPayload struct {
+	ObjectKind  string `json:"object_kind"`
+	Ref         string `json:"ref"`
+	Before      string `json:"before"`
+	After       string `json:"after"`
+	CheckoutSHA string `json:"checkout_sha"`
+	// TotalCommitsCount is the number of the pushed commits, the commits list contains at most 20 of them.
+	TotalCommitsCount int `json:"total_commits_count"`
+	Commits           []struct {
+		ID       string   `json:"id"`
+		Message  string   `json:"message"`
+		Added    []string `json:"added"`
+		Removed  []string `json:"removed"`
+		Modified []string `json:"modified"`
+	} `json:"commits"`
+	Project gitLabProject `json:"project"`
+}
+
+type gitLabMergeRequestPayload struct {
+	ObjectAttributes struct {
+		IID            int    `json:"iid"`
+		Title          string `json:"title"`
+		SourceBranch   string `json:"source_branch"`
+		TargetBranch   string `json:"target_branch"`
+		State          string `json:"state"`
+		Action         string `json:"action"`
+		OldRev         string `json:"oldrev"`
+		WorkInProgress bool   `json:"work_in_progress"`
+		Draft          bool   `json:"draft"`
+		LastCommit     struct {
+			ID      string `json:"id"`
+			Message string `json:"message"`
+		} `json:"last_commit"`
+		Source gitLabProject `json:"source"`
+		Target gitLabProject `json:"target"`
+	} `json:"object_attributes"`
+	Labels []struct {
+		Title string `json:"title"`
+	} `json:"labels"`
+	Project gitLabProject `json:"project"`
+}
+
+// parseGitLab parses GitLab webhooks, the event type is always read from the payload's object_kind.
+func parseGitLab(payload []byte) (Event, error) {
+	var probe struct {
+		ObjectKind string `json:"object_kind"`
+	}
+	if err := unmarshalPayload(payload, &probe); err != nil {
+		return Event{}, err
+	}
+
+	switch probe.ObjectKind {
+	case "push", "tag_push":
+		return parseGitLabPush(payload)
+	case "merge_request":
+		return parseGitLabMergeRequest(payload)
+	}
+	return Event{}, fmt.Errorf("unsupported gitlab event: %s", probe.ObjectKind)
+}
+
+func parseGitLabPush(payload []byte) (Event, error) {
+	var push gitLabPushPayload
+	if err := unmarshalPayload(payload, &push); err != nil {
+		return Event{}, err
+	}
+	if push.CheckoutSHA == "" || isZeroCommitHash(push.After) {
+		return Event{}, fmt.Errorf("the push deletes %s, nothing to trigger", push.Ref)
+	}
+
+	branch, tag, err := refToBranchOrTag(push.Ref)
+	if err != nil {
+		return Event{}, err
+	}
+
+	event := Event{
+		PushBranch:    branch,
+		Tag:           tag,
+		CommitHash:    push.CheckoutSHA,
+		RepositoryURL: push.Project.GitHTTPURL,
+	}
+	var fileLists [][]string
+	for _, commit := range push.Commits {
+		if commit.ID == push.CheckoutSHA {
+			event.CommitMessage = commit.Message
+		}
+		fileLists = append(fileLists, commit.Added, commit.Removed, commit.Modified)
+	}
+	// the commits of new branches and large pushes are not listed completely, the changed files are unknown in these cases
+	isNewBranch := push.Before == "" || isZeroCommitHash(push.Before)
+	if branch != "" && !isNewBranch && len(push.Commits) > 0 && push.TotalCommitsCount <= len(push.Commits) {
+		event.ChangedFiles = collectChangedFiles(fileLists...)
+	}
+
+	return event, nil
+}
+
+func parseGitLabMergeRequest(payload []byte) (Event, error) {
+	var mr gitLabMergeRequestPayload
+	if err := unmarshalPayload(payload, &mr); err != nil {
+		return Event{}, err
+	}
+
+	attributes := mr.ObjectAttributes
+	if attributes.State == "closed" || attributes.State == "merged" {
+		return Event{}, fmt.Errorf("the merge request is %s, nothing to trigger", attributes.State)
+	}
+	// only the updates pushing new commits (with oldrev) change the merge request's code,
+	// the other updates (like labels or description) would only rebuild the same commit
+	isBuildAction := attributes.Action == "open" || attributes.Action == "reopen" || (attributes.Action == "update" && attributes.OldRev != "")
+	if !isBuildAction {
+		return Event{}, fmt.Errorf("the merge request action (%s) does not change the code, nothing to trigger", attributes.Action)
+	}
+
+	event := Event{
+		PRSourceBranch:           attributes.SourceBranch,
+		PRTargetBranch:           attributes.TargetBranch,
+		CommitHash:               attributes.LastCommit.ID,
+		CommitMessage:            attributes.LastCommit.Message,
+		PullRequestID:            strconv.Itoa(attributes.IID),
+		PRLabels:                 []string{},
+		IsDraftPR:                attributes.Draft || attributes.WorkInProgress,
+		RepositoryURL:            attributes.Target.GitHTTPURL,
+		PullRequestRepositoryURL: attributes.Source.GitHTTPURL,
+	}
+	if event.RepositoryURL == "" {
+		event.RepositoryURL = mr.Project.GitHTTPURL
+	}
+	for _, label := range mr.Labels {
+		event.PRLabels = append(event.PRLabels, label.Title)
+	}
+
+	return event, nil
+}
@@ -0,0 +1,176 @@
+// Package webhook parses the webhook payloads of the git hosting providers into trigger events.
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/tothszabi/bitrise-test/configs"
+	"github.com/tothszabi/bitrise-test/models"
+)
+
+// Provider ...
+type Provider string
+
+const (
+	// ProviderGitHub ...
+	ProviderGitHub Provider = "github"
+	// ProviderGitLab ...
+	ProviderGitLab Provider = "gitlab"
+	// ProviderBitbucket ...
+	ProviderBitbucket Provider = "bitbucket"
+)
+
+// Providers lists the supported providers.
+var Providers = []Provider{ProviderGitHub, ProviderGitLab, ProviderBitbucket}
+
+const (
+	// GitBranchEnvKey ...
+	GitBranchEnvKey = "BITRISE_GIT_BRANCH"
+	// GitBranchDestEnvKey ...
+	GitBranchDestEnvKey = "BITRISEIO_GIT_BRANCH_DEST"
+	// GitTagEnvKey ...
+	GitTagEnvKey = "BITRISE_GIT_TAG"
+	// GitCommitEnvKey ...
+	GitCommitEnvKey = "BITRISE_GIT_COMMIT"
+	// GitMessageEnvKey ...
+	GitMessageEnvKey = "BITRISE_GIT_MESSAGE"
+	// GitRepositoryURLEnvKey ...
+	GitRepositoryURLEnvKey = "GIT_REPOSITORY_URL"
+	// PullRequestRepositoryURLEnvKey ...
+	PullRequestRepositoryURLEnvKey = "BITRISEIO_PULL_REQUEST_REPOSITORY_URL"
+)
+
+// Event is a git event parsed from a webhook payload.
+type Event struct {
+	PushBranch     string
+	PRSourceBranch string
+	PRTargetBranch string
+	Tag            string
+
+	CommitHash    string
+	CommitMessage string
+	// ChangedFiles is nil if the payload does not list the changed files.
+	ChangedFiles []string
+
+	PullRequestID string
+	// PRLabels is nil if the payload does not list the pull request labels.
+	PRLabels  []string
+	IsDraftPR bool
+
+	RepositoryURL            string
+	PullRequestRepositoryURL string
+}
+
+// IsPullRequest ...
+func (event Event) IsPullRequest() bool {
+	return event.PRSourceBranch != "" || event.PRTargetBranch != ""
+}
+
+// Envs returns the standard git related build envs of the event, the envs with empty value are omitted.
+func (event Event) Envs() map[string]string {
+	branch := event.PushBranch
+	if event.IsPullRequest() {
+		branch = event.PRSourceBranch
+	}
+
+	envs := map[string]string{}
+	for key, value := range map[string]string{
+		GitBranchEnvKey:                branch,
+		GitBranchDestEnvKey:            event.PRTargetBranch,
+		GitTagEnvKey:                   event.Tag,
+		GitCommitEnvKey:                event.CommitHash,
+		GitMessageEnvKey:               event.CommitMessage,
+		GitRepositoryURLEnvKey:         event.RepositoryURL,
+		PullRequestRepositoryURLEnvKey: event.PullRequestRepositoryURL,
+		configs.PullRequestIDEnvKey:    event.PullRequestID,
+	} {
+		if value != "" {
+			envs[key] = value
+		}
+	}
+	return envs
+}
+
+// ParseProvider ...
+func ParseProvider(provider string) (Provider, error) {
+	for _, p := range Providers {
+		if string(p) == provider {
+			return p, nil
+		}
+	}
+
+	var providers []string
+	for _, p := range Providers {
+		providers = append(providers, string(p))
+	}
+	return "", fmt.Errorf("unsupported provider: %s, supported providers: %s", provider, strings.Join(providers, ", "))
+}
+
+// Parse parses the webhook payload of the provider into an Event.
+// The eventType is the provider specific event type header (X-GitHub-Event, X-Gitlab-Event or X-Event-Key),
+// if empty, it is inferred from the payload.
+func Parse(provider Provider, eventType string, payload []byte) (Event, error) {
+	switch provider {
+	case ProviderGitHub:
+		return parseGitHub(eventType, payload)
+	case ProviderGitLab:
+		return parseGitLab(payload)
+	case ProviderBitbucket:
+		return parseBitbucket(eventType, payload)
+	}
+	return Event{}, fmt.Errorf("unsupported provider: %s", provider)
+}
+
+func unmarshalPayload(payload []byte, v interface{}) error {
+	if err := json.Unmarshal(payload, v); err != nil {
+		return fmt.Errorf("failed to parse webhook payload: %s", err)
+	}
+	return nil
+}
+
+// refToBranchOrTag splits a git ref (refs/heads/master or refs/tags/1.0.0) into a branch or a tag.
+func refToBranchOrTag(ref string) (branch, tag string, err error) {
+	switch {
+	case strings.HasPrefix(ref, "refs/heads/"):
+		return strings.TrimPrefix(ref, "refs/heads/"), "", nil
+	case strings.HasPrefix(ref, "refs/tags/"):
+		return "", strings.TrimPrefix(ref, "refs/tags/"), nil
+	}
+	return "", "", fmt.Errorf("unsupported git ref: %s", ref)
+}
+
+// isZeroCommitHash returns true for the commit hash used by the providers for deleted refs.
+func isZeroCommitHash(hash string) bool {
+	return hash != "" && strings.Trim(hash, "0") == ""
+}
+
+// collectChangedFiles returns the unique files of the given file lists, in order.
+func collectChangedFiles(fileLists ...[]string) []string {
+	changedFiles := []string{}
+	seen := map[string]bool{}
+	for _, files := range fileLists {
+		for _, file := range files {
+			if !seen[file] {
+				seen[file] = true
+				changedFiles = append(changedFiles, file)
+			}
+		}
+	}
+	return changedFiles
+}
+
+// TriggerEvent returns the event, which is matched against the trigger map items.
+func (event Event) TriggerEvent() models.TriggerEventModel {
+	return models.TriggerEventModel{
+		PushBranch:     event.PushBranch,
+		PRSourceBranch: event.PRSourceBranch,
+		PRTargetBranch: event.PRTargetBranch,
+		Tag:            event.Tag,
+		ChangedFiles:   event.ChangedFiles,
+		CommitMessage:  event.CommitMessage,
+		PRLabels:       event.PRLabels,
+		IsDraftPR:      event.IsDraftPR,
+	}
+}
@@ -0,0 +1,268 @@
+package webhook
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseGitHub(t *testing.T) {
+	t.Log("push")
+	{
+		payload := `{
+  "ref": "refs/heads/master",
+  "after": "b2b5ce1e3d1e2a3c4f5a6b7c8d9e0f1a2b3c4d5e",
+  "deleted": false,
+  "head_commit": {"id": "b2b5ce1e3d1e2a3c4f5a6b7c8d9e0f1a2b3c4d5e", "message": "Update Podfile [deploy]"},
+  "commits": [
+    {"id": "a1", "added": ["ios/Podfile"], "removed": [], "modified": ["README.md"]},
+    {"id": "b2b5ce1e3d1e2a3c4f5a6b7c8d9e0f1a2b3c4d5e", "added": [], "removed": ["old.txt"], "modified": ["ios/Podfile"]}
+  ],
+  "repository": {"clone_url": "https://github.com/bitrise-io/bitrise.git"}
+}`
+		event, err := Parse(ProviderGitHub, "", []byte(payload))
+		require.NoError(t, err)
+		require.Equal(t, Event{
+			PushBranch:    "master",
+			CommitHash:    "b2b5ce1e3d1e2a3c4f5a6b7c8d9e0f1a2b3c4d5e",
+			CommitMessage: "Update Podfile [deploy]",
+			ChangedFiles:  []string{"ios/Podfile", "README.md", "old.txt"},
+			RepositoryURL: "https://github.com/bitrise-io/bitrise.git",
+		}, event)
+	}
+
+	t.Log("tag push")
+	{
+		payload := `{"ref": "refs/tags/1.0.0", "after": "c3", "head_commit": {"message": "Release"}, "commits": [], "repository": {}}`
+		event, err := Parse(ProviderGitHub, "push", []byte(payload))
+		require.NoError(t, err)
+		require.Equal(t, Event{Tag: "1.0.0", CommitHash: "c3", CommitMessage: "Release"}, event)
+	}
+
+	t.Log("branch deletion")
+	{
+		payload := `{"ref": "refs/heads/feature", "after": "0000000000000000000000000000000000000000", "deleted": true}`
+		_, err := Parse(ProviderGitHub, "", []byte(payload))
+		require.EqualError(t, err, "the push deletes refs/heads/feature, nothing to trigger")
+	}
+
+	t.Log("push with unknown changed files")
+	{
+		for _, payload := range []string{
+			`{"ref": "refs/heads/feature", "after": "c3", "created": true, "commits": []}`,
+			`{"ref": "refs/heads/feature", "after": "c3", "created": true, "commits": [{"id": "c3", "added": ["a.go"]}]}`,
+			`{"ref": "refs/heads/feature", "after": "c3", "forced": true, "commits": [{"id": "c3", "added": ["a.go"]}]}`,
+			`{"ref": "refs/heads/feature", "after": "c3", "commits": []}`,
+		} {
+			event, err := Parse(ProviderGitHub, "push", []byte(payload))
+			require.NoError(t, err)
+			require.Equal(t, "feature", event.PushBranch)
+			require.Nil(t, event.ChangedFiles)
+		}
+	}
+
+	t.Log("pull request")
+	{
+		payload := `{
+  "action": "opened",
+  "pull_request": {
+    "number": 42,
+    "title": "Add login",
+    "draft": true,
+    "labels": [{"name": "ci:full"}],
+    "head": {"ref": "feature/login", "sha": "d4", "repo": {"clone_url": "https://github.com/fork/bitrise.git"}},
+    "base": {"ref": "master", "sha": "e5", "repo": {"clone_url": "https://github.com/bitrise-io/bitrise.git"}}
+  },
+  "repository": {"clone_url": "https://github.com/bitrise-io/bitrise.git"}
+}`
+		event, err := Parse(ProviderGitHub, "", []byte(payload))
+		require.NoError(t, err)
+		require.Equal(t, Event{
+			PRSourceBranch:           "feature/login",
+			PRTargetBranch:           "master",
+			CommitHash:               "d4",
+			CommitMessage:            "Add login",
+			PullRequestID:            "42",
+			PRLabels:                 []string{"ci:full"},
+			IsDraftPR:                true,
+			RepositoryURL:            "https://github.com/bitrise-io/bitrise.git",
+			PullRequestRepositoryURL: "https://github.com/fork/bitrise.git",
+		}, event)
+
+		require.Equal(t, map[string]string{
+			"BITRISE_GIT_BRANCH":                    "feature/login",
+			"BITRISEIO_GIT_BRANCH_DEST":             "master",
+			"BITRISE_GIT_COMMIT":                    "d4",
+			"BITRISE_GIT_MESSAGE":                   "Add login",
+			"GIT_REPOSITORY_URL":                    "https://github.com/bitrise-io/bitrise.git",
+			"BITRISEIO_PULL_REQUEST_REPOSITORY_URL": "https://github.com/fork/bitrise.git",
+			"PULL_REQUEST_ID":                       "42",
+		}, event.Envs())
+	}
+
+	t.Log("pull request actions")
+	{
+		for _, action := range []string{"opened", "synchronize", "reopened", "ready_for_review"} {
+			payload := `{"action": "` + action + `", "pull_request": {"number": 42, "head": {"ref": "feature/login", "sha": "d4"}, "base": {"ref": "master"}}}`
+			_, err := Parse(ProviderGitHub, "pull_request", []byte(payload))
+			require.NoError(t, err)
+		}
+
+		for _, action := range []string{"closed", "labeled", "assigned", "edited", "review_requested"} {
+			payload := `{"action": "` + action + `", "pull_request": {"number": 42, "head": {"ref": "feature/login", "sha": "d4"}, "base": {"ref": "master"}}}`
+			_, err := Parse(ProviderGitHub, "pull_request", []byte(payload))
+			require.EqualError(t, err, "the pull request action ("+action+") does not change the code, nothing to trigger")
+		}
+	}
+
+	t.Log("unsupported event")
+	{
+		_, err := Parse(ProviderGitHub, "issues", []byte(`{}`))
+		require.EqualError(t, err, "unsupported github event: issues")
+	}
+}
+
+func TestParseGitLab(t *testing.T) {
+	t.Log("push")
+	{
+		payload := `{
+  "object_kind": "push",
+  "ref": "refs/heads/develop",
+  "before": "e5",
+  "after": "f6",
+  "checkout_sha": "f6",
+  "total_commits_count": 2,
+  "commits": [
+    {"id": "a1", "message": "First", "added": ["a.go"], "removed": [], "modified": []},
+    {"id": "f6", "message": "Second", "added": [], "removed": [], "modified": ["a.go", "b.go"]}
+  ],
+  "project": {"git_http_url": "https://gitlab.com/bitrise/bitrise.git"}
+}`
+		event, err := Parse(ProviderGitLab, "", []byte(payload))
+		require.NoError(t, err)
+		require.Equal(t, Event{
+			PushBranch:    "develop",
+			CommitHash:    "f6",
+			CommitMessage: "Second",
+			ChangedFiles:  []string{"a.go", "b.go"},
+			RepositoryURL: "https://gitlab.com/bitrise/bitrise.git",
+		}, event)
+	}
+
+	t.Log("push with unknown changed files")
+	{
+		for _, payload := range []string{
+			`{"object_kind": "push", "ref": "refs/heads/feature", "before": "0000000000000000000000000000000000000000", "after": "f6", "checkout_sha": "f6", "total_commits_count": 1, "commits": [{"id": "f6", "added": ["a.go"]}]}`,
+			`{"object_kind": "push", "ref": "refs/heads/develop", "before": "e5", "after": "f6", "checkout_sha": "f6", "total_commits_count": 25, "commits": [{"id": "f6", "added": ["a.go"]}]}`,
+			`{"object_kind": "push", "ref": "refs/heads/develop", "before": "e5", "after": "f6", "checkout_sha": "f6", "total_commits_count": 0, "commits": []}`,
+		} {
+			event, err := Parse(ProviderGitLab, "", []byte(payload))
+			require.NoError(t, err)
+			require.Nil(t, event.ChangedFiles)
+		}
+	}
+
+	t.Log("merge request")
+	{
+		payload := `{
+  "object_kind": "merge_request",
+  "object_attributes": {
+    "iid": 7,
+    "title": "Draft: Fix",
+    "source_branch": "fix",
+    "target_branch": "main",
+    "state": "opened",
+    "action": "open",
+    "work_in_progress": true,
+    "last_commit": {"id": "g7", "message": "Fix crash"},
+    "source": {"git_http_url": "https://gitlab.com/fork/bitrise.git"},
+    "target": {"git_http_url": "https://gitlab.com/bitrise/bitrise.git"}
+  },
+  "labels": [{"title": "bug"}]
+}`
+		event, err := Parse(ProviderGitLab, "Merge Request Hook", []byte(payload))
+		require.NoError(t, err)
+		require.Equal(t, Event{
+			PRSourceBranch:           "fix",
+			PRTargetBranch:           "main",
+			CommitHash:               "g7",
+			CommitMessage:            "Fix crash",
+			PullRequestID:            "7",
+			PRLabels:                 []string{"bug"},
+			IsDraftPR:                true,
+			RepositoryURL:            "https://gitlab.com/bitrise/bitrise.git",
+			PullRequestRepositoryURL: "https://gitlab.com/fork/bitrise.git",
+		}, event)
+	}
+
+	t.Log("merge request actions")
+	{
+		for _, attributes := range []string{`"action": "open"`, `"action": "reopen"`, `"action": "update", "oldrev": "f6"`} {
+			_, err := Parse(ProviderGitLab, "", []byte(`{"object_kind": "merge_request", "object_attributes": {"state": "opened", `+attributes+`}}`))
+			require.NoError(t, err)
+		}
+
+		_, err := Parse(ProviderGitLab, "", []byte(`{"object_kind": "merge_request", "object_attributes": {"state": "opened", "action": "update"}}`))
+		require.EqualError(t, err, "the merge request action (update) does not change the code, nothing to trigger")
+
+		_, err = Parse(ProviderGitLab, "", []byte(`{"object_kind": "merge_request", "object_attributes": {"state": "opened", "action": "approved"}}`))
+		require.EqualError(t, err, "the merge request action (approved) does not change the code, nothing to trigger")
+	}
+
+	t.Log("merged merge request")
+	{
+		_, err := Parse(ProviderGitLab, "", []byte(`{"object_kind": "merge_request", "object_attributes": {"state": "merged"}}`))
+		require.EqualError(t, err, "the merge request is merged, nothing to trigger")
+	}
+}
+
+func TestParseBitbucket(t *testing.T) {
+	t.Log("push")
+	{
+		payload := `{
+  "push": {"changes": [{"new": {"type": "tag", "name": "2.0.0", "target": {"hash": "h8", "message": "Release 2.0.0"}}}]},
+  "repository": {"links": {"html": {"href": "https://bitbucket.org/bitrise/bitrise"}}}
+}`
+		event, err := Parse(ProviderBitbucket, "repo:push", []byte(payload))
+		require.NoError(t, err)
+		require.Equal(t, Event{
+			Tag:           "2.0.0",
+			CommitHash:    "h8",
+			CommitMessage: "Release 2.0.0",
+			RepositoryURL: "https://bitbucket.org/bitrise/bitrise",
+		}, event)
+	}
+
+	t.Log("pull request")
+	{
+		payload := `{
+  "pullrequest": {
+    "id": 3,
+    "title": "Feature",
+    "state": "OPEN",
+    "source": {"branch": {"name": "feature"}, "commit": {"hash": "i9"}},
+    "destination": {"branch": {"name": "master"}, "commit": {"hash": "j0"}}
+  },
+  "repository": {"links": {"html": {"href": "https://bitbucket.org/bitrise/bitrise"}}}
+}`
+		event, err := Parse(ProviderBitbucket, "", []byte(payload))
+		require.NoError(t, err)
+		require.Equal(t, Event{
+			PRSourceBranch: "feature",
+			PRTargetBranch: "master",
+			CommitHash:     "i9",
+			CommitMessage:  "Feature",
+			PullRequestID:  "3",
+			RepositoryURL:  "https://bitbucket.org/bitrise/bitrise",
+		}, event)
+	}
+}
+
+func TestParseProvider(t *testing.T) {
+	provider, err := ParseProvider("gitlab")
+	require.NoError(t, err)
+	require.Equal(t, ProviderGitLab, provider)
+
+	_, err = ParseProvider("svn")
+	require.EqualError(t, err, "unsupported provider: svn, supported providers: github, gitlab, bitbucket")
+}